@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// Elasticsearch _analyze compatibility. Offsets are in UTF-16 code units, as
+// Elasticsearch (Java) reports them, and separator/whitespace segments are not
+// emitted as tokens. Token types follow the standard tokenizer's names.
+const (
+	esTypeSoutheastAsian = "<SOUTHEAST_ASIAN>"
+	esTypeNum            = "<NUM>"
+	esTypeAlphanum       = "<ALPHANUM>"
+)
+
+// esToken is one entry of an _analyze response
+type esToken struct {
+	Token       string `json:"token"`
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+	Type        string `json:"type"`
+	Position    int    `json:"position"`
+}
+
+// analyzeTokens converts segments of input into _analyze tokens. offsetBase
+// and positionBase shift the results, for multi-valued requests. compounds,
+// when not nil, holds the parts of each segment (see khmer.SplitCompounds);
+// they follow their compound at the same position, like a decompounder's output.
+func analyzeTokens(input string, segments []string, compounds [][]string, offsetBase, positionBase int) []esToken {
+	// utf16Off[b] is the UTF-16 offset of the rune at byte offset b
+	utf16Off := make([]int, len(input)+1)
+	units := 0
+	for b, r := range input {
+		utf16Off[b] = units
+		units += utf16Len(r)
+	}
+	utf16Off[len(input)] = units
+
+	tokens := make([]esToken, 0, len(segments))
+	pos, position := 0, positionBase
+	for i, seg := range segments {
+		start, end, ok := alignSegment(input, pos, seg)
+		if !ok {
+			continue
+		}
+		pos = end
+		if isSeparatorToken(seg) {
+			continue
+		}
+		tokens = append(tokens, esToken{
+			Token:       seg,
+			StartOffset: offsetBase + utf16Off[start],
+			EndOffset:   offsetBase + utf16Off[end],
+			Type:        esTokenType(seg),
+			Position:    position,
+		})
+		if compounds != nil {
+			partPos := start
+			for _, part := range compounds[i] {
+				partStart, partEnd, ok := alignSegment(input[:end], partPos, part)
+				if !ok {
+					break
+				}
+				partPos = partEnd
+				tokens = append(tokens, esToken{
+					Token:       part,
+					StartOffset: offsetBase + utf16Off[partStart],
+					EndOffset:   offsetBase + utf16Off[partEnd],
+					Type:        esTokenType(part),
+					Position:    position,
+				})
+			}
+		}
+		position++
+	}
+	return tokens
+}
+
+// utf16Len is the number of UTF-16 code units encoding r
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// alignSegment finds seg in input at or after byte offset from, the way
+// khmer.AlignSegments does: zero-width spaces and LegacyChars rewrites are
+// accounted for, and a segment not found at from (e.g. after a filtered
+// stopword) is searched for further along. Returns the byte span of the match.
+func alignSegment(input string, from int, seg string) (int, int, bool) {
+	spans := khmer.AlignSegments(input[from:], []string{seg})
+	if len(spans) == 0 {
+		return 0, 0, false
+	}
+	return from + spans[0].Start, from + spans[0].End, true
+}
+
+// isSeparatorToken reports whether seg is only punctuation and whitespace
+func isSeparatorToken(seg string) bool {
+	for _, r := range seg {
+		if !khmer.IsSeparator(r) && !unicode.IsSpace(r) && r != '\u200b' {
+			return false
+		}
+	}
+	return true
+}
+
+func esTokenType(seg string) string {
+	hasDigit, hasKhmer := false, false
+	for _, r := range seg {
+		switch {
+		case khmer.IsDigit(r):
+			hasDigit = true
+		case khmer.IsKhmerChar(r):
+			hasKhmer = true
+		case r == ',' || r == '.':
+		default:
+			if unicode.IsLetter(r) {
+				return esTypeAlphanum
+			}
+		}
+	}
+	switch {
+	case hasKhmer:
+		return esTypeSoutheastAsian
+	case hasDigit:
+		return esTypeNum
+	}
+	return esTypeAlphanum
+}
+
+// buildAnalyzeJSON writes a record as {"id":N,"tokens":[...]} where tokens has
+// the _analyze shape
+func buildAnalyzeJSON(sb *strings.Builder, rec *record) {
+	sb.Reset()
+	sb.Grow(len(rec.input)*3 + len(rec.segments)*80 + 32)
+
+	sb.WriteString(`{"id":`)
+	writeInt(sb, rec.id)
+	sb.WriteString(`,"tokens":[`)
+	for i, tok := range analyzeTokens(rec.input, rec.segments, rec.compounds, 0, 0) {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(`{"token":"`)
+		writeEscapedJSON(sb, tok.Token)
+		sb.WriteString(`","start_offset":`)
+		writeInt(sb, tok.StartOffset)
+		sb.WriteString(`,"end_offset":`)
+		writeInt(sb, tok.EndOffset)
+		sb.WriteString(`,"type":"`)
+		sb.WriteString(tok.Type)
+		sb.WriteString(`","position":`)
+		writeInt(sb, tok.Position)
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(']')
+	if rec.timed {
+		sb.WriteString(`,"time_us":`)
+		writeInt(sb, int(rec.timeUs))
+	}
+	sb.WriteString("}\n")
+}
+
+// analyzeRequest is the subset of the Elasticsearch _analyze body we honour.
+// Text may be a string or an array of strings; analyzer/tokenizer fields are
+// accepted and ignored. Dict selects a --domain dictionary.
+type analyzeRequest struct {
+	Text json.RawMessage `json:"text"`
+	Dict string          `json:"dict"`
+}
+
+type analyzeResponse struct {
+	Tokens []esToken `json:"tokens"`
+}
+
+// Gaps between the values of a multi-valued text, as for an Elasticsearch text field
+const (
+	esPositionIncrementGap = 100
+	esOffsetGap            = 1
+)
+
+// handleAnalyze serves an Elasticsearch-compatible _analyze endpoint, so the
+// server can be used as a remote Khmer analyzer
+func (s *server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	var texts []string
+	domain := r.URL.Query().Get("dict")
+	switch r.Method {
+	case http.MethodGet:
+		texts = r.URL.Query()["text"]
+		if r.ContentLength == 0 {
+			break
+		}
+		fallthrough
+	case http.MethodPost:
+		var req analyzeRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+		parsed, err := parseAnalyzeText(req.Text)
+		if err != nil {
+			httpError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		texts = parsed
+		if req.Dict != "" {
+			domain = req.Dict
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	dict := s.dictionaryFor(w, domain)
+	if dict == nil {
+		return
+	}
+	resp := analyzeResponse{Tokens: []esToken{}}
+	offset, position := 0, 0
+	for i, text := range texts {
+		if i > 0 {
+			offset += esOffsetGap
+			position += esPositionIncrementGap
+		}
+		tokens := analyzeTokens(text, s.segment(r.Context(), text, dict), nil, offset, position)
+		resp.Tokens = append(resp.Tokens, tokens...)
+		for _, r := range text {
+			offset += utf16Len(r)
+		}
+		if len(tokens) > 0 {
+			position = tokens[len(tokens)-1].Position + 1
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parseAnalyzeText accepts "text" as a string or an array of strings
+func parseAnalyzeText(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("missing \"text\"")
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return []string{one}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, fmt.Errorf("\"text\" must be a string or an array of strings")
+	}
+	return many, nil
+}
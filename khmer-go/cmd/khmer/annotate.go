@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// annotateLine is an input line up for review, with the spans where the
+// segmenter is unsure
+type annotateLine struct {
+	goldenLine
+	segments []string
+	spans    []khmer.Ambiguity
+	// margin is how much more the runner-up segmentation costs; lines with
+	// unknown clusters and no alternative have margin 0
+	margin float32
+}
+
+// runAnnotate implements `khmer annotate`: step through the least confident
+// lines of a file, let the user accept or fix their boundaries, and append
+// each reviewed line to a gold file
+func runAnnotate(args []string) error {
+	fs := flag.NewFlagSet("annotate", flag.ExitOnError)
+	inputPath := fs.String("input", "", "Raw text file, one line per case (required)")
+	goldPath := fs.String("gold", "../data/test_cases.json", "Gold file to append reviewed lines to")
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	epsilon := fs.Float64("epsilon", 1, "Lines with a segmentation within this cost of the best one are low-confidence")
+	limit := fs.Int("limit", 0, "Review at most this many lines (0 = all low-confidence lines)")
+	delimiter := fs.String("delimiter", "|", "Token delimiter for typed corrections")
+	fs.StringVar(inputPath, "i", "", "Raw text file (short)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+	if *inputPath == "" || *delimiter == "" {
+		fmt.Fprintln(os.Stderr, "Usage: khmer annotate --input <file> [--gold test_cases.json] [options]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	raw, err := readGoldenLines(*inputPath)
+	if err != nil {
+		return err
+	}
+	gold, err := readGoldCases(*goldPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	dictionary, err := loadDictionary(*dictPath, *freqPath)
+	if err != nil {
+		return err
+	}
+	segmenter := khmer.NewKhmerSegmenter(dictionary)
+
+	known := make(map[string]bool, len(gold))
+	for _, c := range gold {
+		known[c.Input] = true
+	}
+	var queue []annotateLine
+	for _, line := range raw {
+		if known[line.text] {
+			continue
+		}
+		known[line.text] = true
+		if l, unsure := assessLine(segmenter, line, float32(*epsilon)); unsure {
+			queue = append(queue, l)
+		}
+	}
+	// Least confident first
+	sort.SliceStable(queue, func(i, j int) bool { return queue[i].margin < queue[j].margin })
+	if *limit > 0 && len(queue) > *limit {
+		queue = queue[:*limit]
+	}
+	logger.Info("Low-confidence lines", "lines", len(queue), "of", len(raw))
+
+	in := bufio.NewReader(os.Stdin)
+	added := 0
+	for i, line := range queue {
+		expected, quit, err := reviewLine(in, line, i+1, len(queue), *delimiter)
+		if err != nil {
+			return err
+		}
+		if quit {
+			break
+		}
+		if expected == nil {
+			continue
+		}
+		nextID := 0
+		for _, c := range gold {
+			if c.ID >= nextID {
+				nextID = c.ID + 1
+			}
+		}
+		gold = append(gold, goldenCase{
+			ID: nextID, Input: line.text, Expected: expected,
+			Description: fmt.Sprintf("%s:%d (annotated)", *inputPath, line.number),
+		})
+		// Written after every line, so quitting keeps what was reviewed
+		if err := writeGoldenFile(*goldPath, gold); err != nil {
+			return err
+		}
+		added++
+	}
+	logger.Info("Added test cases", "path", *goldPath, "added", added, "total", len(gold))
+	return nil
+}
+
+// assessLine segments a line and reports whether it is worth reviewing: it
+// has ambiguous spans within epsilon of the best path, or unknown clusters
+func assessLine(segmenter *khmer.KhmerSegmenter, line goldenLine, epsilon float32) (annotateLine, bool) {
+	l := annotateLine{goldenLine: line, segments: segmenter.Segment(line.text)}
+	l.spans = segmenter.Ambiguities(line.text, epsilon)
+	l.margin = epsilon + 1
+	for _, span := range l.spans {
+		if c := span.Alternatives[1].Cost; c < l.margin {
+			l.margin = c
+		}
+	}
+	for _, t := range khmer.ClassifyTokens(l.segments, segmenter.Dictionary) {
+		if t == khmer.TokenKhmerUnknown {
+			l.margin = 0
+		}
+	}
+	return l, l.margin <= epsilon
+}
+
+// reviewLine shows a line with its ambiguous spans and reads the user's
+// verdict: the expected segments, nil to skip the line, or quit
+func reviewLine(in *bufio.Reader, line annotateLine, n, total int, delimiter string) ([]string, bool, error) {
+	fmt.Printf("\n[%d/%d] line %d (margin %.2f)\n", n, total, line.number, line.margin)
+	fmt.Printf("  %s\n", strings.Join(line.segments, " "+delimiter+" "))
+	for s, span := range line.spans {
+		fmt.Printf("  span %d %q:", s+1, span.Text)
+		for a, alt := range span.Alternatives {
+			fmt.Printf("  %d) %s", a+1, strings.Join(alt.Segments, delimiter))
+			if a > 0 {
+				fmt.Printf(" (+%.2f)", alt.Cost)
+			}
+		}
+		fmt.Println()
+	}
+	for {
+		fmt.Printf("Enter accepts; numbers pick an alternative per span (\"2\", \"1 2\"); or type the line with %s between tokens; s skips, q quits\n> ", delimiter)
+		answer, err := in.ReadString('\n')
+		if err == io.EOF && answer == "" {
+			return nil, true, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, false, err
+		}
+		answer = strings.TrimRight(answer, "\r\n")
+		switch {
+		case strings.TrimSpace(answer) == "":
+			return line.segments, false, nil
+		case strings.TrimSpace(answer) == "s":
+			return nil, false, nil
+		case strings.TrimSpace(answer) == "q":
+			return nil, true, nil
+		case strings.Contains(answer, delimiter):
+			// Typed tokens are kept as they are, so spaces can be tokens
+			tokens := strings.Split(answer, delimiter)
+			if strings.Join(tokens, "") != strings.Join(line.segments, "") {
+				fmt.Println("  The tokens do not join to the line; try again")
+				continue
+			}
+			return tokens, false, nil
+		}
+		if segments, err := pickAlternatives(line, strings.FieldsFunc(answer, func(r rune) bool { return r == ' ' || r == ',' })); err != nil {
+			fmt.Printf("  %v; try again\n", err)
+		} else {
+			return segments, false, nil
+		}
+	}
+}
+
+// pickAlternatives applies the chosen alternative (1-based) of each span, in
+// order, to the line's segments; spans without a choice keep the first
+func pickAlternatives(line annotateLine, choices []string) ([]string, error) {
+	if len(line.spans) == 0 {
+		return nil, fmt.Errorf("the line has no alternatives to pick from")
+	}
+	if len(choices) > len(line.spans) {
+		return nil, fmt.Errorf("%d choices for %d spans", len(choices), len(line.spans))
+	}
+	// Boundaries are rune offsets into the joined segments, which are the
+	// text the spans index
+	runes := []rune(strings.Join(line.segments, ""))
+	bounds := make(map[int]bool)
+	pos := 0
+	for _, seg := range line.segments {
+		pos += len([]rune(seg))
+		bounds[pos] = true
+	}
+	for s, choice := range choices {
+		k, err := strconv.Atoi(choice)
+		span := line.spans[s]
+		if err != nil || k < 1 || k > len(span.Alternatives) {
+			return nil, fmt.Errorf("span %d has no alternative %q", s+1, choice)
+		}
+		if k == 1 {
+			// The segments already hold the first, post-processed
+			continue
+		}
+		for p := span.Start + 1; p < span.End; p++ {
+			delete(bounds, p)
+		}
+		pos := span.Start
+		for _, seg := range span.Alternatives[k-1].Segments {
+			pos += len([]rune(seg))
+			bounds[pos] = true
+		}
+	}
+	var segments []string
+	start := 0
+	for p := 1; p <= len(runes); p++ {
+		if bounds[p] {
+			segments = append(segments, string(runes[start:p]))
+			start = p
+		}
+	}
+	return segments, nil
+}
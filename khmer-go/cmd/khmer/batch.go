@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// maxBatchBody caps the size of a /segment/batch request
+const maxBatchBody = 16 << 20 // 16MB
+
+// defaultMaxBatch is the default --max-batch
+const defaultMaxBatch = 1000
+
+// batchRequest is the object form of a /segment/batch body
+type batchRequest struct {
+	Texts []string `json:"texts"`
+	Types bool     `json:"types"`
+	Dict  string   `json:"dict"`
+}
+
+type batchResponse struct {
+	Results []segmentResponse `json:"results"`
+}
+
+// handleBatch serves POST /segment/batch. The body is a JSON array of texts,
+// {"texts": [...]} (which may also set "types" and "dict"), or NDJSON with
+// one text or {"text": "..."} per line. Results come back in input order, as
+// {"results": [...]} or, for an NDJSON request, one JSON line per text.
+func (s *server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBody))
+	if err != nil {
+		httpError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	req := batchRequest{Dict: r.URL.Query().Get("dict")}
+	req.Types, _ = strconv.ParseBool(r.URL.Query().Get("types"))
+	ndjson := strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-ndjson")
+	if ndjson {
+		req.Texts, err = parseNDJSONTexts(body)
+	} else {
+		err = parseBatchJSON(body, &req)
+	}
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Texts) > s.maxBatch {
+		httpError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("batch of %d texts exceeds the limit of %d", len(req.Texts), s.maxBatch))
+		return
+	}
+	dict := s.dictionaryFor(w, req.Dict)
+	if dict == nil {
+		return
+	}
+
+	results := make([]segmentResponse, len(req.Texts))
+	jobs := make(chan int, len(req.Texts))
+	for k := range req.Texts {
+		jobs <- k
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	for n := min(runtime.NumCPU(), len(req.Texts)); n > 0; n-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range jobs {
+				res := segmentResponse{Segments: s.segment(r.Context(), strings.TrimSpace(req.Texts[k]), dict)}
+				if req.Types {
+					res.Types = khmer.ClassifyTokens(res.Segments, dict)
+				}
+				results[k] = res
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !ndjson {
+		writeJSON(w, http.StatusOK, batchResponse{Results: results})
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, res := range results {
+		enc.Encode(res)
+	}
+	bw.Flush()
+}
+
+// parseBatchJSON accepts a JSON array of texts or a batchRequest object
+func parseBatchJSON(body []byte, req *batchRequest) error {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &req.Texts); err != nil {
+			return fmt.Errorf("invalid JSON: expected an array of strings: %w", err)
+		}
+		return nil
+	}
+	var obj batchRequest
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	req.Texts = obj.Texts
+	req.Types = req.Types || obj.Types
+	if obj.Dict != "" {
+		req.Dict = obj.Dict
+	}
+	return nil
+}
+
+// parseNDJSONTexts reads one text per line, plain or {"text": "..."}
+func parseNDJSONTexts(body []byte) ([]string, error) {
+	var texts []string
+	if len(bytes.TrimSpace(body)) == 0 {
+		return texts, nil
+	}
+	for n, line := range strings.Split(strings.TrimRight(string(body), "\r\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "{") {
+			var obj streamLine
+			if err := json.Unmarshal([]byte(line), &obj); err != nil {
+				return nil, fmt.Errorf("line %d: invalid JSON: %w", n+1, err)
+			}
+			line = obj.Text
+		}
+		texts = append(texts, line)
+	}
+	return texts, nil
+}
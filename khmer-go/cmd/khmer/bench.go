@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// builtinImpl is the name of the in-process Go segmenter in bench reports
+const builtinImpl = "go"
+
+// benchImpl is a segmenter implementation under test: a command template, an
+// HTTP endpoint, or (with an empty spec) the in-process segmenter
+type benchImpl struct {
+	name string
+	spec string
+}
+
+// benchRun is the output of one implementation over one set of lines
+type benchRun struct {
+	segments [][]string
+	elapsed  time.Duration
+	// reported is the "Speed: N lines/sec" the implementation printed, if any
+	reported float64
+}
+
+// benchResult is one row of the bench report
+type benchResult struct {
+	Name          string  `json:"name"`
+	Error         string  `json:"error,omitempty"`
+	Lines         int     `json:"lines"`
+	Seconds       float64 `json:"seconds"`
+	LinesPerSec   float64 `json:"lines_per_sec"`
+	ReportedSpeed float64 `json:"reported_lines_per_sec,omitempty"`
+	Agreement     float64 `json:"agreement_pct"`
+	GoldCases     int     `json:"gold_cases"`
+	GoldExact     float64 `json:"gold_exact_pct"`
+	Precision     float64 `json:"boundary_precision"`
+	Recall        float64 `json:"boundary_recall"`
+	F1            float64 `json:"boundary_f1"`
+}
+
+// benchEnv holds what every implementation run needs
+type benchEnv struct {
+	dictPath, freqPath string
+	dictionary         *khmer.Dictionary
+	tmpDir             string
+	client             *http.Client
+}
+
+// parseImpl parses a --impl value of the form name=spec
+func parseImpl(v string) (benchImpl, error) {
+	name, spec, ok := strings.Cut(v, "=")
+	name, spec = strings.TrimSpace(name), strings.TrimSpace(spec)
+	if !ok || name == "" || spec == "" {
+		return benchImpl{}, fmt.Errorf("invalid --impl %q (want name=command or name=http://host/segment)", v)
+	}
+	if name == builtinImpl {
+		return benchImpl{}, fmt.Errorf("--impl name %q is reserved for the built-in segmenter", name)
+	}
+	if !isURL(spec) && !strings.Contains(spec, "{output}") {
+		return benchImpl{}, fmt.Errorf("--impl %s: command must contain {output}", name)
+	}
+	return benchImpl{name: name, spec: spec}, nil
+}
+
+func isURL(spec string) bool {
+	return strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://")
+}
+
+// runBench implements `khmer bench`: run several segmenter implementations on
+// the same input and report their speed, agreement and accuracy side by side
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	inputPath := fs.String("input", "../data/test_input.txt", "Workload text file, one line per segmentation")
+	goldPath := fs.String("gold", "../data/test_cases.json", "Gold test cases for accuracy (empty = skip)")
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	limit := fs.Int("limit", 0, "Limit number of workload lines (0 = unlimited)")
+	reference := fs.String("reference", builtinImpl, "Implementation the others' agreement is measured against")
+	noBuiltin := fs.Bool("no-builtin", false, "Do not run the in-process Go segmenter")
+	reportPath := fs.String("report", "", "Also write the report as JSON to this file")
+	var specs stringList
+	fs.Var(&specs, "impl", "Implementation as name=command (with {input} {output} {dict} {freq}) or name=URL; repeatable")
+	fs.StringVar(inputPath, "i", "../data/test_input.txt", "Workload text file (short)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	var impls []benchImpl
+	if !*noBuiltin {
+		impls = append(impls, benchImpl{name: builtinImpl})
+	}
+	seen := map[string]bool{builtinImpl: true}
+	for _, v := range specs {
+		impl, err := parseImpl(v)
+		if err != nil {
+			return err
+		}
+		if seen[impl.name] {
+			return fmt.Errorf("duplicate --impl name %q", impl.name)
+		}
+		seen[impl.name] = true
+		impls = append(impls, impl)
+	}
+	if len(impls) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: khmer bench [--impl name=command|URL ...] [options]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	lines, _, err := readLines(*inputPath, 0, *limit, maxLineBytes, nil)
+	if err != nil {
+		return err
+	}
+	var gold []goldenCase
+	if *goldPath != "" {
+		cases, err := readGoldCases(*goldPath)
+		if err != nil {
+			return err
+		}
+		// Command-line implementations read trimmed, non-empty lines, so
+		// cases they cannot be given verbatim are left out for everyone
+		for _, c := range cases {
+			if c.Input != "" && c.Input == strings.TrimSpace(c.Input) && !strings.ContainsAny(c.Input, "\r\n") {
+				gold = append(gold, c)
+			}
+		}
+		if skipped := len(cases) - len(gold); skipped > 0 {
+			logger.Info("Skipping gold cases that are not a single trimmed line", "count", skipped)
+		}
+	}
+
+	env := &benchEnv{dictPath: *dictPath, freqPath: *freqPath, client: &http.Client{Timeout: time.Minute}}
+	if !*noBuiltin {
+		if env.dictionary, err = loadDictionary(*dictPath, *freqPath); err != nil {
+			return err
+		}
+	}
+	if env.tmpDir, err = os.MkdirTemp("", "khmer-bench-"); err != nil {
+		return err
+	}
+	defer os.RemoveAll(env.tmpDir)
+
+	results := make([]benchResult, len(impls))
+	outputs := make(map[string][][]string, len(impls))
+	for i, impl := range impls {
+		res := &results[i]
+		res.Name = impl.name
+		logger.Info("Benchmarking", "impl", impl.name, "lines", len(lines))
+		run, err := impl.run(env, "workload", lines)
+		if err != nil {
+			res.Error = err.Error()
+			logger.Error("Implementation failed", "impl", impl.name, "error", err)
+			continue
+		}
+		outputs[impl.name] = run.segments
+		res.Lines = len(lines)
+		res.Seconds = run.elapsed.Seconds()
+		if res.Seconds > 0 {
+			res.LinesPerSec = float64(len(lines)) / res.Seconds
+		}
+		res.ReportedSpeed = run.reported
+
+		if len(gold) > 0 {
+			if err := scoreGold(impl, env, gold, res); err != nil {
+				res.Error = err.Error()
+				logger.Error("Gold run failed", "impl", impl.name, "error", err)
+			}
+		}
+	}
+
+	ref, ok := outputs[*reference]
+	if !ok {
+		logger.Warn("Reference implementation has no output; agreement not measured", "reference", *reference)
+	}
+	for i := range results {
+		if out, found := outputs[results[i].Name]; found && ok {
+			results[i].Agreement = agreement(ref, out)
+		}
+	}
+
+	printBenchReport(results, *reference)
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*reportPath, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		logger.Info("Wrote bench report", "path", *reportPath)
+	}
+	return nil
+}
+
+// run segments lines with the implementation and times it. For commands the
+// time includes process start and dictionary load; the in-process segmenter
+// is timed single-threaded with the dictionary already loaded.
+func (b benchImpl) run(env *benchEnv, label string, lines []string) (*benchRun, error) {
+	switch {
+	case b.spec == "":
+		seg := khmer.NewKhmerSegmenter(env.dictionary)
+		run := &benchRun{segments: make([][]string, len(lines))}
+		start := time.Now()
+		for i, line := range lines {
+			run.segments[i] = seg.Segment(line)
+		}
+		run.elapsed = time.Since(start)
+		return run, nil
+	case isURL(b.spec):
+		return b.runHTTP(env, lines)
+	default:
+		return b.runCommand(env, label, lines)
+	}
+}
+
+// runCommand writes lines to a file, runs the command template on it and
+// reads back its JSON lines output
+func (b benchImpl) runCommand(env *benchEnv, label string, lines []string) (*benchRun, error) {
+	inPath := filepath.Join(env.tmpDir, b.name+"-"+label+".txt")
+	outPath := filepath.Join(env.tmpDir, b.name+"-"+label+".json")
+	if err := os.WriteFile(inPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return nil, err
+	}
+	replacer := strings.NewReplacer("{input}", inPath, "{output}", outPath, "{dict}", env.dictPath, "{freq}", env.freqPath)
+	argv := strings.Fields(b.spec)
+	for i, arg := range argv {
+		argv[i] = replacer.Replace(arg)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	start := time.Now()
+	err := cmd.Run()
+	elapsed := time.Since(start)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	segments, err := readSegmentations(outPath, lines)
+	if err != nil {
+		return nil, err
+	}
+	return &benchRun{segments: segments, elapsed: elapsed, reported: reportedSpeed(stdout.String())}, nil
+}
+
+// runHTTP posts each line to a /segment-style endpoint
+func (b benchImpl) runHTTP(env *benchEnv, lines []string) (*benchRun, error) {
+	run := &benchRun{segments: make([][]string, len(lines))}
+	start := time.Now()
+	for i, line := range lines {
+		body, _ := json.Marshal(segmentRequest{Text: line})
+		resp, err := env.client.Post(b.spec, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		var out segmentResponse
+		err = json.NewDecoder(resp.Body).Decode(&out)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("line %d: %s", i+1, resp.Status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		run.segments[i] = out.Segments
+	}
+	run.elapsed = time.Since(start)
+	return run, nil
+}
+
+// readSegmentations reads a JSON lines output and returns its segments in
+// record id order, checking there is exactly one record per input line
+func readSegmentations(path string, lines []string) ([][]string, error) {
+	r, err := openRecords(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.close()
+	var records []*outputRecord
+	for {
+		rec, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			break
+		}
+		records = append(records, rec)
+	}
+	if len(records) != len(lines) {
+		return nil, fmt.Errorf("%d records for %d input lines", len(records), len(lines))
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	segments := make([][]string, len(records))
+	for i, rec := range records {
+		if rec.Input != lines[i] {
+			return nil, fmt.Errorf("record %d does not match input line %d", rec.ID, i+1)
+		}
+		segments[i] = rec.Segments
+	}
+	return segments, nil
+}
+
+// reportedSpeed extracts the lines/sec from a "Speed: N lines/sec" line
+func reportedSpeed(stdout string) float64 {
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "Speed:" {
+			if v, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				return v
+			}
+		}
+	}
+	return 0
+}
+
+// scoreGold runs the gold inputs through the implementation and fills in the
+// exact-match rate and boundary precision/recall/F1
+func scoreGold(impl benchImpl, env *benchEnv, gold []goldenCase, res *benchResult) error {
+	inputs := make([]string, len(gold))
+	for i, c := range gold {
+		inputs[i] = c.Input
+	}
+	run, err := impl.run(env, "gold", inputs)
+	if err != nil {
+		return err
+	}
+	scoreSegmentations(run.segments, gold, res)
+	return nil
+}
+
+// scoreSegmentations fills in the exact-match rate and boundary
+// precision/recall/F1 of segments, one per gold case
+func scoreSegmentations(segments [][]string, gold []goldenCase, res *benchResult) {
+	exact, truePos, falsePos, falseNeg := 0, 0, 0, 0
+	for i, c := range gold {
+		got := segments[i]
+		if reflect.DeepEqual(got, c.Expected) {
+			exact++
+		}
+		d, err := khmer.DiffSegmentations(got, c.Expected)
+		if errors.Is(err, khmer.ErrTextMismatch) {
+			// Output that does not cover the input gets no credit
+			falsePos += len(khmer.Boundaries(got))
+			falseNeg += len(khmer.Boundaries(c.Expected))
+			continue
+		}
+		truePos += len(d.Common)
+		falsePos += len(d.OnlyA)
+		falseNeg += len(d.OnlyB)
+	}
+	res.GoldCases = len(gold)
+	res.GoldExact = percent(exact, len(gold))
+	res.Precision = ratio(truePos, truePos+falsePos)
+	res.Recall = ratio(truePos, truePos+falseNeg)
+	if res.Precision+res.Recall > 0 {
+		res.F1 = 2 * res.Precision * res.Recall / (res.Precision + res.Recall)
+	}
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+// agreement returns the percentage of lines segmented identically
+func agreement(a, b [][]string) float64 {
+	same := 0
+	for i := range a {
+		if reflect.DeepEqual(a[i], b[i]) {
+			same++
+		}
+	}
+	return percent(same, len(a))
+}
+
+func printBenchReport(results []benchResult, reference string) {
+	fmt.Printf("%-12s %8s %10s %12s %12s %10s %8s %8s\n",
+		"impl", "lines", "seconds", "lines/sec", "reported", "agree", "exact", "F1")
+	for _, r := range results {
+		if r.Error != "" && r.Lines == 0 {
+			fmt.Printf("%-12s failed: %s\n", r.Name, r.Error)
+			continue
+		}
+		reported := "-"
+		if r.ReportedSpeed > 0 {
+			reported = fmt.Sprintf("%.2f", r.ReportedSpeed)
+		}
+		gold := fmt.Sprintf("%7.2f%% %8.4f", r.GoldExact, r.F1)
+		if r.GoldCases == 0 {
+			gold = fmt.Sprintf("%8s %8s", "-", "-")
+		}
+		fmt.Printf("%-12s %8d %10.3f %12.2f %12s %9.2f%% %s\n",
+			r.Name, r.Lines, r.Seconds, r.LinesPerSec, reported, r.Agreement, gold)
+	}
+	fmt.Printf("Agreement is against %q; exact and F1 are against the gold set.\n", reference)
+}
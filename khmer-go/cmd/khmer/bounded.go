@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ringSlots caps how many lines may be in flight in bounded mode regardless
+// of their size
+const ringSlots = 4096
+
+// outputExpansion estimates output bytes per input byte (input echo plus
+// escaped segments) for the memory budget
+const outputExpansion = 3
+
+// parseSize parses a byte size such as "512MB", "2G" or "1048576"
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	if s == "" {
+		return 0, nil
+	}
+	mult := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"G", 1 << 30}, {"MB", 1 << 20}, {"M", 1 << 20}, {"KB", 1 << 10}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			mult = unit.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
+
+// reorderRing holds finished records until they can be written in input order.
+// Producers block while their sequence number is more than len(slots) ahead of
+// the writer, and while the byte budget is exhausted.
+type reorderRing struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	slots  []string
+	filled []bool
+	// offsets[i] is the input offset after the line of slot i
+	offsets []int64
+	next    int // next sequence number to write
+	issued  int // sequence numbers handed out to the reader so far
+
+	budget int64
+	used   int64
+	cost   []int64
+	closed bool
+}
+
+func newReorderRing(size int, budget int64) *reorderRing {
+	r := &reorderRing{
+		slots:   make([]string, size),
+		filled:  make([]bool, size),
+		offsets: make([]int64, size),
+		cost:    make([]int64, size),
+		budget:  budget,
+	}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+// reserve blocks until a line costing n bytes may enter the window, then
+// returns its sequence number. offset is the input offset after the line,
+// handed back to the writer with its record. A single line larger than the
+// budget is admitted when nothing else is in flight.
+func (r *reorderRing) reserve(n, offset int64) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.issued-r.next >= len(r.slots) || (r.used > 0 && r.used+n > r.budget) {
+		r.cond.Wait()
+	}
+	seq := r.issued
+	r.issued++
+	r.used += n
+	r.cost[seq%len(r.slots)] = n
+	r.offsets[seq%len(r.slots)] = offset
+	return seq
+}
+
+// put stores a finished record
+func (r *reorderRing) put(seq int, out string) {
+	r.mu.Lock()
+	idx := seq % len(r.slots)
+	r.slots[idx] = out
+	r.filled[idx] = true
+	r.mu.Unlock()
+	r.cond.Broadcast()
+}
+
+// finish marks the input as exhausted so the writer can stop
+func (r *reorderRing) finish() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.cond.Broadcast()
+}
+
+// drain writes records, with the input offset after their line, in order as
+// they become available until finish has been called and every issued record
+// is written
+func (r *reorderRing) drain(write func(out string, offset int64)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		idx := r.next % len(r.slots)
+		for r.next < r.issued && r.filled[idx] {
+			out, offset := r.slots[idx], r.offsets[idx]
+			r.slots[idx] = ""
+			r.filled[idx] = false
+			r.used -= r.cost[idx]
+			r.next++
+			r.mu.Unlock()
+			write(out, offset)
+			r.mu.Lock()
+			idx = r.next % len(r.slots)
+		}
+		r.cond.Broadcast()
+		if r.closed && r.next == r.issued {
+			return
+		}
+		r.cond.Wait()
+	}
+}
+
+// runBounded streams the input through the workers keeping at most
+// opts.maxMemory bytes (estimated) of lines and results in flight. Output
+// order matches input order. With opts.resume, progress is saved to a
+// checkpoint every checkpointInterval and on Ctrl-C, and a run finding a
+// checkpoint for its input continues from it; the checkpoint is removed once
+// the input is done.
+func runBounded(opts options, proc *processor, numWorkers int) error {
+	logger.Info("Streaming source", "path", opts.inputPath, "max_memory", opts.maxMemory, "workers", numWorkers)
+	if opts.timing {
+		logger.Warn("Timing summary is not kept with --max-memory; per-record time_us is still written")
+	}
+
+	inputFile, err := openInput(opts.inputPath)
+	if err != nil {
+		return err
+	}
+	defer inputFile.Close()
+
+	// Lines and offset already done by an interrupted run
+	var resumed checkpoint
+	cpPath := checkpointPath(opts.outputPath)
+	if opts.resume {
+		cp, err := loadCheckpoint(cpPath)
+		if err != nil {
+			return err
+		}
+		if cp != nil && cp.Input != opts.inputPath {
+			return fmt.Errorf("checkpoint %s is for input %s; remove it to start over", cpPath, cp.Input)
+		}
+		if cp != nil {
+			resumed = *cp
+			logger.Info("Resuming from checkpoint", "path", cpPath, "lines", cp.Lines, "offset", cp.Offset)
+			seeker, ok := inputFile.(io.Seeker)
+			if !ok {
+				return fmt.Errorf("cannot resume remote input %s", opts.inputPath)
+			}
+			if _, err := seeker.Seek(cp.Offset, io.SeekStart); err != nil {
+				return err
+			}
+		}
+	}
+	skip := opts.skip
+	if resumed.Lines > 0 {
+		// The skipped lines are behind the checkpoint
+		skip = 0
+	}
+
+	var output *outputWriter
+	if opts.outputPath != "" {
+		if resumed.OutputBytes > 0 {
+			output, err = appendOutput(opts.outputPath, resumed.OutputBytes)
+		} else {
+			output, err = createOutput(opts.outputPath, proc.format.header, opts.shardSize)
+		}
+		if err != nil {
+			return err
+		}
+		defer output.close()
+	}
+
+	ctx := context.Background()
+	if opts.resume {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+	}
+
+	startProcess := time.Now()
+	ring := newReorderRing(ringSlots, opts.maxMemory)
+
+	type job struct {
+		seq, id int
+		line    string
+	}
+	jobs := make(chan job, numWorkers)
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wk := proc.newWorker()
+			defer wk.close()
+			for j := range jobs {
+				out, _ := wk.process(j.id, j.line)
+				ring.put(j.seq, out)
+			}
+		}()
+	}
+
+	// progress is what the writer has written; it is read once writeDone is closed
+	writeDone := make(chan struct{})
+	var writeErr error
+	progress := resumed
+	progress.Input = opts.inputPath
+	saveCheckpoint := func() error {
+		size, err := output.mark()
+		if err != nil {
+			return err
+		}
+		progress.OutputBytes = size
+		return progress.save(cpPath)
+	}
+	go func() {
+		defer close(writeDone)
+		lastSave := time.Now()
+		ring.drain(func(out string, offset int64) {
+			if output == nil || writeErr != nil {
+				return
+			}
+			if writeErr = output.write(out); writeErr != nil {
+				return
+			}
+			progress.Offset = offset
+			progress.Lines++
+			if opts.resume && time.Since(lastSave) >= checkpointInterval {
+				writeErr = saveCheckpoint()
+				lastSave = time.Now()
+			}
+		})
+	}()
+
+	numLines := 0
+	// nextID is the id of the next non-empty line: its index among the
+	// non-empty lines of the input, whatever is skipped or filtered. A resumed
+	// run starts after the skipped lines and the records already written.
+	nextID := 0
+	if resumed.Lines > 0 {
+		nextID = opts.skip + resumed.Lines
+	}
+	consumed := resumed.Offset
+	scanner := newLineScanner(inputFile, opts.maxLine())
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		consumed += int64(advance)
+		return advance, token, err
+	})
+	for ctx.Err() == nil && scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		id := nextID
+		nextID++
+		if id < skip || !proc.input.keep(line) {
+			continue
+		}
+		seq := ring.reserve(int64(len(line))*(1+outputExpansion), consumed)
+		jobs <- job{seq: seq, id: id, line: line}
+		numLines++
+		if opts.limit > 0 && resumed.Lines+numLines >= opts.limit {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	ring.finish()
+	<-writeDone
+	if err := scanner.Err(); err != nil {
+		return lineError(err, opts.maxLine())
+	}
+	if output != nil {
+		if writeErr != nil {
+			return writeErr
+		}
+		if ctx.Err() != nil {
+			if err := saveCheckpoint(); err != nil {
+				return err
+			}
+			return fmt.Errorf("interrupted after %d lines; run again with --resume to continue", progress.Lines)
+		}
+		if err := output.close(); err != nil {
+			return err
+		}
+		if opts.resume {
+			if err := os.Remove(cpPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		output.logSaved()
+	}
+
+	duration := time.Since(startProcess).Seconds()
+	fmt.Printf("Time taken: %.2fs\n", duration)
+	fmt.Printf("Speed: %.2f lines/sec\n", float64(numLines)/duration)
+	return nil
+}
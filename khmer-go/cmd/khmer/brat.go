@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// bratConf declares the token types as brat entity types, so a collection
+// of exported documents loads without configuration warnings
+const bratConf = `[entities]
+KHMER_WORD
+KHMER_UNKNOWN
+NUMBER
+CURRENCY
+PUNCT
+LATIN
+ACRONYM
+SYMBOL
+
+[relations]
+
+[events]
+
+[attributes]
+`
+
+// bratDoc is an open .txt/.ann pair
+type bratDoc struct {
+	txt, ann *os.File
+	w        *khmer.BratWriter
+}
+
+func createBratDoc(base string) (*bratDoc, error) {
+	txt, err := os.Create(base + ".txt")
+	if err != nil {
+		return nil, err
+	}
+	ann, err := os.Create(base + ".ann")
+	if err != nil {
+		txt.Close()
+		return nil, err
+	}
+	return &bratDoc{txt: txt, ann: ann, w: khmer.NewBratWriter(txt, ann)}, nil
+}
+
+func (d *bratDoc) close() error {
+	err := d.w.Flush()
+	if cerr := d.txt.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := d.ann.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// runBrat implements `khmer brat`: segment a text file into brat standoff
+// documents, a .txt of the lines and an .ann of typed token spans
+func runBrat(args []string) error {
+	fs := flag.NewFlagSet("brat", flag.ExitOnError)
+	inputPath := fs.String("input", "", "Text file to segment (required)")
+	outDir := fs.String("out", ".", "Directory for the .txt/.ann documents")
+	name := fs.String("name", "", "Document name (default: the input file name without extension)")
+	linesPerDoc := fs.Int("lines-per-doc", 0, "Start a new document (name-00001, ...) every n lines (0 = one document)")
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	fs.StringVar(inputPath, "i", "", "Text file to segment (short)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+	if *inputPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: khmer brat --input <file> [--out <dir>] [options]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	if *name == "" {
+		*name = strings.TrimSuffix(filepath.Base(*inputPath), filepath.Ext(*inputPath))
+	}
+
+	dictionary, err := loadDictionary(*dictPath, *freqPath)
+	if err != nil {
+		return err
+	}
+	segmenter := khmer.NewKhmerSegmenter(dictionary)
+
+	input, err := os.Open(*inputPath)
+	if err != nil {
+		return fmt.Errorf("input file not found: %w", err)
+	}
+	defer input.Close()
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+	// An existing configuration is the user's and is kept
+	confPath := filepath.Join(*outDir, "annotation.conf")
+	if _, err := os.Stat(confPath); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(confPath, []byte(bratConf), 0o644); err != nil {
+			return err
+		}
+	}
+
+	base := filepath.Join(*outDir, *name)
+	var doc *bratDoc
+	docs, lines := 0, 0
+	scanner := bufio.NewScanner(input)
+	const maxCapacity = 1024 * 1024 // 1MB
+	scanner.Buffer(make([]byte, maxCapacity), maxCapacity)
+	for scanner.Scan() {
+		if doc == nil || (*linesPerDoc > 0 && lines%*linesPerDoc == 0) {
+			if doc != nil {
+				if err := doc.close(); err != nil {
+					return err
+				}
+			}
+			docs++
+			path := base
+			if *linesPerDoc > 0 {
+				path = shardPath(base, docs)
+			}
+			if doc, err = createBratDoc(path); err != nil {
+				return err
+			}
+		}
+		line := scanner.Text()
+		spans := segmenter.SegmentSpans(line)
+		segments := make([]string, len(spans))
+		for i, sp := range spans {
+			segments[i] = sp.Text
+		}
+		if err := doc.w.WriteLine(line, spans, khmer.ClassifyTokens(segments, dictionary)); err != nil {
+			doc.close()
+			return err
+		}
+		lines++
+	}
+	if doc != nil {
+		if err := doc.close(); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	logger.Info("Wrote brat documents", "dir", *outDir, "documents", docs, "lines", lines)
+	return nil
+}
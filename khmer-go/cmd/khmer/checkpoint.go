@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// checkpointInterval is how often a --resume run records its progress
+const checkpointInterval = 10 * time.Second
+
+// defaultResumeMemory is the in-flight budget of a --resume run without
+// --max-memory, which streams the input like one
+const defaultResumeMemory = 256 << 20
+
+// checkpoint records how far a streaming run got: the records of every line
+// before Offset in the input are the first OutputBytes bytes of the output
+type checkpoint struct {
+	Input       string `json:"input"`
+	Offset      int64  `json:"offset"`
+	Lines       int    `json:"lines"`
+	OutputBytes int64  `json:"output_bytes"`
+}
+
+// checkpointPath names the checkpoint of a run writing to outputPath
+func checkpointPath(outputPath string) string {
+	return outputPath + ".checkpoint"
+}
+
+// loadCheckpoint reads the checkpoint at path; it returns nil when there is none
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// save writes the checkpoint to path, replacing the previous one atomically
+// so a crash never leaves a partial checkpoint
+func (cp *checkpoint) save(path string) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("could not write checkpoint: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeResumeInput writes the test subset, n times over with blank lines
+// between, and returns its path and non-empty lines
+func writeResumeInput(t *testing.T, dir string, n int) (string, []string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "data", "test_subset.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	var all []string
+	for i := 0; i < n; i++ {
+		all = append(all, lines...)
+	}
+	path := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(all, "\n\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, all
+}
+
+// readOutput returns the content of an output, decompressed
+func readOutput(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, gzipExt) {
+		return data
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestResumeAfterCrash resumes from a checkpoint followed by output written
+// after it: half a record, or half a compressed member
+func TestResumeAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	input, lines := writeResumeInput(t, dir, 1)
+	for _, ext := range []string{".jsonl", ".jsonl" + gzipExt} {
+		full := filepath.Join(dir, "full"+ext)
+		if out, err := runKhmer(t, "-i", input, "-o", full, "--resume"); err != nil {
+			t.Fatalf("%s: %v\n%s", ext, err, out)
+		}
+		want := readOutput(t, full)
+
+		// The checkpoint covers the first 40 lines and records
+		const done = 40
+		offset := int64(0)
+		for _, line := range lines[:done] {
+			offset += int64(len(line)) + 2
+		}
+		records := bytes.SplitAfter(want, []byte("\n"))
+		written := bytes.Join(records[:done], nil)
+		partial := records[done][:len(records[done])/2]
+		output := filepath.Join(dir, "out"+ext)
+		crashed := append(append([]byte{}, written...), partial...)
+		if ext != ".jsonl" {
+			written = gzipBytes(t, written)
+			next := gzipBytes(t, records[done])
+			crashed = append(append([]byte{}, written...), next[:len(next)/2]...)
+		}
+		if err := os.WriteFile(output, crashed, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cp := checkpoint{Input: input, Offset: offset, Lines: done, OutputBytes: int64(len(written))}
+		if err := cp.save(checkpointPath(output)); err != nil {
+			t.Fatal(err)
+		}
+
+		if out, err := runKhmer(t, "-i", input, "-o", output, "--resume"); err != nil {
+			t.Fatalf("%s: resuming: %v\n%s", ext, err, out)
+		}
+		if got := readOutput(t, output); !bytes.Equal(got, want) {
+			t.Errorf("%s: resumed output differs from an uninterrupted run", ext)
+		}
+		if _, err := os.Stat(checkpointPath(output)); !os.IsNotExist(err) {
+			t.Errorf("%s: checkpoint left after the run: %v", ext, err)
+		}
+	}
+}
+
+// TestResumeAfterInterrupt stops a run with Ctrl-C and runs it again
+func TestResumeAfterInterrupt(t *testing.T) {
+	dir := t.TempDir()
+	input, _ := writeResumeInput(t, dir, 40)
+	for _, ext := range []string{".jsonl", ".jsonl" + gzipExt} {
+		full := filepath.Join(dir, "full"+ext)
+		if out, err := runKhmer(t, "-i", input, "-o", full); err != nil {
+			t.Fatalf("%s: %v\n%s", ext, err, out)
+		}
+
+		output := filepath.Join(dir, "out"+ext)
+		cmd := exec.Command(khmerBin, "-i", input, "-o", output, "--resume", "--threads", "2")
+		cmd.Dir = filepath.Join("..", "..")
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		// Interrupt once the dictionary is loaded and the output created
+		for i := 0; i < 100; i++ {
+			if _, err := os.Stat(output); err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		time.Sleep(300 * time.Millisecond)
+		cmd.Process.Signal(os.Interrupt)
+		// The run may have finished first; resuming then has nothing to do
+		cmd.Wait()
+		if _, err := os.Stat(checkpointPath(output)); err != nil {
+			t.Logf("%s: no checkpoint after the interrupt: %v", ext, err)
+		}
+
+		if out, err := runKhmer(t, "-i", input, "-o", output, "--resume"); err != nil {
+			t.Fatalf("%s: resuming: %v\n%s", ext, err, out)
+		}
+		if got, want := readOutput(t, output), readOutput(t, full); !bytes.Equal(got, want) {
+			t.Errorf("%s: resumed output differs from an uninterrupted run", ext)
+		}
+	}
+}
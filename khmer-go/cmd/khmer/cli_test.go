@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// khmerBin is the khmer binary built for the tests
+var khmerBin string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "khmer-cli")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	khmerBin = filepath.Join(dir, "khmer")
+	if out, err := exec.Command("go", "build", "-o", khmerBin, ".").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "building khmer: %v\n%s", err, out)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// runKhmer runs the khmer binary from the module root, where the default
+// --dict and --freq paths resolve, and returns its combined output
+func runKhmer(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := exec.Command(khmerBin, args...)
+	cmd.Dir = filepath.Join("..", "..")
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// testRecord is the part of a JSON output record the tests check
+type testRecord struct {
+	ID       int      `json:"id"`
+	Input    string   `json:"input"`
+	Segments []string `json:"segments"`
+}
+
+func readTestRecords(t *testing.T, path string) []testRecord {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []testRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var rec testRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestHugeLine(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "huge.txt")
+	// Over 1MB on one line, then a short one
+	huge := strings.TrimSpace(strings.Repeat("ខ្ញុំទៅសាលារៀន ១៤ ០០០ រៀល។ ", 40000))
+	if err := os.WriteFile(input, []byte(huge+"\nសួស្តី\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range [][]string{nil, {"--max-memory", "16MB"}} {
+		output := filepath.Join(dir, "out.json")
+		args := append([]string{"-i", input, "-o", output, "--chunk-runes", "10000", "--line-workers", "4"}, mode...)
+		if out, err := runKhmer(t, args...); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out)
+		}
+		records := readTestRecords(t, output)
+		if len(records) != 2 {
+			t.Fatalf("%v: expected 2 records, got %d", args, len(records))
+		}
+		if got := strings.Join(records[0].Segments, ""); got != huge {
+			t.Errorf("%v: segments of the huge line do not join to it (%d bytes, want %d)", args, len(got), len(huge))
+		}
+	}
+
+	// Without --chunk-runes the line is refused with a hint
+	out, err := runKhmer(t, "-i", input, "-o", filepath.Join(dir, "refused.json"))
+	if err == nil || !strings.Contains(out, "--chunk-runes") {
+		t.Errorf("expected a --chunk-runes hint, got %v: %s", err, out)
+	}
+}
+
+func TestWritersUnordered(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.jsonl")
+	if out, err := runKhmer(t, "-i", filepath.Join("..", "data", "test_subset.txt"), "-o", output, "--writers", "2", "--unordered"); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	// Only the writers' files, holding every record once
+	if _, err := os.Stat(output); err == nil {
+		t.Errorf("%s was created beside the writers' files", output)
+	}
+	ids := make(map[int]bool)
+	for n := 1; n <= 2; n++ {
+		for _, rec := range readTestRecords(t, shardPath(output, n)) {
+			if ids[rec.ID] {
+				t.Errorf("record %d written twice", rec.ID)
+			}
+			ids[rec.ID] = true
+		}
+	}
+	if len(ids) != 90 {
+		t.Errorf("expected 90 records, got %d", len(ids))
+	}
+}
+
+func TestRecordIDsAreInputLines(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "data", "test_subset.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Blank lines between, and duplicates for --dedupe to drop
+	var nonEmpty []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			nonEmpty = append(nonEmpty, line)
+		}
+	}
+	nonEmpty = append(nonEmpty, nonEmpty[:10]...)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(input, []byte(strings.Join(nonEmpty, "\n\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"--shuffle", "--seed", "3"},
+		{"--dedupe", "--skip", "5"},
+		{"--sample", "0.5", "--seed", "1"},
+		{"--sample", "0.5", "--seed", "1", "--dedupe", "--max-memory", "1MB", "--skip", "5"},
+	} {
+		output := filepath.Join(dir, "out.json")
+		if out, err := runKhmer(t, append([]string{"-i", input, "-o", output}, args...)...); err != nil {
+			t.Fatalf("%v: %v\n%s", args, err, out)
+		}
+		records := readTestRecords(t, output)
+		if len(records) == 0 {
+			t.Fatalf("%v: no records", args)
+		}
+		for _, rec := range records {
+			if rec.ID < 0 || rec.ID >= len(nonEmpty) || nonEmpty[rec.ID] != rec.Input {
+				t.Errorf("%v: record %d is not input line %d", args, rec.ID, rec.ID)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// printDPTrace writes the --debug trace of text: each position with the
+// cheapest path to it and the candidate tokens starting there, "*" marking
+// those that win their end position, then the best path and the segments
+// after post-processing
+func printDPTrace(segmenter *khmer.KhmerSegmenter, text string) {
+	tr := segmenter.DebugTrace(text)
+	fmt.Printf("Text: %s (%d runes)\n", tr.Text, len(tr.Steps)-1)
+	for _, step := range tr.Steps {
+		if math.IsInf(float64(step.Cost), 1) {
+			fmt.Printf("\n [%d] %q unreachable\n", step.Pos, step.Rune)
+			continue
+		}
+		path := " "
+		if step.OnPath {
+			path = ">"
+		}
+		if step.Pos == len(tr.Steps)-1 {
+			fmt.Printf("\n%s[%d] end  cost %.4f", path, step.Pos, step.Cost)
+		} else {
+			fmt.Printf("\n%s[%d] %q  cost %.4f", path, step.Pos, step.Rune, step.Cost)
+		}
+		if step.From >= 0 {
+			fmt.Printf("  (%s from %d)", step.Kind, step.From)
+		}
+		fmt.Println()
+		for _, e := range step.Edges {
+			won := " "
+			if e.Won {
+				won = "*"
+			}
+			fmt.Printf("   %s %-10s -> %-4d %-20s step %8.4f  path %8.4f\n", won, e.Kind, e.End, e.Text, e.Cost, e.PathCost)
+		}
+	}
+	fmt.Printf("\nViterbi path (cost %.4f): %s\n", tr.Cost, strings.Join(tr.Path, " | "))
+	fmt.Printf("Segments: %s\n", strings.Join(segmenter.Segment(text), " | "))
+}
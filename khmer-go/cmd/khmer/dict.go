@@ -0,0 +1,306 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// dictCommands are the `khmer dict <command>` subcommands
+var dictCommands = map[string]func(args []string) error{
+	"compile":  runDictCompile,
+	"validate": runDictValidate,
+	"diff":     runDictDiff,
+	"stats":    runDictStats,
+	"export":   runDictExport,
+}
+
+// runDict dispatches `khmer dict <command>`
+func runDict(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: khmer dict <compile|validate|diff|stats|export> [options]")
+	}
+	cmd, ok := dictCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown dict command %q", args[0])
+	}
+	return cmd(args[1:])
+}
+
+// loadTracer traces dictionary loading when --trace is on
+var loadTracer khmer.Tracer
+
+// noVariants skips variant generation when loading text dictionaries
+// (--no-variants)
+var noVariants bool
+
+// newDictionary returns an empty dictionary with the loading options set
+func newDictionary() *khmer.Dictionary {
+	dictionary := khmer.NewDictionary()
+	dictionary.Logger = logger
+	dictionary.Tracer = loadTracer
+	dictionary.DisableVariants = noVariants
+	return dictionary
+}
+
+// loadDictionary loads a compiled trie artifact when dictPath is one,
+// otherwise the text dictionary plus frequency file
+func loadDictionary(dictPath, freqPath string) (*khmer.Dictionary, error) {
+	dictionary := newDictionary()
+	if khmer.IsCompiledDictionary(dictPath) {
+		return dictionary, dictionary.LoadCompiled(dictPath)
+	}
+	return dictionary, dictionary.Load(dictPath, freqPath)
+}
+
+// printMemReport writes the dictionary's size and memory estimate, with the
+// Go heap in use after a collection for comparison
+func printMemReport(dictionary *khmer.Dictionary) {
+	st := dictionary.Stats()
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	fmt.Println("Dictionary memory report")
+	fmt.Printf("  Words:           %d (%d head words, %d variants, %d with frequencies)\n",
+		st.Words, st.HeadWords, st.VariantWords, st.CostedWords)
+	fmt.Printf("  Max word length: %d runes\n", st.MaxWordLength)
+	fmt.Printf("  Trie nodes:      %d\n", st.TrieNodes)
+	fmt.Printf("  Trie:            %s (approx)\n", formatMB(st.TrieBytes))
+	fmt.Printf("  Word maps:       %s (approx)\n", formatMB(st.MapBytes))
+	fmt.Printf("  Total:           %s (approx)\n", formatMB(st.ApproxBytes))
+	fmt.Printf("  Go heap in use:  %s\n", formatMB(int64(ms.HeapInuse)))
+}
+
+// formatMB formats a byte count in megabytes
+func formatMB(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
+
+// runDictCompile implements `khmer dict compile`: preprocess the text
+// dictionary and frequencies once and write a binary trie that loads directly
+func runDictCompile(args []string) error {
+	fs := flag.NewFlagSet("dict compile", flag.ExitOnError)
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	outPath := fs.String("out", "", "Output compiled dictionary (required)")
+	fs.BoolVar(&noVariants, "no-variants", false, "Skip Coeng Ta/Da and Coeng Ro variant generation")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	if *outPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: khmer dict compile [--dict <file>] [--freq <file>] --out <file>")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	dictionary := newDictionary()
+	if err := dictionary.Load(*dictPath, *freqPath); err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(*outPath)
+	if err != nil {
+		return fmt.Errorf("could not create output file: %w", err)
+	}
+	if err := dictionary.WriteCompiled(outFile); err != nil {
+		outFile.Close()
+		return err
+	}
+	if err := outFile.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Compiled %d words to %s in %.2fs\n", dictionary.Size(), *outPath, time.Since(start).Seconds())
+	return nil
+}
+
+// runDictValidate implements `khmer dict validate`: report malformed entries
+// with line numbers. Exits non-zero when problems are found.
+func runDictValidate(args []string) error {
+	fs := flag.NewFlagSet("dict validate", flag.ExitOnError)
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	file, err := os.Open(*dictPath)
+	if err != nil {
+		return fmt.Errorf("dictionary not found at %s: %w", *dictPath, err)
+	}
+	defer file.Close()
+
+	issues, err := khmer.ValidateDictionary(file)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, issue := range issues {
+		fmt.Printf("%s:%s\n", *dictPath, issue)
+		counts[issue.Kind]++
+	}
+	if len(issues) == 0 {
+		fmt.Printf("%s: no problems found\n", *dictPath)
+		return nil
+	}
+
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	fmt.Fprintln(os.Stderr, "Summary:")
+	for _, kind := range kinds {
+		fmt.Fprintf(os.Stderr, "  %-18s %d\n", kind, counts[kind])
+	}
+	return fmt.Errorf("%d problems found in %s", len(issues), *dictPath)
+}
+
+// runDictDiff implements `khmer dict diff`: list added, removed and
+// changed-cost words between two dictionaries (text or compiled)
+func runDictDiff(args []string) error {
+	fs := flag.NewFlagSet("dict diff", flag.ExitOnError)
+	oldPath := fs.String("old", "", "Old dictionary, text or compiled (required)")
+	oldFreq := fs.String("old-freq", "", "Frequency file for a text --old dictionary")
+	newPath := fs.String("new", "", "New dictionary, text or compiled (required)")
+	newFreq := fs.String("new-freq", "", "Frequency file for a text --new dictionary")
+	epsilon := fs.Float64("epsilon", 0.01, "Ignore cost changes smaller than this")
+	summary := fs.Bool("summary", false, "Only print counts")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	if *oldPath == "" || *newPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: khmer dict diff --old <file> [--old-freq <file>] --new <file> [--new-freq <file>]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	oldDict, err := loadDictionary(*oldPath, *oldFreq)
+	if err != nil {
+		return err
+	}
+	newDict, err := loadDictionary(*newPath, *newFreq)
+	if err != nil {
+		return err
+	}
+
+	diff := khmer.DiffDictionaries(oldDict, newDict, float32(*epsilon))
+	if !*summary {
+		for _, word := range diff.Added {
+			cost, _ := newDict.LookupRunes([]rune(word))
+			fmt.Printf("+ %s\t%.2f\n", word, cost)
+		}
+		for _, word := range diff.Removed {
+			cost, _ := oldDict.LookupRunes([]rune(word))
+			fmt.Printf("- %s\t%.2f\n", word, cost)
+		}
+		for _, c := range diff.Changed {
+			fmt.Printf("~ %s\t%.2f -> %.2f\n", c.Word, c.OldCost, c.NewCost)
+		}
+	}
+	fmt.Printf("Added: %d, Removed: %d, Changed cost: %d\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	return nil
+}
+
+// runDictStats implements `khmer dict stats`: print the trie's shape and the
+// dictionary's coverage by word length
+func runDictStats(args []string) error {
+	fs := flag.NewFlagSet("dict stats", flag.ExitOnError)
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file (text or compiled)")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	dictionary, err := loadDictionary(*dictPath, *freqPath)
+	if err != nil {
+		return err
+	}
+	st := dictionary.TrieStats()
+
+	fmt.Printf("Trie: %d nodes (%d word ends, %d leaves), depth %d\n",
+		st.Nodes, st.WordNodes, st.Leaves, len(st.NodesByDepth)-1)
+	fmt.Printf("Branching: %.2f children per internal node, max %d\n", st.AvgBranching, st.MaxBranching)
+	fmt.Printf("Byte trie: %d nodes, %.2f per rune-level node\n", st.ByteNodes, float64(st.ByteNodes)/float64(max(st.Nodes, 1)))
+
+	fmt.Println("\nNodes by depth:")
+	for depth, count := range st.NodesByDepth {
+		fmt.Printf("  %3d  %8d\n", depth, count)
+	}
+	fmt.Println("\nNodes by number of children:")
+	for children, count := range st.ChildCounts {
+		if count > 0 {
+			fmt.Printf("  %3d  %8d\n", children, count)
+		}
+	}
+	fmt.Println("\nWords by length (runes):")
+	fmt.Printf("  %3s  %8s  %8s  %8s\n", "len", "words", "costed", "coverage")
+	for l, ls := range st.Lengths {
+		if ls.Words > 0 {
+			fmt.Printf("  %3d  %8d  %8d  %7.1f%%\n", l, ls.Words, ls.CostedWords, 100*float64(ls.CostedWords)/float64(ls.Words))
+		}
+	}
+	return nil
+}
+
+// runDictExport implements `khmer dict export`: write the effective lexicon,
+// after variant generation and filtering, with each word's cost, or as a
+// SentencePiece vocabulary
+func runDictExport(args []string) error {
+	fs := flag.NewFlagSet("dict export", flag.ExitOnError)
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file (text or compiled)")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	outPath := fs.String("out", "", "Output file (default stdout)")
+	format := fs.String("format", "tsv", "Output format: tsv (word, cost, source) or sentencepiece (piece, score)")
+	fs.BoolVar(&noVariants, "no-variants", false, "Skip Coeng Ta/Da and Coeng Ro variant generation")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	var export func(*khmer.Dictionary, io.Writer) error
+	switch *format {
+	case "tsv":
+		export = (*khmer.Dictionary).Export
+	case "sentencepiece":
+		export = (*khmer.Dictionary).ExportSentencePiece
+	default:
+		return fmt.Errorf("unknown export format %q (available: tsv, sentencepiece)", *format)
+	}
+
+	dictionary, err := loadDictionary(*dictPath, *freqPath)
+	if err != nil {
+		return err
+	}
+	if *outPath == "" {
+		return export(dictionary, os.Stdout)
+	}
+	outFile, err := os.Create(*outPath)
+	if err != nil {
+		return fmt.Errorf("could not create output file: %w", err)
+	}
+	if err := export(dictionary, outFile); err != nil {
+		outFile.Close()
+		return err
+	}
+	return outFile.Close()
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// outputRecord is the part of a JSON output record that diff compares
+type outputRecord struct {
+	ID       int      `json:"id"`
+	Input    string   `json:"input"`
+	Segments []string `json:"segments"`
+}
+
+// recordReader reads JSON output records one line at a time, decompressing
+// a file whose path ends in a compression extension (see compressions)
+type recordReader struct {
+	path    string
+	file    *os.File
+	comp    io.ReadCloser
+	scanner *bufio.Scanner
+	line    int
+}
+
+func openRecords(path string) (*recordReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("output file not found: %w", err)
+	}
+	r := &recordReader{path: path, file: file}
+	var src io.Reader = file
+	if ext := compressionExt(path); ext != "" {
+		if r.comp, err = compressions[ext].newReader(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		src = r.comp
+	}
+	r.scanner = bufio.NewScanner(src)
+	// Records are several times larger than their (up to 1MB) input line
+	const maxCapacity = 16 * 1024 * 1024
+	r.scanner.Buffer(make([]byte, 1024*1024), maxCapacity)
+	return r, nil
+}
+
+// next returns the next record, or nil at end of file
+func (r *recordReader) next() (*outputRecord, error) {
+	text, err := r.nextLine()
+	if text == nil || err != nil {
+		return nil, err
+	}
+	var rec outputRecord
+	if err := json.Unmarshal(text, &rec); err != nil {
+		return nil, fmt.Errorf("%s:%d: %w", r.path, r.line, err)
+	}
+	return &rec, nil
+}
+
+// nextLine returns the next non-empty line, or nil at end of file
+func (r *recordReader) nextLine() ([]byte, error) {
+	for r.scanner.Scan() {
+		r.line++
+		if text := bytes.TrimSpace(r.scanner.Bytes()); len(text) > 0 {
+			return text, nil
+		}
+	}
+	return nil, r.scanner.Err()
+}
+
+func (r *recordReader) close() {
+	if r.comp != nil {
+		r.comp.Close()
+	}
+	r.file.Close()
+}
+
+// ANSI escapes for --color
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+// renderBoundaries draws text with its word boundaries: "|" where both sides
+// split, "[-|]" where only A splits and "[+|]" where only B splits. With color
+// the markers are a dim, red and green "|" instead. With context > 0 only the
+// text within context runes of a differing boundary is shown.
+func renderBoundaries(segments []string, d khmer.BoundaryDiff, color bool, context int) string {
+	marks := make(map[int]string, len(d.Common)+len(d.OnlyA)+len(d.OnlyB))
+	common, onlyA, onlyB := "|", "[-|]", "[+|]"
+	if color {
+		common, onlyA, onlyB = ansiDim+"|"+ansiReset, ansiRed+"|"+ansiReset, ansiGreen+"|"+ansiReset
+	}
+	for _, b := range d.Common {
+		marks[b] = common
+	}
+	for _, b := range d.OnlyA {
+		marks[b] = onlyA
+	}
+	for _, b := range d.OnlyB {
+		marks[b] = onlyB
+	}
+
+	runes := []rune(strings.Join(segments, ""))
+	visible := func(int) bool { return true }
+	if context > 0 {
+		show := make([]bool, len(runes)+1)
+		for _, diffs := range [][]int{d.OnlyA, d.OnlyB} {
+			for _, b := range diffs {
+				for i := b - context; i <= b+context; i++ {
+					if i >= 0 && i <= len(runes) {
+						show[i] = true
+					}
+				}
+			}
+		}
+		visible = func(i int) bool { return show[i] }
+	}
+
+	var sb strings.Builder
+	gap := false
+	for i, r := range runes {
+		if !visible(i) {
+			gap = true
+			continue
+		}
+		if gap {
+			sb.WriteString(" … ")
+		}
+		gap = false
+		if m, ok := marks[i]; ok {
+			sb.WriteString(m)
+		}
+		sb.WriteRune(r)
+	}
+	if gap {
+		sb.WriteString(" …")
+	}
+	return strings.TrimPrefix(sb.String(), " ")
+}
+
+// segDiffStats accumulates the totals printed at the end of a diff
+type segDiffStats struct {
+	compared, differing, mismatched int
+	onlyA, onlyB                    int
+	// records whose id appears in only one of the files
+	recordsOnlyA, recordsOnlyB int
+}
+
+// runDiff implements `khmer diff a.json b.json`: align two JSON outputs of the
+// same input by record id and report lines whose segmentation differs
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	maxDiffs := fs.Int("max", 50, "Show at most this many differing lines (0 = all)")
+	summary := fs.Bool("summary", false, "Only print counts")
+	color := fs.Bool("color", false, "Highlight boundaries with ANSI colors")
+	context := fs.Int("context", 20, "Runes of context shown around differing boundaries (0 = whole line)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: khmer diff [options] <a.json> <b.json>")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	a, err := openRecords(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer a.close()
+	b, err := openRecords(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer b.close()
+
+	var stats segDiffStats
+	shown := 0
+	report := func(ra, rb *outputRecord) {
+		stats.compared++
+		if ra.Input != rb.Input {
+			stats.mismatched++
+			logger.Warn("Inputs differ", "id", ra.ID)
+			return
+		}
+		d, err := khmer.DiffSegmentations(ra.Segments, rb.Segments)
+		if errors.Is(err, khmer.ErrTextMismatch) {
+			// e.g. one side was run with --drop-stopwords
+			stats.mismatched++
+			logger.Warn("Segments cover different text", "id", ra.ID)
+			return
+		}
+		if d.Equal() {
+			return
+		}
+		stats.differing++
+		stats.onlyA += len(d.OnlyA)
+		stats.onlyB += len(d.OnlyB)
+		if *summary || (*maxDiffs > 0 && shown >= *maxDiffs) {
+			return
+		}
+		shown++
+		fmt.Printf("id %d: %s\n", ra.ID, renderBoundaries(ra.Segments, d, *color, *context))
+	}
+
+	// Walk both files in step; records that arrive out of order (--unordered
+	// output) wait in pending until their counterpart is read
+	pendingA := make(map[int]*outputRecord)
+	pendingB := make(map[int]*outputRecord)
+	for {
+		ra, err := a.next()
+		if err != nil {
+			return err
+		}
+		rb, err := b.next()
+		if err != nil {
+			return err
+		}
+		if ra == nil && rb == nil {
+			break
+		}
+		if ra != nil && rb != nil && ra.ID == rb.ID {
+			report(ra, rb)
+			continue
+		}
+		if ra != nil {
+			if other, ok := pendingB[ra.ID]; ok {
+				delete(pendingB, ra.ID)
+				report(ra, other)
+			} else {
+				pendingA[ra.ID] = ra
+			}
+		}
+		if rb != nil {
+			if other, ok := pendingA[rb.ID]; ok {
+				delete(pendingA, rb.ID)
+				report(other, rb)
+			} else {
+				pendingB[rb.ID] = rb
+			}
+		}
+	}
+	stats.recordsOnlyA = len(pendingA)
+	stats.recordsOnlyB = len(pendingB)
+	for i, ids := range [][]int{sortedIDs(pendingA), sortedIDs(pendingB)} {
+		if len(ids) > 0 {
+			logger.Warn("Records present in only one file", "file", fs.Arg(i), "count", len(ids), "first_id", ids[0])
+		}
+	}
+
+	if shown < stats.differing && !*summary {
+		fmt.Printf("... %d more differing lines\n", stats.differing-shown)
+	}
+	fmt.Printf("Compared: %d, Differing: %d (%.2f%%), Boundaries only in A: %d, only in B: %d\n",
+		stats.compared, stats.differing, percent(stats.differing, stats.compared), stats.onlyA, stats.onlyB)
+	if stats.mismatched > 0 || stats.recordsOnlyA > 0 || stats.recordsOnlyB > 0 {
+		fmt.Printf("Not comparable: %d, Records only in A: %d, only in B: %d\n", stats.mismatched, stats.recordsOnlyA, stats.recordsOnlyB)
+	}
+	return nil
+}
+
+func sortedIDs[T any](records map[int]T) []int {
+	ids := make([]int, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
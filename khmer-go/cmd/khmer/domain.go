@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// defaultDomain names the dictionary given with --dict and --freq
+const defaultDomain = "default"
+
+// domainSpec is one NAME=DICT[,FREQ] value of --domain
+type domainSpec struct {
+	name, dictPath, freqPath string
+}
+
+// parseDomains parses --domain values. A spec without FREQ uses freqPath.
+// The --dict dictionary is added first as "default".
+func parseDomains(specs []string, dictPath, freqPath string) ([]domainSpec, error) {
+	domains := []domainSpec{{name: defaultDomain, dictPath: dictPath, freqPath: freqPath}}
+	seen := map[string]bool{defaultDomain: true}
+	for _, spec := range specs {
+		name, paths, ok := strings.Cut(spec, "=")
+		if !ok || name == "" || paths == "" {
+			return nil, fmt.Errorf("invalid --domain %q: expected NAME=dict[,freq]", spec)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("--domain %q given twice (%q is the --dict dictionary)", name, defaultDomain)
+		}
+		seen[name] = true
+		d := domainSpec{name: name, dictPath: paths, freqPath: freqPath}
+		if dict, freq, ok := strings.Cut(paths, ","); ok {
+			d.dictPath, d.freqPath = dict, freq
+		}
+		domains = append(domains, d)
+	}
+	return domains, nil
+}
+
+// loadDomains loads every domain's dictionary, keyed by name
+func loadDomains(domains []domainSpec) (map[string]*khmer.Dictionary, error) {
+	dicts := make(map[string]*khmer.Dictionary, len(domains))
+	for _, d := range domains {
+		dictionary, err := loadDictionary(d.dictPath, d.freqPath)
+		if err != nil {
+			return nil, fmt.Errorf("domain %s: %w", d.name, err)
+		}
+		dicts[d.name] = dictionary
+	}
+	return dicts, nil
+}
+
+// domainInput splits a NAME:path input into its domain and path. Inputs
+// without a known domain prefix are plain paths segmented with fallback.
+func domainInput(input string, dicts map[string]*khmer.Dictionary, fallback string) (string, string) {
+	if name, path, ok := strings.Cut(input, ":"); ok {
+		if _, known := dicts[name]; known && path != "" {
+			return name, path
+		}
+	}
+	return fallback, input
+}
+
+// serverDomain is a named dictionary in the server. dict is nil until a
+// --lazy load has its first stage and is replaced on reload; complete is set
+// once the whole dictionary is in.
+type serverDomain struct {
+	domainSpec
+	dict     atomic.Pointer[khmer.Dictionary]
+	complete atomic.Bool
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// errorLog receives the error records of lines that could not be segmented
+// (--error-log), one JSON object per line, written by all workers
+type errorLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	w    *bufio.Writer
+}
+
+// createErrorLog creates the error log at path
+func createErrorLog(path string) (*errorLog, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create error log: %w", err)
+	}
+	return &errorLog{path: path, file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// write appends an encoded error record
+func (l *errorLog) write(out string) {
+	l.mu.Lock()
+	l.w.WriteString(out)
+	l.mu.Unlock()
+}
+
+// reset empties the log for a new segmentation pass
+func (l *errorLog) reset() error {
+	l.w.Reset(l.file)
+	if err := l.file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := l.file.Seek(0, 0)
+	return err
+}
+
+// flush writes buffered records to the file
+func (l *errorLog) flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Flush()
+}
+
+// fail returns the output of a line that could not be segmented: its error
+// record ({"id":N,"input":"...","error":"..."} in JSON; other formats get the
+// record with no segments), or nothing when it goes to the error log instead
+func (w *worker) fail(id int, line, msg string) string {
+	w.proc.failed.Add(1)
+	rec := record{id: id, input: line, err: msg}
+	if w.proc.errorLog != nil {
+		buildJSON(w.sb, &rec)
+		w.sb.WriteByte('\n')
+		w.proc.errorLog.write(w.sb.String())
+		return ""
+	}
+	w.proc.format.encode(w.sb, &rec)
+	return w.sb.String()
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// outputFormat encodes segmented records for the output file
+type outputFormat struct {
+	name string
+	// ext is the file extension used for per-file outputs of multi-file runs
+	ext string
+	// header is written once at the start of each output file
+	header string
+	// encode resets sb and writes one record, including any record terminator
+	encode func(sb *strings.Builder, rec *record)
+}
+
+// outputFormats builds each --format for the run's options
+var outputFormats = map[string]func(opts options) *outputFormat{
+	"json": func(options) *outputFormat {
+		return &outputFormat{name: "json", ext: ".json", encode: func(sb *strings.Builder, rec *record) {
+			buildJSON(sb, rec)
+			sb.WriteByte('\n')
+		}}
+	},
+	"msgpack": func(options) *outputFormat {
+		return &outputFormat{name: "msgpack", ext: ".msgpack", encode: buildMsgpack}
+	},
+	"es": func(options) *outputFormat {
+		return &outputFormat{name: "es", ext: ".json", encode: buildAnalyzeJSON}
+	},
+	"csv": func(opts options) *outputFormat {
+		sep := opts.csvTokenSep
+		return &outputFormat{name: "csv", ext: ".csv", header: csvHeader(opts), encode: func(sb *strings.Builder, rec *record) {
+			buildCSV(sb, rec, sep)
+		}}
+	},
+	"html": func(options) *outputFormat {
+		return &outputFormat{name: "html", ext: ".html", header: htmlHeader(), encode: buildHTML}
+	},
+}
+
+// newOutputFormat returns the encoder selected by opts.format
+func newOutputFormat(opts options) (*outputFormat, error) {
+	if build, ok := outputFormats[strings.ToLower(opts.format)]; ok {
+		return build(opts), nil
+	}
+	names := make([]string, 0, len(outputFormats))
+	for n := range outputFormats {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown output format %q (available: %s)", opts.format, strings.Join(names, ", "))
+}
+
+// csvHeader names the CSV columns; optional columns match the optional record fields
+func csvHeader(opts options) string {
+	header := "id,input,segments"
+	if opts.romanize != "" {
+		header += ",romanized"
+	}
+	if opts.types {
+		header += ",types"
+	}
+	if opts.compounds {
+		header += ",compounds"
+	}
+	if opts.numberValues {
+		header += ",values,currencies"
+	}
+	if opts.timing {
+		header += ",time_us"
+	}
+	return header + "\r\n"
+}
+
+// buildCSV writes a record as an RFC 4180 row. Token lists are joined with sep
+// into a single field.
+func buildCSV(sb *strings.Builder, rec *record, sep string) {
+	sb.Reset()
+	sb.Grow(len(rec.input)*2 + len(rec.segments)*(len(sep)+1) + 16)
+
+	writeInt(sb, rec.id)
+	sb.WriteByte(',')
+	writeCSVField(sb, rec.input)
+	sb.WriteByte(',')
+	writeCSVField(sb, strings.Join(rec.segments, sep))
+	if rec.romanized != nil {
+		sb.WriteByte(',')
+		writeCSVField(sb, strings.Join(rec.romanized, sep))
+	}
+	if rec.types != nil {
+		names := make([]string, len(rec.types))
+		for i, t := range rec.types {
+			names[i] = string(t)
+		}
+		sb.WriteByte(',')
+		writeCSVField(sb, strings.Join(names, sep))
+	}
+	if rec.compounds != nil {
+		// One entry per segment: its parts joined with "+", empty if not a compound
+		entries := make([]string, len(rec.compounds))
+		for i, parts := range rec.compounds {
+			entries[i] = strings.Join(parts, "+")
+		}
+		sb.WriteByte(',')
+		writeCSVField(sb, strings.Join(entries, sep))
+	}
+	if rec.values != nil {
+		// Empty entries for segments that are not numbers
+		entries := make([]string, len(rec.values))
+		for i, v := range rec.values {
+			if !math.IsNaN(v) {
+				entries[i] = strconv.FormatFloat(v, 'g', -1, 64)
+			}
+		}
+		sb.WriteByte(',')
+		writeCSVField(sb, strings.Join(entries, sep))
+		sb.WriteByte(',')
+		writeCSVField(sb, strings.Join(rec.currencies, sep))
+	}
+	if rec.timed {
+		sb.WriteByte(',')
+		writeInt(sb, int(rec.timeUs))
+	}
+	sb.WriteString("\r\n")
+}
+
+// writeCSVField quotes a field when it contains a delimiter, quote, line break
+// or leading/trailing space, doubling embedded quotes
+func writeCSVField(sb *strings.Builder, field string) {
+	if field == "" || (!strings.ContainsAny(field, ",\"\r\n") &&
+		field[0] != ' ' && field[0] != '\t' && field[len(field)-1] != ' ' && field[len(field)-1] != '\t') {
+		sb.WriteString(field)
+		return
+	}
+	sb.WriteByte('"')
+	for i := 0; i < len(field); i++ {
+		if field[i] == '"' {
+			sb.WriteByte('"')
+		}
+		sb.WriteByte(field[i])
+	}
+	sb.WriteByte('"')
+}
+
+// buildMsgpack writes a record as a MessagePack map with the same keys as the
+// JSON output. Records are concatenated without separators, which is what
+// streaming decoders (e.g. Python's msgpack.Unpacker) expect.
+func buildMsgpack(sb *strings.Builder, rec *record) {
+	sb.Reset()
+	sb.Grow(len(rec.input)*2 + len(rec.segments)*4 + 32)
+
+	fields := 3
+	if rec.romanized != nil {
+		fields++
+	}
+	if rec.types != nil {
+		fields++
+	}
+	if rec.compounds != nil {
+		fields++
+	}
+	if rec.values != nil {
+		fields += 2
+	}
+	if rec.timed {
+		fields++
+	}
+	sb.WriteByte(0x80 | byte(fields)) // fixmap
+
+	writeMsgpackString(sb, "id")
+	writeMsgpackInt(sb, int64(rec.id))
+	writeMsgpackString(sb, "input")
+	writeMsgpackString(sb, rec.input)
+	writeMsgpackString(sb, "segments")
+	writeMsgpackStringArray(sb, rec.segments)
+	if rec.romanized != nil {
+		writeMsgpackString(sb, "romanized")
+		writeMsgpackStringArray(sb, rec.romanized)
+	}
+	if rec.types != nil {
+		writeMsgpackString(sb, "types")
+		writeMsgpackArrayHeader(sb, len(rec.types))
+		for _, t := range rec.types {
+			writeMsgpackString(sb, string(t))
+		}
+	}
+	if rec.compounds != nil {
+		writeMsgpackString(sb, "compounds")
+		writeMsgpackArrayHeader(sb, len(rec.compounds))
+		for _, parts := range rec.compounds {
+			writeMsgpackStringArray(sb, parts)
+		}
+	}
+	if rec.values != nil {
+		writeMsgpackString(sb, "values")
+		writeMsgpackArrayHeader(sb, len(rec.values))
+		for _, v := range rec.values {
+			if math.IsNaN(v) {
+				sb.WriteByte(0xc0) // nil
+				continue
+			}
+			sb.WriteByte(0xcb) // float 64
+			writeBigEndian(sb, math.Float64bits(v), 8)
+		}
+		writeMsgpackString(sb, "currencies")
+		writeMsgpackArrayHeader(sb, len(rec.currencies))
+		for _, code := range rec.currencies {
+			if code == "" {
+				sb.WriteByte(0xc0) // nil
+				continue
+			}
+			writeMsgpackString(sb, code)
+		}
+	}
+	if rec.timed {
+		writeMsgpackString(sb, "time_us")
+		writeMsgpackInt(sb, rec.timeUs)
+	}
+}
+
+func writeMsgpackStringArray(sb *strings.Builder, items []string) {
+	writeMsgpackArrayHeader(sb, len(items))
+	for _, item := range items {
+		writeMsgpackString(sb, item)
+	}
+}
+
+func writeMsgpackArrayHeader(sb *strings.Builder, n int) {
+	switch {
+	case n < 16:
+		sb.WriteByte(0x90 | byte(n))
+	case n <= 0xffff:
+		sb.WriteByte(0xdc)
+		writeBigEndian(sb, uint64(n), 2)
+	default:
+		sb.WriteByte(0xdd)
+		writeBigEndian(sb, uint64(n), 4)
+	}
+}
+
+func writeMsgpackString(sb *strings.Builder, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		sb.WriteByte(0xa0 | byte(n))
+	case n <= 0xff:
+		sb.WriteByte(0xd9)
+		sb.WriteByte(byte(n))
+	case n <= 0xffff:
+		sb.WriteByte(0xda)
+		writeBigEndian(sb, uint64(n), 2)
+	default:
+		sb.WriteByte(0xdb)
+		writeBigEndian(sb, uint64(n), 4)
+	}
+	sb.WriteString(s)
+}
+
+// writeMsgpackInt uses the smallest integer encoding that holds n
+func writeMsgpackInt(sb *strings.Builder, n int64) {
+	switch {
+	case n >= 0 && n < 128:
+		sb.WriteByte(byte(n)) // positive fixint
+	case n < 0:
+		sb.WriteByte(0xd3)
+		writeBigEndian(sb, uint64(n), 8)
+	case n <= 0xff:
+		sb.WriteByte(0xcc)
+		sb.WriteByte(byte(n))
+	case n <= 0xffff:
+		sb.WriteByte(0xcd)
+		writeBigEndian(sb, uint64(n), 2)
+	case n <= 0xffffffff:
+		sb.WriteByte(0xce)
+		writeBigEndian(sb, uint64(n), 4)
+	default:
+		sb.WriteByte(0xcf)
+		writeBigEndian(sb, uint64(n), 8)
+	}
+}
+
+func writeBigEndian(sb *strings.Builder, v uint64, size int) {
+	for shift := (size - 1) * 8; shift >= 0; shift -= 8 {
+		sb.WriteByte(byte(v >> shift))
+	}
+}
@@ -0,0 +1,74 @@
+//go:build fsnotify
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func init() { newInputWatcher = newNotifyWatcher }
+
+// notifyWatcher reports changes to the input from filesystem notifications,
+// once no event has come for the settle interval
+type notifyWatcher struct {
+	w *fsnotify.Watcher
+	c chan struct{}
+}
+
+func newNotifyWatcher(path string, settle time.Duration) (inputWatcher, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watch the directory: a watch on the file itself ends when an editor
+	// saves by replacing it
+	if err := fw.Add(filepath.Dir(path)); err != nil {
+		fw.Close()
+		return nil, err
+	}
+	w := &notifyWatcher{w: fw, c: make(chan struct{}, 1)}
+	go w.run(filepath.Clean(path), settle)
+	return w, nil
+}
+
+func (w *notifyWatcher) run(path string, settle time.Duration) {
+	timer := time.NewTimer(settle)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case ev, ok := <-w.w.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != path || ev.Op == fsnotify.Chmod {
+				continue
+			}
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(settle)
+		case err, ok := <-w.w.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("Watching input", "error", err)
+		case <-timer.C:
+			notifyChange(w.c)
+		}
+	}
+}
+
+func (w *notifyWatcher) changes() <-chan struct{} { return w.c }
+
+func (w *notifyWatcher) close() error { return w.w.Close() }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// runGate implements `khmer gate`: score the segmenter on a gold set and fail
+// when boundary F1 or the exact-match rate falls below a threshold, so CI can
+// catch regressions from dictionary or cost changes
+func runGate(args []string) error {
+	fs := flag.NewFlagSet("gate", flag.ExitOnError)
+	goldPath := fs.String("gold", "../data/test_cases.json", "Gold test cases to score")
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	costsPath := fs.String("costs", "", "Cost file from khmer tune to score with")
+	minF1 := fs.Float64("min-f1", 0.97, "Fail when boundary F1 is below this (0-1)")
+	minExact := fs.Float64("min-exact", 0, "Fail when the exact-match rate is below this percentage (0 = not checked)")
+	show := fs.Int("show", 10, "Print at most this many failing cases (0 = none)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+	if *minF1 < 0 || *minF1 > 1 || *minExact < 0 || *minExact > 100 {
+		fmt.Fprintln(os.Stderr, "Error: --min-f1 must be between 0 and 1 and --min-exact between 0 and 100")
+		os.Exit(1)
+	}
+
+	gold, err := readGoldCases(*goldPath)
+	if err != nil {
+		return err
+	}
+	if len(gold) == 0 {
+		return fmt.Errorf("%s has no test cases", *goldPath)
+	}
+	dictionary, err := loadDictionary(*dictPath, *freqPath)
+	if err != nil {
+		return err
+	}
+	segmenter := khmer.NewKhmerSegmenter(dictionary)
+	if *costsPath != "" {
+		c, err := readCostConfig(*costsPath)
+		if err != nil {
+			return err
+		}
+		c.apply(dictionary)
+		segmenter.Costs = &c.StepCosts
+	}
+
+	segments := make([][]string, len(gold))
+	shown := 0
+	for i, g := range gold {
+		segments[i] = segmenter.Segment(g.Input)
+		if shown < *show && !reflect.DeepEqual(segments[i], g.Expected) {
+			shown++
+			fmt.Printf("case %d: got %s, expected %s\n", g.ID, strings.Join(segments[i], "|"), strings.Join(g.Expected, "|"))
+		}
+	}
+	var res benchResult
+	scoreSegmentations(segments, gold, &res)
+	fmt.Printf("Gold cases: %d, Exact: %.2f%%, Precision: %.4f, Recall: %.4f, F1: %.4f\n",
+		res.GoldCases, res.GoldExact, res.Precision, res.Recall, res.F1)
+
+	var failed []string
+	if res.F1 < *minF1 {
+		failed = append(failed, fmt.Sprintf("F1 %.4f is below %.4f", res.F1, *minF1))
+	}
+	if res.GoldExact < *minExact {
+		failed = append(failed, fmt.Sprintf("exact match %.2f%% is below %.2f%%", res.GoldExact, *minExact))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("quality gate failed: %s", strings.Join(failed, "; "))
+	}
+	logger.Info("Quality gate passed", "gold", *goldPath)
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// goldenCase is an entry of data/test_cases.json
+type goldenCase struct {
+	ID          int      `json:"id"`
+	Input       string   `json:"input"`
+	Description string   `json:"description"`
+	Expected    []string `json:"expected"`
+}
+
+// readGoldCases reads a test_cases.json file
+func readGoldCases(path string) ([]goldenCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases []goldenCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cases, nil
+}
+
+// runGolden implements `khmer golden`: turn raw lines, and optionally a
+// hand-corrected delimited copy, into test_cases.json entries
+func runGolden(args []string) error {
+	fs := flag.NewFlagSet("golden", flag.ExitOnError)
+	inputPath := fs.String("input", "", "Raw text file, one case per line (required)")
+	correctedPath := fs.String("corrected", "", "Hand-corrected copy of --input with tokens separated by --delimiter")
+	delimiter := fs.String("delimiter", "|", "Token delimiter used in --corrected")
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file (used without --corrected)")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	appendPath := fs.String("append", "", "Add the cases to this test_cases.json (ids continue, known inputs are skipped)")
+	outPath := fs.String("out", "", "Write the new cases to this file instead of stdout")
+	description := fs.String("description", "", "Description for every case (default: <file>:<line>)")
+	fs.StringVar(inputPath, "i", "", "Raw text file (short)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	if *inputPath == "" || *delimiter == "" {
+		fmt.Fprintln(os.Stderr, "Usage: khmer golden --input <file> [--corrected <file>] [--append test_cases.json | --out <file>]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	raw, err := readGoldenLines(*inputPath)
+	if err != nil {
+		return err
+	}
+
+	var expected [][]string
+	if *correctedPath != "" {
+		corrected, err := readGoldenLines(*correctedPath)
+		if err != nil {
+			return err
+		}
+		if len(corrected) != len(raw) {
+			return fmt.Errorf("%s has %d lines but %s has %d", *correctedPath, len(corrected), *inputPath, len(raw))
+		}
+		for i, line := range corrected {
+			tokens := strings.Split(line.text, *delimiter)
+			if strings.Join(tokens, "") != strings.ReplaceAll(raw[i].text, khmer.ZeroWidthSpace, "") {
+				return fmt.Errorf("%s:%d: tokens do not join to the input line %d", *correctedPath, line.number, raw[i].number)
+			}
+			expected = append(expected, tokens)
+		}
+	} else {
+		// No corrections: snapshot the current output so regressions are caught
+		dictionary, err := loadDictionary(*dictPath, *freqPath)
+		if err != nil {
+			return err
+		}
+		segmenter := khmer.NewKhmerSegmenter(dictionary)
+		for _, line := range raw {
+			expected = append(expected, segmenter.Segment(line.text))
+		}
+	}
+
+	var existing []goldenCase
+	if *appendPath != "" {
+		data, err := os.ReadFile(*appendPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &existing); err != nil {
+				return fmt.Errorf("%s: %w", *appendPath, err)
+			}
+		}
+	}
+	known := make(map[string]bool, len(existing))
+	nextID := 0
+	for _, c := range existing {
+		known[c.Input] = true
+		if c.ID >= nextID {
+			nextID = c.ID + 1
+		}
+	}
+
+	var added []goldenCase
+	for i, line := range raw {
+		if known[line.text] {
+			logger.Info("Skipping known input", "line", line.number)
+			continue
+		}
+		known[line.text] = true
+		desc := *description
+		if desc == "" {
+			desc = fmt.Sprintf("%s:%d", *inputPath, line.number)
+		}
+		added = append(added, goldenCase{ID: nextID, Input: line.text, Description: desc, Expected: expected[i]})
+		nextID++
+	}
+
+	switch {
+	case *appendPath != "":
+		if err := writeGoldenFile(*appendPath, append(existing, added...)); err != nil {
+			return err
+		}
+		logger.Info("Added test cases", "path", *appendPath, "added", len(added), "total", len(existing)+len(added))
+	case *outPath != "":
+		if err := writeGoldenFile(*outPath, added); err != nil {
+			return err
+		}
+		logger.Info("Wrote test cases", "path", *outPath, "cases", len(added))
+	default:
+		if err := writeGoldenCases(os.Stdout, added); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// goldenLine is a non-empty input line and its 1-based line number
+type goldenLine struct {
+	number int
+	text   string
+}
+
+func readGoldenLines(path string) ([]goldenLine, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("input file not found: %w", err)
+	}
+	defer file.Close()
+
+	var lines []goldenLine
+	scanner := bufio.NewScanner(file)
+	const maxCapacity = 1024 * 1024 // 1MB
+	scanner.Buffer(make([]byte, maxCapacity), maxCapacity)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		if text != "" {
+			lines = append(lines, goldenLine{number: number, text: text})
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// writeGoldenFile replaces the gold file atomically: the cases go to a
+// temporary file in the same directory that is renamed over it, so a crash or
+// full disk mid-write leaves the previous gold set intact
+func writeGoldenFile(path string, cases []goldenCase) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+	err = writeGoldenCases(file, cases)
+	if err == nil {
+		err = file.Chmod(0o644)
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// writeGoldenCases writes cases formatted like data/test_cases.json: two-space
+// indent, unescaped Khmer, no trailing newline
+func writeGoldenCases(w io.Writer, cases []goldenCase) error {
+	if cases == nil {
+		cases = []goldenCase{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cases); err != nil {
+		return err
+	}
+	_, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
@@ -0,0 +1,224 @@
+//go:build grpc
+
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+func init() { newGRPCServer = newSegmenterGRPC }
+
+// grpcRequest and grpcResult are the SegmentRequest and SegmentResult messages
+// of proto/segmenter.proto, encoded by hand so the build needs neither protoc
+// nor generated code
+type grpcRequest struct {
+	id, text, dict string
+	types          bool
+}
+
+type grpcResult struct {
+	id       string
+	segments []string
+	types    []string
+	err      string
+}
+
+func (m *grpcRequest) unmarshal(b []byte) error {
+	*m = grpcRequest{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			m.id, n = consumeString(b)
+		case num == 2 && typ == protowire.BytesType:
+			m.text, n = consumeString(b)
+		case num == 3 && typ == protowire.BytesType:
+			m.dict, n = consumeString(b)
+		case num == 4 && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			m.types = v != 0
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeString(b []byte) (string, int) {
+	v, n := protowire.ConsumeBytes(b)
+	return string(v), n
+}
+
+func (m *grpcResult) marshal() []byte {
+	var b []byte
+	appendString := func(num protowire.Number, v string) {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	}
+	if m.id != "" {
+		appendString(1, m.id)
+	}
+	for _, s := range m.segments {
+		appendString(2, s)
+	}
+	for _, t := range m.types {
+		appendString(3, t)
+	}
+	if m.err != "" {
+		appendString(4, m.err)
+	}
+	return b
+}
+
+// protoCodec encodes the messages above under the "proto" name, so clients
+// generated from proto/segmenter.proto talk to the server unchanged
+type protoCodec struct{}
+
+func (protoCodec) Name() string { return "proto" }
+
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	if m, ok := v.(*grpcResult); ok {
+		return m.marshal(), nil
+	}
+	return nil, fmt.Errorf("cannot marshal %T", v)
+}
+
+func (protoCodec) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(*grpcRequest); ok {
+		return m.unmarshal(data)
+	}
+	return fmt.Errorf("cannot unmarshal into %T", v)
+}
+
+// segmenterService is implemented by *server
+type segmenterService interface {
+	segmentStream(stream grpc.ServerStream) error
+}
+
+var segmenterServiceDesc = grpc.ServiceDesc{
+	ServiceName: "khmer.v1.Segmenter",
+	HandlerType: (*segmenterService)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName: "SegmentStream",
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			return srv.(segmenterService).segmentStream(stream)
+		},
+		ServerStreams: true,
+		ClientStreams: true,
+	}},
+	Metadata: "segmenter.proto",
+}
+
+// newSegmenterGRPC serves the Segmenter service of s, over TLS with tlsCfg
+// when it is set. --max-concurrent and --rate-limit count each stream as a
+// request, as they do each /segment/stream.
+func newSegmenterGRPC(s *server, tlsCfg *tls.Config) (grpcServer, error) {
+	opts := []grpc.ServerOption{
+		grpc.ForceServerCodec(protoCodec{}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			release, wait, reason := s.limits.admit()
+			if release == nil {
+				return status.Errorf(codes.ResourceExhausted, "%s, retry in %s", reason, wait.Round(time.Millisecond))
+			}
+			defer release()
+			return handler(srv, ss)
+		}),
+	}
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+	gs := grpc.NewServer(opts...)
+	gs.RegisterService(&segmenterServiceDesc, s)
+	return gs, nil
+}
+
+// segmentStream serves SegmentStream like handleStream serves
+// /segment/stream: requests are segmented in parallel as they arrive, and
+// each result is sent when ready with its request's id, or the request's
+// 0-based index when it has none
+func (s *server) segmentStream(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	results := make(chan *grpcResult, 64)
+	sendErr := make(chan error, 1)
+	go func() {
+		var err error
+		// Keep draining after a failed send so the workers never block
+		for res := range results {
+			if err == nil {
+				err = stream.SendMsg(res)
+			}
+		}
+		sendErr <- err
+	}()
+
+	jobs := make(chan *grpcRequest, 64)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range jobs {
+				res := &grpcResult{id: req.id}
+				dict, err := s.dictionary(req.dict)
+				if err != nil {
+					res.err = err.Error()
+				} else {
+					res.segments = s.segment(ctx, strings.TrimSpace(req.text), dict)
+					if req.types {
+						for _, t := range khmer.ClassifyTokens(res.segments, dict) {
+							res.types = append(res.types, string(t))
+						}
+					}
+				}
+				results <- res
+			}
+		}()
+	}
+
+	var recvErr error
+	for n := 0; ; n++ {
+		req := &grpcRequest{}
+		if recvErr = stream.RecvMsg(req); recvErr != nil {
+			break
+		}
+		if req.id == "" {
+			req.id = strconv.Itoa(n)
+		}
+		jobs <- req
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+	if err := <-sendErr; err != nil {
+		return err
+	}
+	if errors.Is(recvErr, io.EOF) {
+		return nil
+	}
+	return recvErr
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"html"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// htmlTypes are the token types colored by --format html, in legend order
+var htmlTypes = []khmer.TokenType{
+	khmer.TokenKhmerWord, khmer.TokenKhmerUnknown, khmer.TokenNumber, khmer.TokenCurrency,
+	khmer.TokenAcronym, khmer.TokenLatin, khmer.TokenPunct, khmer.TokenSymbol, khmer.TokenSpace,
+}
+
+// htmlStyle colors each token type; entity types from --gazetteer get the
+// default token style
+const htmlStyle = `body{font-family:"Noto Sans Khmer","Khmer OS",sans-serif;margin:1.5em;line-height:2.4}
+h1{font-size:1.2em}
+.legend span,.line span.t{border:1px solid #999;border-radius:3px;padding:0 .15em;margin:0 1px;background:#eee}
+.line{border-bottom:1px solid #ddd;padding:.2em 0}
+.line .id{color:#888;font:.75em monospace;display:inline-block;min-width:4em}
+.line .err{color:#b00020;font-family:monospace}
+.KHMER_WORD{background:#dcedc8!important;border-color:#7cb342!important}
+.KHMER_UNKNOWN{background:#ffcdd2!important;border-color:#e53935!important}
+.NUMBER,.CURRENCY{background:#bbdefb!important;border-color:#1e88e5!important}
+.ACRONYM,.LATIN{background:#fff9c4!important;border-color:#fdd835!important}
+.PUNCT,.SYMBOL{background:#e1bee7!important;border-color:#8e24aa!important}
+.SPACE{background:none!important;border-style:dotted!important}
+`
+
+// htmlHeader starts the self-contained page of --format html: styles and a
+// legend. Records follow as one <div> per line; HTML lets the page end
+// without closing its body, so no footer is needed.
+func htmlHeader() string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"km\">\n<head>\n<meta charset=\"utf-8\">\n<title>Khmer word segmentation</title>\n<style>\n")
+	sb.WriteString(htmlStyle)
+	sb.WriteString("</style>\n</head>\n<body>\n<h1>Khmer word segmentation</h1>\n<p class=\"legend\">")
+	for _, t := range htmlTypes {
+		sb.WriteString(`<span class="`)
+		sb.WriteString(string(t))
+		sb.WriteString(`">`)
+		sb.WriteString(string(t))
+		sb.WriteString("</span> ")
+	}
+	sb.WriteString("<br>Hover over a token for its type and cost.</p>\n")
+	return sb.String()
+}
+
+// buildHTML writes a record as a line of colored tokens, each with a
+// tooltip giving its type, cost and romanization
+func buildHTML(sb *strings.Builder, rec *record) {
+	sb.Reset()
+	sb.Grow(len(rec.input)*2 + len(rec.segments)*64 + 64)
+
+	sb.WriteString(`<div class="line" id="l`)
+	writeInt(sb, rec.id)
+	sb.WriteString(`"><span class="id">`)
+	writeInt(sb, rec.id)
+	sb.WriteString("</span>")
+	if rec.err != "" {
+		sb.WriteString(html.EscapeString(rec.input))
+		sb.WriteString(` <span class="err">`)
+		sb.WriteString(html.EscapeString(rec.err))
+		sb.WriteString("</span></div>\n")
+		return
+	}
+	for i, seg := range rec.segments {
+		var title strings.Builder
+		sb.WriteString(`<span class="t`)
+		if rec.types != nil {
+			sb.WriteByte(' ')
+			sb.WriteString(string(rec.types[i]))
+			title.WriteString(string(rec.types[i]))
+		}
+		if rec.costs != nil && !math.IsNaN(float64(rec.costs[i])) {
+			title.WriteString("\ncost ")
+			title.WriteString(strconv.FormatFloat(float64(rec.costs[i]), 'f', 2, 32))
+		}
+		if rec.romanized != nil {
+			title.WriteString("\n")
+			title.WriteString(rec.romanized[i])
+		}
+		sb.WriteString(`" title="`)
+		sb.WriteString(html.EscapeString(title.String()))
+		sb.WriteString(`">`)
+		sb.WriteString(html.EscapeString(seg))
+		sb.WriteString("</span>")
+	}
+	sb.WriteString("</div>\n")
+}
+
+// segmentCosts returns the path cost the dictionary gives each Khmer
+// segment, NaN for other types, whose costs come from fixed rules
+func segmentCosts(segments []string, types []khmer.TokenType, dict *khmer.Dictionary) []float32 {
+	costs := make([]float32, len(segments))
+	for i, seg := range segments {
+		switch types[i] {
+		case khmer.TokenKhmerWord, khmer.TokenKhmerUnknown:
+			costs[i] = dict.Cost(seg)
+		default:
+			costs[i] = float32(math.NaN())
+		}
+	}
+	return costs
+}
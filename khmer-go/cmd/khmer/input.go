@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// maxLineBytes caps an input line, so a file with no line breaks is not read
+// whole by mistake. --chunk-runes is meant for huge lines and lifts it to
+// maxChunkedLineBytes.
+const (
+	maxLineBytes        = 1 << 20 // 1MB
+	maxChunkedLineBytes = 1 << 30 // 1GB
+)
+
+// maxLine returns the longest input line opts accept
+func (o options) maxLine() int {
+	if o.chunkRunes > 0 {
+		return maxChunkedLineBytes
+	}
+	return maxLineBytes
+}
+
+// newLineScanner scans the lines of r, accepting lines of up to maxLine bytes
+func newLineScanner(r io.Reader, maxLine int) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	return scanner
+}
+
+// lineError explains a scanner error for a line over maxLine bytes
+func lineError(err error, maxLine int) error {
+	if errors.Is(err, bufio.ErrTooLong) && maxLine < maxChunkedLineBytes {
+		return fmt.Errorf("a line is longer than %dMB; set --chunk-runes (e.g. 10000) to segment huge lines", maxLine>>20)
+	}
+	return err
+}
+
+// lineFilter selects the input lines to segment. With dedupe, a line seen
+// before in the pass is skipped and counted; lines are remembered by a 64-bit
+// FNV-1a hash, so memory stays at 8 bytes per distinct line. With a sample
+// rate, each remaining line is kept with that probability, drawn from a
+// generator seeded with seed, so the same input and seed give the same subset.
+type lineFilter struct {
+	dedupe     bool
+	seen       map[uint64]struct{}
+	duplicates int
+
+	sample  float64
+	seed    int64
+	rng     *rand.Rand
+	sampled int
+}
+
+// newLineFilter returns the filter for opts, or nil when every line is kept
+func newLineFilter(opts options) *lineFilter {
+	if !opts.dedupe && opts.sample == 0 {
+		return nil
+	}
+	f := &lineFilter{dedupe: opts.dedupe, sample: opts.sample, seed: opts.seed}
+	f.reset()
+	return f
+}
+
+// keep reports whether line should be segmented. A nil filter keeps every line.
+func (f *lineFilter) keep(line string) bool {
+	if f == nil {
+		return true
+	}
+	if f.dedupe {
+		h := hashLine(line)
+		if _, ok := f.seen[h]; ok {
+			f.duplicates++
+			return false
+		}
+		f.seen[h] = struct{}{}
+	}
+	if f.sample > 0 {
+		if f.rng.Float64() >= f.sample {
+			f.sampled++
+			return false
+		}
+	}
+	return true
+}
+
+// reset forgets the lines seen, before another pass over the input
+func (f *lineFilter) reset() {
+	if f == nil {
+		return
+	}
+	f.seen = make(map[uint64]struct{})
+	f.duplicates = 0
+	f.rng = rand.New(rand.NewSource(f.seed))
+	f.sampled = 0
+}
+
+// report prints what the filter skipped
+func (f *lineFilter) report() {
+	if f == nil {
+		return
+	}
+	if f.dedupe {
+		fmt.Printf("Duplicate lines: %d skipped, %d distinct\n", f.duplicates, len(f.seen))
+	}
+	if f.sample > 0 {
+		fmt.Printf("Sampling: %d lines left out (rate %g, seed %d)\n", f.sampled, f.sample, f.seed)
+	}
+}
+
+// hashLine is the 64-bit FNV-1a hash of line
+func hashLine(line string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(line); i++ {
+		h ^= uint64(line[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// shuffleLines puts lines, with their ids, in a random order drawn from
+// seed, the same for the same input and seed
+func shuffleLines(lines []string, ids []int, seed int64) {
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(lines), func(i, j int) {
+		lines[i], lines[j] = lines[j], lines[i]
+		ids[i], ids[j] = ids[j], ids[i]
+	})
+}
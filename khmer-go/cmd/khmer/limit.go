@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenBucket allows rate events per second on average and up to burst at once
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// allow takes a token if one is available, or reports how long until one is
+func (b *tokenBucket) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// limiter rejects requests beyond a concurrency limit or a request rate with
+// 429, so a burst of large documents is turned away instead of piling up
+// goroutines and memory. A zero limit is off.
+type limiter struct {
+	slots  chan struct{}
+	bucket *tokenBucket
+}
+
+func newLimiter(maxConcurrent int, rate float64, burst int) *limiter {
+	l := &limiter{}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	if rate > 0 {
+		l.bucket = newTokenBucket(rate, burst)
+	}
+	return l
+}
+
+// admit applies the limits to one request. It returns the function to call
+// when the request ends, or nil with how long the client should wait and why
+// when the request is over a limit.
+func (l *limiter) admit() (release func(), retryAfter time.Duration, reason string) {
+	if l == nil {
+		return func() {}, 0, ""
+	}
+	if l.bucket != nil {
+		if ok, wait := l.bucket.allow(); !ok {
+			return nil, wait, "rate limit exceeded"
+		}
+	}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			return func() { <-l.slots }, 0, ""
+		default:
+			return nil, time.Second, "too many concurrent requests"
+		}
+	}
+	return func() {}, 0, ""
+}
+
+// wrap applies the limits to next
+func (l *limiter) wrap(next http.Handler) http.Handler {
+	if l == nil || (l.slots == nil && l.bucket == nil) {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, wait, reason := l.admit()
+		if release == nil {
+			tooManyRequests(w, wait, reason)
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration, msg string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	httpError(w, http.StatusTooManyRequests, msg)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// listen opens a listener for spec: unix:///path/to.sock, tcp://host:port or
+// plain host:port. It returns the listener and a URL for the log. A stale
+// socket file left by a previous run is replaced.
+func listen(spec string) (net.Listener, string, error) {
+	if path, ok := strings.CutPrefix(spec, "unix://"); ok {
+		if path == "" {
+			return nil, "", fmt.Errorf("invalid listen address %q: missing socket path", spec)
+		}
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			if conn, err := net.Dial("unix", path); err == nil {
+				conn.Close()
+				return nil, "", fmt.Errorf("%s is in use by another server", path)
+			}
+			os.Remove(path)
+		}
+		ln, err := net.Listen("unix", path)
+		return ln, spec, err
+	}
+	addr := strings.TrimPrefix(spec, "tcp://")
+	ln, err := net.Listen("tcp", addr)
+	return ln, "http://" + addr, err
+}
+
+// serveUntilSignal serves handler on ln until SIGINT or SIGTERM, or until an
+// error arrives on fail, then lets requests in flight finish and returns that
+// error. Closing a Unix listener removes its socket file.
+func serveUntilSignal(ln net.Listener, handler http.Handler, fail <-chan error) error {
+	srv := &http.Server{Handler: handler}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	var failErr error
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	case failErr = <-fail:
+	}
+	logger.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return failErr
+}
+
+// tlsConfig builds the server TLS configuration for --tls-cert and
+// --tls-key, or returns nil when neither is set. With clientCA, clients must
+// present a certificate signed by it (mutual TLS).
+func tlsConfig(certFile, keyFile, clientCA string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCA != "" {
+			return nil, fmt.Errorf("--tls-client-ca needs --tls-cert and --tls-key")
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("--tls-cert and --tls-key must be given together")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if clientCA != "" {
+		pem, err := os.ReadFile(clientCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCA)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
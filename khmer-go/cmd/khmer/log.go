@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// logger receives progress and diagnostic messages; results stay on stdout
+var logger = slog.New(newPlainHandler(os.Stderr, slog.LevelInfo))
+
+// plainHandler renders records as "message key=value ..." for terminal use
+type plainHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Level
+	attrs []slog.Attr
+}
+
+func newPlainHandler(w io.Writer, level slog.Level) *plainHandler {
+	return &plainHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *plainHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *plainHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	if r.Level >= slog.LevelWarn {
+		sb.WriteString(r.Level.String())
+		sb.WriteString(": ")
+	}
+	sb.WriteString(r.Message)
+	writeAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&sb, " %s=%v", a.Key, a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	sb.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *plainHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is not needed by this CLI; groups are flattened
+func (h *plainHandler) WithGroup(string) slog.Handler { return h }
+
+// addLogFlags registers --log-format and --quiet on fs. The returned function
+// installs the configured logger and must be called after parsing.
+func addLogFlags(fs *flag.FlagSet) func() error {
+	format := fs.String("log-format", "plain", "Log format on stderr: plain, text or json")
+	quiet := fs.Bool("quiet", false, "Only log warnings and errors")
+	return func() error {
+		level := slog.LevelInfo
+		if *quiet {
+			level = slog.LevelWarn
+		}
+		opts := &slog.HandlerOptions{Level: level}
+		switch *format {
+		case "plain":
+			logger = slog.New(newPlainHandler(os.Stderr, level))
+		case "text":
+			logger = slog.New(slog.NewTextHandler(os.Stderr, opts))
+		case "json":
+			logger = slog.New(slog.NewJSONHandler(os.Stderr, opts))
+		default:
+			return fmt.Errorf("invalid --log-format %q: expected plain, text or json", *format)
+		}
+		return nil
+	}
+}
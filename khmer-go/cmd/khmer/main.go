@@ -1,13 +1,16 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
+	"math"
 	"os"
 	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/khmer-segmenter/pkg/khmer"
@@ -20,27 +23,131 @@ var builderPool = sync.Pool{
 	},
 }
 
+// record is one segmented input line; optional fields are omitted from output when unset
+type record struct {
+	id         int
+	input      string
+	segments   []string
+	romanized  []string
+	types      []khmer.TokenType
+	compounds  [][]string
+	values     []float64 // parsed NUMBER and CURRENCY segments, NaN elsewhere
+	currencies []string  // currency codes of CURRENCY segments, "" elsewhere
+	costs      []float32 // dictionary costs of Khmer segments, NaN elsewhere
+	timeUs     int64
+	timed      bool
+	// fields are the fields of an NDJSON input record, written in place of
+	// id and input; err replaces the segments of a record that could not be
+	// read
+	fields []recordField
+	err    string
+}
+
 // 1BRC optimization: Custom JSON builder - avoids reflection and allocation overhead of json.Marshal
 // Format: {"id":N,"input":"...","segments":["...","..."]}
-func buildJSON(sb *strings.Builder, id int, input string, segments []string) {
+// Optional "romanized", "types", "compounds", "values", "currencies" and "time_us" fields follow when set.
+// An NDJSON input record's own fields take the place of id and input.
+func buildJSON(sb *strings.Builder, rec *record) {
 	sb.Reset()
-	sb.Grow(len(input)*2 + len(segments)*10 + 50) // Pre-allocate estimated size
+	sb.Grow(len(rec.input)*2 + len(rec.segments)*10 + 50) // Pre-allocate estimated size
+
+	sb.WriteByte('{')
+	if rec.fields != nil {
+		writeInputFields(sb, rec.id, rec.fields)
+	} else {
+		sb.WriteString(`"id":`)
+		writeInt(sb, rec.id)
+		sb.WriteString(`,"input":"`)
+		writeEscapedJSON(sb, rec.input)
+		sb.WriteByte('"')
+	}
+	if rec.err != "" {
+		sb.WriteString(`,"error":"`)
+		writeEscapedJSON(sb, rec.err)
+		sb.WriteString(`"}`)
+		return
+	}
+	sb.WriteString(`,"segments":`)
+	writeStringArray(sb, rec.segments)
+	if rec.romanized != nil {
+		sb.WriteString(`,"romanized":`)
+		writeStringArray(sb, rec.romanized)
+	}
+	if rec.types != nil {
+		sb.WriteString(`,"types":[`)
+		for i, t := range rec.types {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteByte('"')
+			sb.WriteString(string(t))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte(']')
+	}
+	if rec.compounds != nil {
+		sb.WriteString(`,"compounds":[`)
+		for i, parts := range rec.compounds {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeStringArray(sb, parts)
+		}
+		sb.WriteByte(']')
+	}
+	if rec.values != nil {
+		sb.WriteString(`,"values":[`)
+		for i, v := range rec.values {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeNumberValue(sb, v)
+		}
+		sb.WriteByte(']')
+		sb.WriteString(`,"currencies":[`)
+		for i, code := range rec.currencies {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if code == "" {
+				sb.WriteString("null")
+			} else {
+				sb.WriteByte('"')
+				sb.WriteString(code)
+				sb.WriteByte('"')
+			}
+		}
+		sb.WriteByte(']')
+	}
+	if rec.timed {
+		sb.WriteString(`,"time_us":`)
+		writeInt(sb, int(rec.timeUs))
+	}
+	sb.WriteByte('}')
+}
 
-	sb.WriteString(`{"id":`)
-	writeInt(sb, id)
-	sb.WriteString(`,"input":"`)
-	writeEscapedJSON(sb, input)
-	sb.WriteString(`","segments":[`)
+// writeNumberValue writes a parsed number, or null for NaN
+func writeNumberValue(sb *strings.Builder, v float64) {
+	if math.IsNaN(v) {
+		sb.WriteString("null")
+		return
+	}
+	var buf [32]byte
+	sb.Write(strconv.AppendFloat(buf[:0], v, 'g', -1, 64))
+}
 
-	for i, seg := range segments {
+// writeStringArray writes a JSON array of escaped strings
+func writeStringArray(sb *strings.Builder, items []string) {
+	sb.WriteByte('[')
+	for i, item := range items {
 		if i > 0 {
 			sb.WriteByte(',')
 		}
 		sb.WriteByte('"')
-		writeEscapedJSON(sb, seg)
+		writeEscapedJSON(sb, item)
 		sb.WriteByte('"')
 	}
-	sb.WriteString(`]}`)
+	sb.WriteByte(']')
 }
 
 // 1BRC optimization: Fast integer to string (avoids strconv allocation)
@@ -95,99 +202,1102 @@ func writeEscapedJSON(sb *strings.Builder, s string) {
 	}
 }
 
+// subcommands are dispatched on the first argument; anything else runs segmentation
+var subcommands = map[string]func(args []string) error{
+	"train":    runTrain,
+	"dict":     runDict,
+	"stats":    runStats,
+	"serve":    runServe,
+	"diff":     runDiff,
+	"golden":   runGolden,
+	"bench":    runBench,
+	"tune":     runTune,
+	"annotate": runAnnotate,
+	"brat":     runBrat,
+	"gate":     runGate,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// Parse command-line arguments
 	dictPath := flag.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
 	freqPath := flag.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
-	inputPath := flag.String("input", "", "Input text file (required)")
-	outputPath := flag.String("output", "", "Output JSON file (required)")
+	var inputs stringList
+	flag.Var(&inputs, "input", "Input text file or http(s):// or s3:// URL (required; repeatable, extra files may also follow the flags)")
+	outputPath := flag.String("output", "", "Output JSON file (required); gzip-compressed if it ends in .gz (.zst needs -tags zstd)")
 	limit := flag.Int("limit", 0, "Limit number of lines (0 = unlimited)")
+	skip := flag.Int("skip", 0, "Skip this many non-empty lines before processing")
 	threads := flag.Int("threads", 0, "Number of worker threads (0 = use all CPUs)")
+	disablePasses := flag.String("disable-passes", "", "Comma-separated post-processing passes to skip (e.g. merge-unknowns)")
+	dropStopwords := flag.Bool("drop-stopwords", false, "Remove stopwords and separators from output segments")
+	stopwordsPath := flag.String("stopwords", "", "Stopword list file, one word per line (default: built-in list)")
+	subwords := flag.Bool("subwords", false, "Split out-of-vocabulary segments into syllables")
+	bpeMerges := flag.String("bpe-merges", "", "Split out-of-vocabulary segments into BPE pieces from a merges file (implies --subwords)")
+	filters := flag.String("filters", "", "Comma-separated token filters to apply in order: lowercase, strip-punct, arabic-digits, trim")
+	whitespace := flag.String("whitespace", khmer.WhitespaceKeep.String(), "Whitespace segments: keep, collapse (one \" \" per run) or drop")
+	inputFormat := flag.String("input-format", "text", "Input format: text (one line per record) or ndjson (one JSON object per line, see --text-field)")
+	textField := flag.String("text-field", "text", "Field of each NDJSON input record to segment; the other fields are copied to the output")
+	format := flag.String("format", "json", "Output format: json, msgpack, csv, es or html")
+	csvTokenSep := flag.String("csv-token-sep", "|", "Delimiter joining tokens within a CSV field")
+	fuzzy := flag.Bool("fuzzy", false, "Match dictionary words with one vowel sign, diacritic or coeng off (for noisy text)")
+	fuzzyPenalty := flag.Float64("fuzzy-penalty", float64(khmer.DefaultFuzzyPenalty), "Extra cost of a --fuzzy match")
+	affixes := flag.Bool("affixes", false, "Segment prefix+word and word+suffix forms (ការ-, ភាព-, អ្នក-, ...) missing from the dictionary as one word")
+	affixPenalty := flag.Float64("affix-penalty", float64(khmer.DefaultAffixPenalty), "Extra cost of an --affixes form over its stem")
+	mixedScript := flag.String("mixed-script", khmer.MixedScriptPerRune.String(), "Policy for Latin runs like Covid-19 or 5G: per-rune, keep, split-script or split-punct")
+	legacyChars := flag.String("legacy-chars", khmer.LegacyKeep.String(), "Deprecated ឣ/ឤ and invisible vowels U+17B4/U+17B5: keep, normalize or strip-invisible")
+	lossless := flag.Bool("lossless", false, "Keep zero-width spaces, invalid bytes and all other input text in the segments, so they join back into the line exactly")
+	lineTimeout := flag.Duration("line-timeout", 0, "Split the rest of a line into clusters once its segmentation takes this long (e.g. 100ms; 0 = no limit)")
+	chunkRunes := flag.Int("chunk-runes", 0, "Segment lines longer than this many runes in chunks split at spaces between words or sentence marks (e.g. 10000)")
+	lineWorkers := flag.Int("line-workers", 0, "Segment up to N chunks of a --chunk-runes line at once, so a huge line does not hold one worker for its whole length")
+	costsPath := flag.String("costs", "", "Cost file from khmer tune (dictionary and step costs)")
+	beam := flag.Float64("beam", 0, "Prune Viterbi states costlier than a path reaching further by more than this (e.g. 5; 0 = full search)")
+	rescorer := flag.String("rescorer", "", "Command that scores the near-best segmentations of ambiguous spans (JSON lines on stdin/stdout)")
+	rescoreEpsilon := flag.Float64("rescore-epsilon", float64(khmer.DefaultRescoreEpsilon), "Cost window of the alternatives offered to --rescorer")
+	tieBreak := flag.String("tie-break", khmer.TieBreakLongestLast.String(), "Rule for equal-cost segmentations: longest-last or fewest-segments")
+	numberValues := flag.Bool("number-values", false, "Add the parsed value of each NUMBER and CURRENCY segment, and currency codes (null for other segments)")
+	dedupe := flag.Bool("dedupe", false, "Skip lines seen before in the input (by hash) and report how many were duplicates")
+	sample := flag.Float64("sample", 0, "Segment a random subset of the lines, keeping each with this probability (e.g. 0.01)")
+	shuffle := flag.Bool("shuffle", false, "Segment and write the lines in a random order (see --seed)")
+	seed := flag.Int64("seed", 1, "Random seed for --sample and --shuffle, so a run can be reproduced")
+	cacheSize := flag.Int("cache", 0, "Cache the segments of up to N distinct lines (LRU), so duplicate lines are segmented once")
+	fastNonKhmer := flag.Bool("fast-non-khmer", false, "Split lines with no Khmer text at whitespace and punctuation, skipping the Viterbi loop")
+	groupCurrency := flag.Bool("group-currency", false, "Keep currency symbols with their amounts ($5, ១០០០៛) as one CURRENCY segment")
+	splitCompounds := flag.Bool("split-compounds", false, "Add the dictionary words making up each compound segment")
+	types := flag.Bool("types", false, "Add a token type (KHMER_WORD, NUMBER, PUNCT, ...) for each segment")
+	romanize := flag.String("romanize", "", "Add romanized segments using scheme: alalc or informal")
+	maxMemory := flag.String("max-memory", "", "Stream input keeping at most this much in flight (e.g. 256MB); output stays ordered")
+	resume := flag.Bool("resume", false, "Checkpoint progress while streaming to -o and continue an interrupted run from its checkpoint")
+	deterministic := flag.Bool("deterministic", false, "Guarantee byte-identical output and reports across runs and thread counts")
+	shardSize := flag.Int("shard-size", 0, "Write the output as numbered shards of at most N records (out-00001.jsonl, ...)")
+	writers := flag.Int("writers", 0, "Split the output over N files written directly by the workers (out-00001.jsonl, ...); order not preserved")
+	unordered := flag.Bool("unordered", false, "Write records as soon as they finish (output order not preserved)")
+	oovReport := flag.String("oov-report", "", "Write out-of-vocabulary tokens with counts and example contexts to this TSV file")
+	errorLogPath := flag.String("error-log", "", "Write the error records of lines that could not be segmented to this file instead of the output")
+	metadata := flag.Bool("metadata", false, "Write a sidecar next to the output (out.meta.json) with the tool version, schema version, dictionary checksums and options")
+	verify := flag.String("verify", "", "Compare the output with this reference output by record id (time_us ignored) and fail on any mismatch")
+	debug := flag.Bool("debug", false, "Print the Viterbi candidates and costs at each position of the text given as argument, instead of segmenting files")
+	timing := flag.Bool("timing", false, "Add per-line time_us to output and print a latency histogram")
+	watch := flag.Bool("watch", false, "Keep the dictionary loaded and re-segment the input whenever it changes")
+	watchInterval := flag.Duration("watch-interval", time.Second, "How often --watch checks the input for changes, and how long a change must settle")
+	cpuProfile := flag.String("cpuprofile", "", "Write a CPU profile to this file")
+	memProfile := flag.String("memprofile", "", "Write a heap profile to this file on exit")
+	memReport := flag.Bool("mem-report", false, "Print dictionary size and memory use after loading")
+	pprofAddr := flag.String("pprof", "", "Serve net/http/pprof on this address (e.g. localhost:6060)")
+	var patterns stringList
+	flag.Var(&patterns, "pattern", "Custom token pattern as name=regex (repeatable)")
+	var gazetteers stringList
+	var domains stringList
+	flag.Var(&domains, "domain", "Extra dictionary as NAME=dict[,freq], selected per input as NAME:path (repeatable)")
+	mergeSpec := flag.String("merge", "", "Merge another dictionary as dict[,freq] into --dict, interpolating costs")
+	mergeWeight := flag.Float64("merge-weight", 0.5, "Weight of the --merge dictionary's probabilities (0-1)")
+	useDomain := flag.String("use", defaultDomain, "Domain for inputs without a NAME: prefix")
+	flag.Var(&gazetteers, "gazetteer", "Named-entity list as TYPE=path, matched as whole tokens and tagged TYPE (repeatable; implies --types)")
+	gazetteerCost := flag.Float64("gazetteer-cost", float64(khmer.DefaultGazetteerCost), "Path cost of a --gazetteer entry without its own cost")
+	flag.BoolVar(&noVariants, "no-variants", false, "Skip Coeng Ta/Da and Coeng Ro spelling variants when loading the dictionary")
+	applyLogFlags := addLogFlags(flag.CommandLine)
 
 	// Short aliases
 	flag.StringVar(dictPath, "d", *dictPath, "Path to dictionary file (short)")
 	flag.StringVar(freqPath, "f", *freqPath, "Path to frequency file (short)")
-	flag.StringVar(inputPath, "i", "", "Input text file (short)")
+	flag.Var(&inputs, "i", "Input text file (short)")
 	flag.StringVar(outputPath, "o", "", "Output JSON file (short)")
 	flag.IntVar(limit, "l", 0, "Limit number of lines (short)")
 	flag.IntVar(threads, "t", 0, "Number of worker threads (short)")
 
 	flag.Parse()
+	if err := applyLogFlags(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	if *inputPath == "" {
+	inputs = append(inputs, flag.Args()...)
+	if len(inputs) == 0 {
 		fmt.Fprintln(os.Stderr, "Usage: khmer --input <file> [--output <file>] [options]")
+		fmt.Fprintln(os.Stderr, "       khmer [--output <dir>] [options] <file> <file>...")
+		fmt.Fprintln(os.Stderr, "       khmer train --corpus <file> --out <file> [options]")
+		fmt.Fprintln(os.Stderr, "       khmer dict <compile|validate|diff|stats|export> [options]")
+		fmt.Fprintln(os.Stderr, "       khmer stats --input <file> [options]")
+		fmt.Fprintln(os.Stderr, "       khmer serve [--addr host:port] [options]")
+		fmt.Fprintln(os.Stderr, "       khmer diff [options] <a.json> <b.json>")
+		fmt.Fprintln(os.Stderr, "       khmer golden --input <file> [--corrected <file>] [options]")
+		fmt.Fprintln(os.Stderr, "       khmer tune [--gold <file>] [--out <file>] [options]")
+		fmt.Fprintln(os.Stderr, "       khmer annotate --input <file> [--gold <file>] [options]")
+		fmt.Fprintln(os.Stderr, "       khmer brat --input <file> [--out <dir>] [options]")
+		fmt.Fprintln(os.Stderr, "       khmer gate [--gold <file>] [--min-f1 <f>] [options]")
 		fmt.Fprintln(os.Stderr, "Options:")
-		fmt.Fprintln(os.Stderr, "  --dict, -d <path>   Path to dictionary file")
+		fmt.Fprintln(os.Stderr, "  --dict, -d <path>   Path to dictionary file (text or compiled)")
 		fmt.Fprintln(os.Stderr, "  --freq, -f <path>   Path to frequency file")
-		fmt.Fprintln(os.Stderr, "  --output, -o <path> Output file (optional, skip to benchmark only)")
+		fmt.Fprintln(os.Stderr, "  --domain <NAME=dict[,freq]>  Extra dictionary, used for inputs given as NAME:path (repeatable)")
+		fmt.Fprintln(os.Stderr, "  --merge <dict[,freq]>  Merge another dictionary into --dict, interpolating costs")
+		fmt.Fprintln(os.Stderr, "  --merge-weight <w>  Weight of the --merge dictionary (default 0.5)")
+		fmt.Fprintln(os.Stderr, "  --use <name>        Domain for inputs without a prefix (default: the --dict dictionary)")
+		fmt.Fprintln(os.Stderr, "  --output, -o <path> Output file (optional, skip to benchmark only);")
+		fmt.Fprintln(os.Stderr, "                      a directory of <name>.json files with several inputs")
+		fmt.Fprintln(os.Stderr, "                      gzip-compressed if the path ends in .gz (.zst: -tags zstd)")
 		fmt.Fprintln(os.Stderr, "  --limit, -l <n>     Limit number of lines")
+		fmt.Fprintln(os.Stderr, "  --skip <n>          Skip the first n non-empty lines (ids keep counting from n)")
 		fmt.Fprintln(os.Stderr, "  --threads, -t <n>   Number of worker threads")
+		fmt.Fprintln(os.Stderr, "  --disable-passes <names>  Skip post-processing passes (snap-single-consonants,heuristics,merge-unknowns)")
+		fmt.Fprintln(os.Stderr, "  --pattern <name=regex>    Keep tokens matching regex whole (repeatable)")
+		fmt.Fprintln(os.Stderr, "  --gazetteer <TYPE=path>   Named-entity list, tagged TYPE in types (repeatable)")
+		fmt.Fprintln(os.Stderr, "  --gazetteer-cost <cost>   Path cost of a gazetteer entry (default 2)")
+		fmt.Fprintln(os.Stderr, "  --drop-stopwords          Remove stopwords and separators from output")
+		fmt.Fprintln(os.Stderr, "  --stopwords <path>        Custom stopword list (implies --drop-stopwords)")
+		fmt.Fprintln(os.Stderr, "  --subwords                Split OOV segments into syllables")
+		fmt.Fprintln(os.Stderr, "  --bpe-merges <path>       Split OOV segments into BPE pieces (implies --subwords)")
+		fmt.Fprintln(os.Stderr, "  --filters <list>          Token filters: lowercase, strip-punct, arabic-digits, trim")
+		fmt.Fprintln(os.Stderr, "  --whitespace <policy>     Whitespace segments: keep (default), collapse, drop")
+		fmt.Fprintln(os.Stderr, "  --input-format <fmt>      Input format: text (default), ndjson")
+		fmt.Fprintln(os.Stderr, "  --text-field <name>       NDJSON field to segment (default text)")
+		fmt.Fprintln(os.Stderr, "  --format <fmt>            Output format: json (default), msgpack, csv, es, html")
+		fmt.Fprintln(os.Stderr, "  --csv-token-sep <s>       Delimiter joining tokens in CSV fields (default |)")
+		fmt.Fprintln(os.Stderr, "  --fuzzy                   Let near-miss words match (one mark off) at a penalty")
+		fmt.Fprintln(os.Stderr, "  --fuzzy-penalty <cost>    Extra cost of a fuzzy match (default 5)")
+		fmt.Fprintln(os.Stderr, "  --affixes                 Join known prefixes/suffixes to dictionary stems")
+		fmt.Fprintln(os.Stderr, "  --affix-penalty <cost>    Extra cost of an affixed form (default 1)")
+		fmt.Fprintln(os.Stderr, "  --mixed-script <policy>   Latin runs: per-rune (default), keep, split-script, split-punct")
+		fmt.Fprintln(os.Stderr, "  --line-timeout <d>        Cluster-split the rest of a line after this long (e.g. 100ms)")
+		fmt.Fprintln(os.Stderr, "  --chunk-runes <n>         Segment lines over n runes in chunks at safe boundaries")
+		fmt.Fprintln(os.Stderr, "  --line-workers <n>        Segment up to n chunks of a long line at once")
+		fmt.Fprintln(os.Stderr, "  --costs <path>            Cost file written by khmer tune")
+		fmt.Fprintln(os.Stderr, "  --beam <cost>             Prune the Viterbi search to this cost beam (e.g. 5)")
+		fmt.Fprintln(os.Stderr, "  --rescorer <command>      Let an external model pick among near-best segmentations")
+		fmt.Fprintln(os.Stderr, "  --rescore-epsilon <cost>  Cost window of the alternatives it is offered (default 2)")
+		fmt.Fprintln(os.Stderr, "  --tie-break <rule>        Equal-cost paths: longest-last (default), fewest-segments")
+		fmt.Fprintln(os.Stderr, "  --legacy-chars <policy>   Deprecated/invisible vowels: keep (default), normalize, strip-invisible")
+		fmt.Fprintln(os.Stderr, "  --lossless                Segments join back into each input line exactly")
+		fmt.Fprintln(os.Stderr, "  --types                   Add a token type for each segment")
+		fmt.Fprintln(os.Stderr, "  --number-values           Add parsed values of number and currency segments")
+		fmt.Fprintln(os.Stderr, "  --group-currency          Keep currency symbols with their amounts")
+		fmt.Fprintln(os.Stderr, "  --fast-non-khmer          Split non-Khmer lines at spaces/punctuation without Viterbi")
+		fmt.Fprintln(os.Stderr, "  --dedupe                  Skip repeated lines and report the duplicate count")
+		fmt.Fprintln(os.Stderr, "  --sample <rate>           Segment a random subset of lines (e.g. 0.01)")
+		fmt.Fprintln(os.Stderr, "  --shuffle                 Segment and write lines in a random order")
+		fmt.Fprintln(os.Stderr, "  --seed <n>                Random seed for --sample and --shuffle (default 1)")
+		fmt.Fprintln(os.Stderr, "  --cache <n>               Segment duplicate lines once, caching up to n lines")
+		fmt.Fprintln(os.Stderr, "  --split-compounds         Add the parts of compound words as sub-tokens")
+		fmt.Fprintln(os.Stderr, "  --romanize <scheme>       Add romanized segments (alalc, informal)")
+		fmt.Fprintln(os.Stderr, "  --deterministic           Byte-identical output across runs and thread counts")
+		fmt.Fprintln(os.Stderr, "  --shard-size <n>          Write output shards of at most n records")
+		fmt.Fprintln(os.Stderr, "  --writers <n>             Split output over n files written by the workers")
+		fmt.Fprintln(os.Stderr, "  --unordered               Write records as they finish; order not preserved")
+		fmt.Fprintln(os.Stderr, "  --max-memory <size>       Stream input with bounded memory (e.g. 256MB)")
+		fmt.Fprintln(os.Stderr, "  --resume                  Checkpoint progress; continue an interrupted run")
+		fmt.Fprintln(os.Stderr, "  --oov-report <path>       Write OOV tokens with counts and contexts (TSV)")
+		fmt.Fprintln(os.Stderr, "  --error-log <path>        Write error records of failed lines here, not to the output")
+		fmt.Fprintln(os.Stderr, "  --metadata                Write out.meta.json with version, dictionary checksums, options")
+		fmt.Fprintln(os.Stderr, "  --verify <path>           Compare the output with a reference; fail on mismatches")
+		fmt.Fprintln(os.Stderr, "  --debug                   Print the Viterbi candidates and costs for the text argument")
+		fmt.Fprintln(os.Stderr, "  --timing                  Add per-line time_us and print a latency summary")
+		fmt.Fprintln(os.Stderr, "  --watch                   Re-segment the input whenever it changes (Ctrl-C to stop)")
+		fmt.Fprintln(os.Stderr, "  --watch-interval <d>      Polling and settle interval for --watch (default 1s)")
+		fmt.Fprintln(os.Stderr, "  --no-variants             Don't add Coeng Ta/Da and Coeng Ro spelling variants")
+		fmt.Fprintln(os.Stderr, "  --mem-report              Print dictionary size and memory use after loading")
+		fmt.Fprintln(os.Stderr, "  --cpuprofile <path>       Write a CPU profile")
+		fmt.Fprintln(os.Stderr, "  --memprofile <path>       Write a heap profile on exit")
+		fmt.Fprintln(os.Stderr, "  --pprof <addr>            Serve net/http/pprof while running")
+		fmt.Fprintln(os.Stderr, "  --log-format <fmt>        Log format on stderr: plain, text, json")
+		fmt.Fprintln(os.Stderr, "  --quiet                   Only log warnings and errors")
+		os.Exit(1)
+	}
+
+	if *debug && len(inputs) != 1 {
+		fmt.Fprintln(os.Stderr, "Error: --debug takes one text argument to trace, e.g. khmer --debug \"ខ្ញុំទៅសាលារៀន\"")
+		os.Exit(1)
+	}
+
+	if *metadata && *outputPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: --metadata writes a sidecar next to the output and needs -o")
+		os.Exit(1)
+	}
+
+	if *sample < 0 || *sample > 1 {
+		fmt.Fprintln(os.Stderr, "Error: --sample must be between 0 and 1")
+		os.Exit(1)
+	}
+
+	maxMemoryBytes, err := parseSize(*maxMemory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --max-memory: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := options{
+		dictPath:      *dictPath,
+		freqPath:      *freqPath,
+		domains:       domains,
+		useDomain:     *useDomain,
+		mergeSpec:     *mergeSpec,
+		mergeWeight:   float32(*mergeWeight),
+		inputPath:     inputs[0],
+		inputPaths:    inputs,
+		outputPath:    *outputPath,
+		limit:         *limit,
+		skip:          *skip,
+		threads:       *threads,
+		disablePasses: splitList(*disablePasses),
+		patterns:      patterns,
+		gazetteers:    gazetteers,
+		gazetteerCost: float32(*gazetteerCost),
+		dropStopwords: *dropStopwords || *stopwordsPath != "",
+		stopwordsPath: *stopwordsPath,
+		subwords:      *subwords || *bpeMerges != "",
+		bpeMerges:     *bpeMerges,
+		filters:       splitList(*filters),
+		whitespace:    *whitespace,
+		romanize:      *romanize,
+		types:         *types || len(gazetteers) > 0 || strings.EqualFold(*format, "html"),
+		compounds:     *splitCompounds,
+		numberValues:  *numberValues,
+		groupCurrency: *groupCurrency,
+		fastNonKhmer:  *fastNonKhmer,
+		cacheSize:     *cacheSize,
+		dedupe:        *dedupe,
+		sample:        *sample,
+		shuffle:       *shuffle,
+		seed:          *seed,
+		fuzzyPenalty:  fuzzyPenaltyFor(*fuzzy, *fuzzyPenalty),
+		affixes:       *affixes,
+		affixPenalty:  float32(*affixPenalty),
+		lineTimeout:   *lineTimeout,
+		chunkRunes:    *chunkRunes,
+		lineWorkers:   *lineWorkers,
+		costsPath:     *costsPath,
+		beam:          float32(*beam),
+		rescorer:      *rescorer,
+		rescoreEps:    float32(*rescoreEpsilon),
+		tieBreak:      *tieBreak,
+		mixedScript:   *mixedScript,
+		legacyChars:   *legacyChars,
+		lossless:      *lossless,
+		inputFormat:   strings.ToLower(*inputFormat),
+		textField:     *textField,
+		format:        *format,
+		csvTokenSep:   *csvTokenSep,
+		timing:        *timing,
+		debug:         *debug,
+		metadata:      *metadata,
+		verifyPath:    *verify,
+		setFlags:      setFlags(flag.CommandLine),
+		oovReportPath: *oovReport,
+		errorLogPath:  *errorLogPath,
+		deterministic: *deterministic,
+		shardSize:     *shardSize,
+		writers:       *writers,
+		unordered:     *unordered,
+		maxMemory:     maxMemoryBytes,
+		resume:        *resume,
+		watch:         *watch,
+		memReport:     *memReport,
+		watchInterval: *watchInterval,
+	}
+
+	if opts.deterministic && (opts.unordered || opts.timing) {
+		fmt.Fprintln(os.Stderr, "Error: --deterministic cannot be used with --unordered or --timing, whose output varies between runs")
+		os.Exit(1)
+	}
+
+	if opts.shuffle && opts.maxMemory > 0 {
+		fmt.Fprintln(os.Stderr, "Error: --shuffle reads the whole input and cannot be used with --max-memory")
+		os.Exit(1)
+	}
+
+	if opts.verifyPath != "" {
+		if opts.outputPath == "" || len(inputs) > 1 || opts.watch || !strings.EqualFold(opts.format, "json") {
+			fmt.Fprintln(os.Stderr, "Error: --verify compares a single JSON output file and needs -o, one input and --format json")
+			os.Exit(1)
+		}
+		if opts.shardSize > 0 || opts.writers > 1 {
+			fmt.Fprintln(os.Stderr, "Error: --verify cannot be used with --shard-size or --writers")
+			os.Exit(1)
+		}
+	}
+
+	if opts.writers > 1 {
+		if opts.outputPath == "" || len(inputs) > 1 || opts.watch {
+			fmt.Fprintln(os.Stderr, "Error: --writers needs a single input file and -o")
+			os.Exit(1)
+		}
+		if opts.deterministic || opts.maxMemory > 0 || opts.resume || opts.shardSize > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --writers cannot be used with --deterministic, --max-memory, --resume or --shard-size")
+			os.Exit(1)
+		}
+	}
+
+	switch {
+	case opts.inputFormat != "text" && opts.inputFormat != "ndjson":
+		fmt.Fprintf(os.Stderr, "Error: unknown input format %q (available: text, ndjson)\n", *inputFormat)
+		os.Exit(1)
+	case opts.inputFormat == "ndjson" && !strings.EqualFold(opts.format, "json"):
+		fmt.Fprintln(os.Stderr, "Error: --input-format ndjson copies JSON fields and needs --format json")
+		os.Exit(1)
+	case opts.inputFormat == "ndjson" && opts.textField == "":
+		fmt.Fprintln(os.Stderr, "Error: --text-field cannot be empty")
+		os.Exit(1)
+	}
+
+	if opts.lineWorkers > 1 && opts.chunkRunes <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --line-workers splits lines into --chunk-runes chunks and needs it")
+		os.Exit(1)
+	}
+
+	if err := checkOutputPath(opts.outputPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if isRemoteInput(opts.inputPath) && (opts.watch || opts.resume) {
+		fmt.Fprintln(os.Stderr, "Error: --watch and --resume need a local input file")
+		os.Exit(1)
+	}
+
+	if opts.resume {
+		if opts.outputPath == "" || len(inputs) > 1 || opts.watch {
+			fmt.Fprintln(os.Stderr, "Error: --resume needs a single input file and -o")
+			os.Exit(1)
+		}
+		if opts.unordered || opts.shuffle || opts.dedupe || opts.sample > 0 || opts.shardSize > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --resume cannot be used with --unordered, --shuffle, --dedupe, --sample or --shard-size")
+			os.Exit(1)
+		}
+		// A resumable run streams the input
+		if opts.maxMemory == 0 {
+			opts.maxMemory = defaultResumeMemory
+		}
+	}
+
+	if len(inputs) > 1 && (opts.watch || opts.unordered || opts.maxMemory > 0) {
+		fmt.Fprintln(os.Stderr, "Error: --watch, --unordered and --max-memory take a single input file")
 		os.Exit(1)
 	}
 
-	if err := run(*dictPath, *freqPath, *inputPath, *outputPath, *limit, *threads); err != nil {
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile, *pprofAddr)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	err = run(opts)
+	if perr := stopProfiling(); err == nil {
+		err = perr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// options holds the parsed command-line configuration for a segmentation run
+type options struct {
+	dictPath      string
+	freqPath      string
+	domains       []string
+	useDomain     string
+	mergeSpec     string
+	mergeWeight   float32
+	inputPath     string
+	inputPaths    []string
+	inputDomains  []string
+	outputPath    string
+	limit         int
+	skip          int
+	threads       int
+	disablePasses []string
+	patterns      []string
+	gazetteers    []string
+	gazetteerCost float32
+	dropStopwords bool
+	stopwordsPath string
+	subwords      bool
+	bpeMerges     string
+	filters       []string
+	whitespace    string
+	romanize      string
+	types         bool
+	compounds     bool
+	numberValues  bool
+	groupCurrency bool
+	fastNonKhmer  bool
+	cacheSize     int
+	dedupe        bool
+	sample        float64
+	shuffle       bool
+	seed          int64
+	fuzzyPenalty  float32
+	affixes       bool
+	affixPenalty  float32
+	lineTimeout   time.Duration
+	chunkRunes    int
+	lineWorkers   int
+	costsPath     string
+	beam          float32
+	rescorer      string
+	rescoreEps    float32
+	tieBreak      string
+	mixedScript   string
+	legacyChars   string
+	lossless      bool
+	inputFormat   string
+	textField     string
+	format        string
+	csvTokenSep   string
+	timing        bool
+	debug         bool
+	metadata      bool
+	// verifyPath is a reference output to compare the output with
+	verifyPath string
+	// setFlags are the flags given on the command line, for --metadata
+	setFlags      map[string]string
+	oovReportPath string
+	errorLogPath  string
+	deterministic bool
+	shardSize     int
+	writers       int
+	unordered     bool
+	maxMemory     int64
+	resume        bool
+	watch         bool
+	memReport     bool
+	watchInterval time.Duration
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// parseRecognizers builds regex recognizers from name=regex flag values
+func parseRecognizers(specs []string) ([]khmer.Recognizer, error) {
+	recognizers := make([]khmer.Recognizer, 0, len(specs))
+	for _, spec := range specs {
+		name, pattern, ok := strings.Cut(spec, "=")
+		if !ok || name == "" || pattern == "" {
+			return nil, fmt.Errorf("invalid --pattern %q: expected name=regex", spec)
+		}
+		rec, err := khmer.NewRegexRecognizer(name, pattern)
+		if err != nil {
+			return nil, err
+		}
+		recognizers = append(recognizers, rec)
+	}
+	return recognizers, nil
 }
 
-func run(dictPath, freqPath, inputPath, outputPath string, limit, threads int) error {
-	fmt.Println("Initializing Go Segmenter...")
-	fmt.Printf("Dictionary: %s\n", dictPath)
-	fmt.Printf("Frequencies: %s\n", freqPath)
+// numberValues parses each NUMBER and CURRENCY segment, returning the
+// values (NaN for other segments) and currency codes ("" for others). types
+// may be nil, in which case segments are classified here.
+func numberValues(segments []string, types []khmer.TokenType, dict *khmer.Dictionary) ([]float64, []string) {
+	values := make([]float64, len(segments))
+	codes := make([]string, len(segments))
+	for i, seg := range segments {
+		values[i] = math.NaN()
+		var t khmer.TokenType
+		if types != nil {
+			t = types[i]
+		} else {
+			t = khmer.ClassifyToken(seg, dict)
+		}
+		switch t {
+		case khmer.TokenNumber:
+			if v, err := khmer.ParseNumber(seg); err == nil {
+				values[i] = v
+			}
+		case khmer.TokenCurrency:
+			if c, err := khmer.ParseCurrency(seg); err == nil {
+				values[i], codes[i] = c.Value, c.Code
+			}
+		}
+	}
+	return values, codes
+}
+
+// loadGazetteers loads the TYPE=path gazetteers given with --gazetteer
+func loadGazetteers(specs []string, cost float32) ([]*khmer.Gazetteer, error) {
+	gazetteers := make([]*khmer.Gazetteer, 0, len(specs))
+	for _, spec := range specs {
+		name, path, ok := strings.Cut(spec, "=")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("invalid --gazetteer %q: expected TYPE=path", spec)
+		}
+		g, err := khmer.LoadGazetteer(khmer.TokenType(strings.ToUpper(name)), path)
+		if err != nil {
+			return nil, err
+		}
+		g.Cost = cost
+		logger.Info("Loaded gazetteer", "type", g.Type, "path", path, "entries", g.Len())
+		gazetteers = append(gazetteers, g)
+	}
+	return gazetteers, nil
+}
+
+// fuzzyPenaltyFor returns the segmenter's FuzzyPenalty: 0 (off) unless --fuzzy is set
+func fuzzyPenaltyFor(enabled bool, penalty float64) float32 {
+	if !enabled {
+		return 0
+	}
+	return float32(penalty)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// processor holds the shared, read-only configuration for segmenting lines
+type processor struct {
+	// dictionary is the one workers start with; domains holds all loaded
+	// dictionaries by name, for inputs that select another
+	dictionary  *khmer.Dictionary
+	domains     map[string]*khmer.Dictionary
+	pipeline    khmer.Pipeline
+	recognizers []khmer.Recognizer
+	gazetteers  []*khmer.Gazetteer
+	scheme      *khmer.RomanizationScheme
+	fuzzy       float32
+	affixes     *khmer.Affixes
+	tieBreak    khmer.TieBreak
+	mixed       khmer.MixedScript
+	legacy      khmer.LegacyChars
+	lossless    bool
+	timeout     time.Duration
+	chunkRunes  int
+	lineWorkers int
+	costs       *khmer.StepCosts
+	beam        float32
+	rescorer    khmer.Rescorer
+	rescoreEps  float32
+	types       bool
+	// tokenCosts adds the dictionary cost of each segment to records
+	tokenCosts bool
+	compounds  bool
+	values     bool
+	currency   bool
+	fastPath   bool
+	format     *outputFormat
+	timing     bool
+	// textField is the field segmented in NDJSON input records ("" for
+	// plain text lines)
+	textField string
+
+	// oov accumulates the OOV report across workers (nil when not requested);
+	// with deterministic its examples do not depend on which worker saw a line
+	oovMu         sync.Mutex
+	oov           *khmer.OOVReport
+	deterministic bool
+
+	// cache is shared by the workers (nil when not requested); cacheStart
+	// holds its counts when the current pass began
+	cache      *khmer.SegmentCache
+	cacheStart khmer.CacheStats
+
+	// input selects the lines read (nil keeps all); it is used by the
+	// reading goroutine only
+	input *lineFilter
+
+	// failed counts the lines of the pass that could not be segmented; their
+	// error records go to errorLog when set
+	failed   atomic.Int64
+	errorLog *errorLog
+}
+
+// worker segments lines on one goroutine; it is not safe for concurrent use
+type worker struct {
+	proc      *processor
+	segmenter *khmer.KhmerSegmenter
+	sb        *strings.Builder
+	oov       *khmer.OOVReport
+}
+
+func (p *processor) newWorker() *worker {
+	// 1BRC optimization: Reuse string builder from pool
+	w := &worker{proc: p, segmenter: p.newSegmenter(), sb: builderPool.Get().(*strings.Builder)}
+	if p.oov != nil {
+		w.oov = p.newOOVReport()
+	}
+	return w
+}
+
+// newSegmenter returns a segmenter configured for the run
+func (p *processor) newSegmenter() *khmer.KhmerSegmenter {
+	segmenter := khmer.NewKhmerSegmenter(p.dictionary)
+	segmenter.PostProcessors = p.pipeline
+	segmenter.Recognizers = p.recognizers
+	segmenter.Gazetteers = p.gazetteers
+	segmenter.GroupCurrency = p.currency
+	segmenter.NonKhmerFastPath = p.fastPath
+	segmenter.Cache = p.cache
+	segmenter.FuzzyPenalty = p.fuzzy
+	segmenter.Affixes = p.affixes
+	segmenter.TieBreak = p.tieBreak
+	segmenter.MixedScript = p.mixed
+	segmenter.LegacyChars = p.legacy
+	segmenter.Lossless = p.lossless
+	segmenter.Timeout = p.timeout
+	segmenter.ChunkRunes = p.chunkRunes
+	segmenter.Parallel = p.lineWorkers
+	segmenter.Costs = p.costs
+	segmenter.Beam = p.beam
+	segmenter.Rescorer = p.rescorer
+	segmenter.RescoreEpsilon = p.rescoreEps
+	return segmenter
+}
+
+// close returns pooled buffers and merges the worker's OOV report into the processor's
+func (w *worker) close() {
+	builderPool.Put(w.sb)
+	if w.oov != nil {
+		w.proc.oovMu.Lock()
+		w.proc.oov.Merge(w.oov)
+		w.proc.oovMu.Unlock()
+	}
+}
+
+// newOOVReport creates an empty OOV report
+func (p *processor) newOOVReport() *khmer.OOVReport {
+	r := khmer.NewOOVReport()
+	r.StableExamples = p.deterministic
+	return r
+}
+
+// resetReports clears per-run reports before a segmentation pass
+func (p *processor) resetReports() {
+	if p.cache != nil {
+		p.cacheStart = p.cache.Stats()
+	}
+	if p.oov != nil {
+		p.oov = p.newOOVReport()
+	}
+	p.input.reset()
+	p.failed.Store(0)
+	if p.errorLog != nil {
+		if err := p.errorLog.reset(); err != nil {
+			logger.Warn("Could not reset error log", "path", p.errorLog.path, "error", err)
+		}
+	}
+}
+
+// writeReports writes the reports requested in opts after a segmentation pass
+func (p *processor) writeReports(opts options) error {
+	p.input.report()
+	if p.errorLog != nil {
+		if err := p.errorLog.flush(); err != nil {
+			return err
+		}
+	}
+	if failed := p.failed.Load(); failed > 0 {
+		if p.errorLog != nil {
+			logger.Warn("Some lines could not be segmented", "lines", failed, "error_log", p.errorLog.path)
+		} else {
+			logger.Warn("Some lines could not be segmented; their records have an error field", "lines", failed)
+		}
+	}
+	if p.cache != nil {
+		st := p.cache.Stats()
+		hits, misses := st.Hits-p.cacheStart.Hits, st.Misses-p.cacheStart.Misses
+		pass := khmer.CacheStats{Hits: hits, Misses: misses}
+		fmt.Printf("Line cache: %d hits, %d misses (%.1f%% hit rate), %d lines cached\n",
+			hits, misses, pass.HitRate()*100, st.Entries)
+	}
+	if p.oov == nil {
+		return nil
+	}
+	file, err := os.Create(opts.oovReportPath)
+	if err != nil {
+		return fmt.Errorf("could not create OOV report: %w", err)
+	}
+	defer file.Close()
+	if err := p.oov.WriteTSV(file); err != nil {
+		return err
+	}
+	logger.Info("Saved OOV report", "path", opts.oovReportPath, "types", len(p.oov.Counts))
+	return nil
+}
+
+// process segments one line and returns its encoded output record (including
+// the record terminator) and, when timing is enabled, the segmentation time in
+// microseconds. A panic while segmenting gives the line's error record (see
+// fail) instead of ending the run.
+func (w *worker) process(id int, line string) (out string, timeUs int64) {
+	defer func() {
+		if v := recover(); v != nil {
+			logger.Error("Segmentation panicked", "id", id, "panic", v, "stack", string(debug.Stack()))
+			// The segmenter's buffers may be left inconsistent
+			dict := w.segmenter.Dictionary
+			w.segmenter = w.proc.newSegmenter()
+			w.segmenter.Dictionary = dict
+			out, timeUs = w.fail(id, line, fmt.Sprint("panic: ", v)), 0
+		}
+	}()
+	var lineStart time.Time
+	if w.proc.timing {
+		lineStart = time.Now()
+	}
+	rec := record{id: id, input: line}
+	text := line
+	if w.proc.textField != "" {
+		fields, fieldText, err := parseNDJSON(line, w.proc.textField)
+		if err != nil {
+			return w.fail(id, line, err.Error()), 0
+		}
+		rec.fields, text = fields, fieldText
+	}
+	rec.segments = w.segmenter.Segment(text)
+	if w.proc.timing {
+		rec.timeUs = time.Since(lineStart).Microseconds()
+		rec.timed = true
+	}
+	if w.proc.scheme != nil {
+		rec.romanized = w.proc.scheme.RomanizeSegments(rec.segments)
+	}
+	if w.proc.types {
+		rec.types = khmer.ClassifyTokens(rec.segments, w.segmenter.Dictionary)
+		khmer.TagEntities(rec.types, rec.segments, w.proc.gazetteers)
+	}
+	if w.proc.tokenCosts {
+		rec.costs = segmentCosts(rec.segments, rec.types, w.segmenter.Dictionary)
+	}
+	if w.proc.compounds {
+		rec.compounds = khmer.SplitCompounds(rec.segments, w.segmenter.Dictionary)
+	}
+	if w.proc.values {
+		rec.values, rec.currencies = numberValues(rec.segments, rec.types, w.segmenter.Dictionary)
+	}
+	if w.oov != nil {
+		w.oov.Add(rec.segments, w.segmenter.Dictionary)
+	}
+
+	// 1BRC optimization: Custom encoders (no reflection, minimal allocation)
+	w.proc.format.encode(w.sb, &rec)
+	return w.sb.String(), rec.timeUs
+}
+
+// buildPipeline returns the default post-processing pipeline without the disabled passes
+func buildPipeline(disabled []string) (khmer.Pipeline, error) {
+	pipeline := khmer.DefaultPipeline()
+	for _, name := range disabled {
+		if pipeline.Index(name) < 0 {
+			return nil, fmt.Errorf("unknown post-processing pass %q (available: %s)",
+				name, strings.Join(pipeline.Names(), ", "))
+		}
+	}
+	return pipeline.Without(disabled...), nil
+}
+
+func run(opts options) error {
+	logger.Info("Initializing Go Segmenter", "dict", opts.dictPath, "freq", opts.freqPath)
 
 	startLoad := time.Now()
 
-	dictionary := khmer.NewDictionary()
-	if err := dictionary.Load(dictPath, freqPath); err != nil {
+	specs, err := parseDomains(opts.domains, opts.dictPath, opts.freqPath)
+	if err != nil {
+		return err
+	}
+	domains, err := loadDomains(specs)
+	if err != nil {
 		return err
 	}
+	if opts.mergeSpec != "" {
+		dictPath, freqPath, ok := strings.Cut(opts.mergeSpec, ",")
+		if !ok {
+			freqPath = opts.freqPath
+		}
+		other, err := loadDictionary(dictPath, freqPath)
+		if err != nil {
+			return fmt.Errorf("--merge: %w", err)
+		}
+		domains[defaultDomain].Merge(other, opts.mergeWeight)
+		logger.Info("Merged dictionary", "path", dictPath, "weight", opts.mergeWeight, "words", domains[defaultDomain].Size())
+	}
+	var stepCosts *khmer.StepCosts
+	if opts.costsPath != "" {
+		costs, err := readCostConfig(opts.costsPath)
+		if err != nil {
+			return fmt.Errorf("--costs: %w", err)
+		}
+		for _, d := range domains {
+			costs.apply(d)
+		}
+		stepCosts = &costs.StepCosts
+	}
+	if _, ok := domains[opts.useDomain]; !ok {
+		return fmt.Errorf("--use %q: no such domain", opts.useDomain)
+	}
+	opts.inputDomains = make([]string, len(opts.inputPaths))
+	for i, input := range opts.inputPaths {
+		if opts.debug {
+			// The argument is the text to trace, not a path
+			opts.inputDomains[i] = opts.useDomain
+			continue
+		}
+		opts.inputDomains[i], opts.inputPaths[i] = domainInput(input, domains, opts.useDomain)
+	}
+	opts.inputPath = opts.inputPaths[0]
+	dictionary := domains[opts.inputDomains[0]]
 
 	loadTime := time.Since(startLoad).Seconds()
 	fmt.Printf("Model loaded in %.2fs\n", loadTime)
+	if opts.memReport {
+		for _, d := range specs {
+			if len(specs) > 1 {
+				fmt.Printf("Domain %s:\n", d.name)
+			}
+			printMemReport(domains[d.name])
+		}
+	}
 
-	fmt.Printf("Reading source: %s\n", inputPath)
+	pipeline, err := buildPipeline(opts.disablePasses)
+	if err != nil {
+		return err
+	}
 
-	// Read input file
-	inputFile, err := os.Open(inputPath)
+	recognizers, err := parseRecognizers(opts.patterns)
 	if err != nil {
-		return fmt.Errorf("input file not found: %w", err)
+		return err
 	}
-	defer inputFile.Close()
 
-	var lines []string
-	scanner := bufio.NewScanner(inputFile)
-	// Increase buffer size for long lines
-	const maxCapacity = 1024 * 1024 // 1MB
-	buf := make([]byte, maxCapacity)
-	scanner.Buffer(buf, maxCapacity)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" {
-			lines = append(lines, line)
+	gazetteers, err := loadGazetteers(opts.gazetteers, opts.gazetteerCost)
+	if err != nil {
+		return err
+	}
+
+	if opts.subwords {
+		splitter := &khmer.SubwordSplitter{}
+		if opts.bpeMerges != "" {
+			if splitter.BPE, err = khmer.LoadBPEMerges(opts.bpeMerges); err != nil {
+				return err
+			}
 		}
-		if limit > 0 && len(lines) >= limit {
-			break
+		pipeline = pipeline.Append(splitter)
+	}
+
+	if opts.dropStopwords {
+		stopwords := khmer.NewStopwordFilter(khmer.DefaultStopwords)
+		if opts.stopwordsPath != "" {
+			if stopwords, err = khmer.LoadStopwords(opts.stopwordsPath); err != nil {
+				return err
+			}
 		}
+		pipeline = pipeline.Append(stopwords)
 	}
-	if err := scanner.Err(); err != nil {
+
+	filters, err := khmer.TokenFilters(opts.filters...)
+	if err != nil {
 		return err
 	}
+	pipeline = pipeline.Append(filters...)
 
-	numLines := len(lines)
-	fmt.Printf("Processing %d lines...\n", numLines)
+	whitespace, err := khmer.WhitespaceByName(opts.whitespace)
+	if err != nil {
+		return err
+	}
+	if whitespace != khmer.WhitespaceKeep {
+		pipeline = pipeline.Append(whitespace)
+	}
+
+	var scheme *khmer.RomanizationScheme
+	if opts.romanize != "" {
+		if scheme, err = khmer.RomanizationSchemeByName(opts.romanize); err != nil {
+			return err
+		}
+	}
+
+	tieBreak, err := khmer.TieBreakByName(opts.tieBreak)
+	if err != nil {
+		return err
+	}
+
+	mixed, err := khmer.MixedScriptByName(opts.mixedScript)
+	if err != nil {
+		return err
+	}
+
+	legacy, err := khmer.LegacyCharsByName(opts.legacyChars)
+	if err != nil {
+		return err
+	}
+
+	var affixes *khmer.Affixes
+	if opts.affixes {
+		affixes = khmer.DefaultAffixes()
+		affixes.Penalty = opts.affixPenalty
+	}
+
+	format, err := newOutputFormat(opts)
+	if err != nil {
+		return err
+	}
+
+	proc := &processor{
+		dictionary:  dictionary,
+		domains:     domains,
+		pipeline:    pipeline,
+		recognizers: recognizers,
+		gazetteers:  gazetteers,
+		scheme:      scheme,
+		fuzzy:       opts.fuzzyPenalty,
+		affixes:     affixes,
+		tieBreak:    tieBreak,
+		mixed:       mixed,
+		legacy:      legacy,
+		lossless:    opts.lossless,
+		timeout:     opts.lineTimeout,
+		chunkRunes:  opts.chunkRunes,
+		lineWorkers: opts.lineWorkers,
+		costs:       stepCosts,
+		beam:        opts.beam,
+		rescoreEps:  opts.rescoreEps,
+		types:       opts.types,
+		compounds:   opts.compounds,
+		values:      opts.numberValues,
+		currency:    opts.groupCurrency,
+		fastPath:    opts.fastNonKhmer,
+		format:      format,
+		timing:      opts.timing,
+		textField:   ndjsonTextField(opts),
+
+		deterministic: opts.deterministic,
+	}
+	if opts.oovReportPath != "" {
+		proc.oov = proc.newOOVReport()
+	}
+	if opts.errorLogPath != "" {
+		if proc.errorLog, err = createErrorLog(opts.errorLogPath); err != nil {
+			return err
+		}
+		defer proc.errorLog.file.Close()
+	}
+	if opts.rescorer != "" {
+		rescorer, err := startRescorer(opts.rescorer)
+		if err != nil {
+			return err
+		}
+		defer rescorer.close()
+		proc.rescorer = rescorer
+	}
+	if opts.cacheSize > 0 {
+		proc.cache = khmer.NewSegmentCache(opts.cacheSize)
+	}
+	proc.input = newLineFilter(opts)
+	proc.tokenCosts = format.name == "html"
+	if opts.debug {
+		printDPTrace(proc.newSegmenter(), opts.inputPath)
+		return nil
+	}
+	if opts.metadata {
+		if err := writeMetadata(opts, specs, domains); err != nil {
+			return fmt.Errorf("--metadata: %w", err)
+		}
+	}
 
 	// Determine number of workers
-	numWorkers := threads
+	numWorkers := opts.threads
 	if numWorkers <= 0 {
 		numWorkers = runtime.NumCPU()
 	}
-	fmt.Printf("Using %d worker goroutines\n", numWorkers)
+
+	if len(opts.inputPaths) > 1 {
+		return runMulti(opts, proc, numWorkers)
+	}
+	if opts.watch {
+		return watchInput(opts, proc, numWorkers)
+	}
+	if err := segmentInput(opts, proc, numWorkers); err != nil {
+		return err
+	}
+	if opts.verifyPath != "" {
+		return verifyOutput(opts.outputPath, opts.verifyPath)
+	}
+	return nil
+}
+
+// segmentInput runs one segmentation pass over opts.inputPath with an already
+// loaded dictionary
+func segmentInput(opts options, proc *processor, numWorkers int) error {
+	proc.resetReports()
+	var err error
+	if opts.maxMemory > 0 {
+		err = runBounded(opts, proc, numWorkers)
+	} else {
+		err = runInMemory(opts, proc, numWorkers)
+	}
+	if err != nil {
+		return err
+	}
+	return proc.writeReports(opts)
+}
+
+// runInMemory reads the whole input, segments it on the worker pool and
+// writes the records in input order (or as they finish with --unordered or
+// --writers)
+func runInMemory(opts options, proc *processor, numWorkers int) error {
+
+	logger.Info("Reading source", "path", opts.inputPath)
+
+	lines, ids, err := readLines(opts.inputPath, opts.skip, opts.limit, opts.maxLine(), proc.input)
+	if err != nil {
+		return err
+	}
+	if opts.shuffle {
+		shuffleLines(lines, ids, opts.seed)
+	}
+
+	numLines := len(lines)
+	logger.Info("Processing lines", "lines", numLines)
+	logger.Info("Starting workers", "workers", numWorkers)
 
 	startProcess := time.Now()
 
-	// Pre-allocate results array
-	results := make([]string, numLines)
+	// Split output: workers write straight to their own file
+	var pool *writerPool
+	if opts.writers > 1 {
+		if pool, err = createWriterPool(opts.outputPath, proc.format.header, opts.writers); err != nil {
+			return err
+		}
+	}
+
+	// Pre-allocate results array (ordered mode only)
+	var results []string
+	if !opts.unordered && pool == nil {
+		results = make([]string, numLines)
+	}
+	var lineTimes []int64
+	if opts.timing {
+		lineTimes = make([]int64, numLines)
+	}
+
+	// Unordered mode: workers hand finished records to a single writer goroutine
+	// (each record carries its id), so nothing is buffered beyond the channel.
+	// --writers output is unordered already and needs no writer.
+	var unorderedOut chan string
+	var writerDone chan error
+	if opts.unordered && pool == nil {
+		unorderedOut = make(chan string, numWorkers*64)
+		writerDone = make(chan error, 1)
+		go func() {
+			writerDone <- writeLines(opts.outputPath, proc.format.header, opts.shardSize, unorderedOut)
+		}()
+	}
 
 	// Create worker pool
 	var wg sync.WaitGroup
@@ -196,23 +1306,26 @@ func run(dictPath, freqPath, inputPath, outputPath string, limit, threads int) e
 	// Start workers - each worker gets its own segmenter (with pre-allocated buffers)
 	for w := 0; w < numWorkers; w++ {
 		wg.Add(1)
-		go func() {
+		go func(slot int) {
 			defer wg.Done()
-			// Each goroutine has its own segmenter instance (thread-local buffers)
-			segmenter := khmer.NewKhmerSegmenter(dictionary)
-			// 1BRC optimization: Reuse string builder from pool
-			sb := builderPool.Get().(*strings.Builder)
-			defer builderPool.Put(sb)
+			// Each goroutine has its own worker (thread-local segmenter buffers)
+			wk := proc.newWorker()
+			defer wk.close()
 
 			for i := range jobs {
-				line := lines[i]
-				segments := segmenter.Segment(line)
-
-				// 1BRC optimization: Custom JSON builder (no reflection, minimal allocation)
-				buildJSON(sb, i, line, segments)
-				results[i] = sb.String()
+				out, timeUs := wk.process(ids[i], lines[i])
+				if opts.timing {
+					lineTimes[i] = timeUs
+				}
+				if pool != nil {
+					pool.write(slot, out)
+				} else if opts.unordered {
+					unorderedOut <- out
+				} else {
+					results[i] = out
+				}
 			}
-		}()
+		}(w)
 	}
 
 	// Send jobs
@@ -224,30 +1337,94 @@ func run(dictPath, freqPath, inputPath, outputPath string, limit, threads int) e
 	// Wait for all workers to complete
 	wg.Wait()
 
-	// Write results sequentially (only if output specified)
-	if outputPath != "" {
-		outputFile, err := os.Create(outputPath)
-		if err != nil {
-			return fmt.Errorf("could not create output file: %w", err)
+	if pool != nil {
+		if err := pool.close(); err != nil {
+			return err
 		}
-		defer outputFile.Close()
-
-		// 1BRC optimization: Use larger buffer for output (256KB vs default 4KB)
-		writer := bufio.NewWriterSize(outputFile, 256*1024)
-		for _, jsonStr := range results {
-			writer.WriteString(jsonStr)
-			writer.WriteByte('\n')
+		pool.logSaved()
+	} else if opts.unordered {
+		close(unorderedOut)
+		if err := <-writerDone; err != nil {
+			return err
+		}
+	} else if opts.outputPath != "" {
+		// Write results sequentially (only if output specified)
+		if err := writeResults(opts.outputPath, proc.format.header, opts.shardSize, results); err != nil {
+			return err
 		}
-		writer.Flush()
 	}
 
 	duration := time.Since(startProcess).Seconds()
-
-	if outputPath != "" {
-		fmt.Printf("Done. Saved to %s\n", outputPath)
-	}
 	fmt.Printf("Time taken: %.2fs\n", duration)
 	fmt.Printf("Speed: %.2f lines/sec\n", float64(numLines)/duration)
 
+	if opts.timing {
+		printTimingSummary(lineTimes, lines, ids)
+	}
+
 	return nil
 }
+
+// readLines reads the non-empty, trimmed lines of path, skipping the first
+// skip of them, then those filter does not keep (nil keeps all), and stopping
+// after limit lines (0 = unlimited). ids[k] is the index of lines[k] among the
+// non-empty lines of the input, its record id whatever is skipped, filtered
+// or shuffled. path may be a remote URL (see openInput).
+func readLines(path string, skip, limit, maxLine int, filter *lineFilter) (lines []string, ids []int, err error) {
+	inputFile, err := openInput(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer inputFile.Close()
+
+	scanner := newLineScanner(inputFile, maxLine)
+	for id := 0; scanner.Scan(); {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		id++
+		if id <= skip || !filter.keep(line) {
+			continue
+		}
+		lines = append(lines, line)
+		ids = append(ids, id-1)
+		if limit > 0 && len(lines) >= limit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, lineError(err, maxLine)
+	}
+	return lines, ids, nil
+}
+
+// writeLines writes header and then each received record to path (or its
+// shards) as it arrives. With no path the lines are drained and discarded
+// (benchmark mode).
+func writeLines(path, header string, shardSize int, lines <-chan string) error {
+	if path == "" {
+		for range lines {
+		}
+		return nil
+	}
+
+	output, err := createOutput(path, header, shardSize)
+	if err != nil {
+		for range lines {
+		}
+		return err
+	}
+	for out := range lines {
+		if err == nil {
+			err = output.write(out)
+		}
+	}
+	if cerr := output.close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		output.logSaved()
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// schemaVersion is the version of the output records (and of this metadata).
+// It changes when a field is renamed, removed or changes meaning; new
+// optional fields do not change it.
+const schemaVersion = 1
+
+// runMetadata is the --metadata sidecar describing how an output was made
+type runMetadata struct {
+	SchemaVersion int    `json:"schema_version"`
+	Tool          string `json:"tool"`
+	Version       string `json:"version"`
+	Revision      string `json:"revision,omitempty"`
+	GoVersion     string `json:"go_version"`
+	// Created is left out with --deterministic, so the sidecar is as
+	// reproducible as the output
+	Created      string               `json:"created,omitempty"`
+	Format       string               `json:"format"`
+	Inputs       []string             `json:"inputs"`
+	Dictionaries []dictionaryMetadata `json:"dictionaries"`
+	// Options are the flags set on the command line, by name
+	Options map[string]string `json:"options"`
+}
+
+// dictionaryMetadata identifies the dictionary of a domain by the checksums
+// of its files
+type dictionaryMetadata struct {
+	Domain     string `json:"domain"`
+	Dict       string `json:"dict"`
+	DictSHA256 string `json:"dict_sha256"`
+	Freq       string `json:"freq,omitempty"`
+	FreqSHA256 string `json:"freq_sha256,omitempty"`
+	Words      int    `json:"words"`
+}
+
+// metadataPath names the sidecar of an output: out.meta.json next to
+// out.json (or out.json.gz), or metadata.json in the output directory of a
+// multi-file run
+func metadataPath(outputPath string, multi bool) string {
+	if multi {
+		return filepath.Join(outputPath, "metadata.json")
+	}
+	base := strings.TrimSuffix(outputPath, compressionExt(outputPath))
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".meta.json"
+}
+
+// setFlags returns the flags set on the command line and their values
+func setFlags(fs *flag.FlagSet) map[string]string {
+	set := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+	return set
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's contents
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeMetadata writes the --metadata sidecar of a run
+func writeMetadata(opts options, specs []domainSpec, domains map[string]*khmer.Dictionary) error {
+	meta := runMetadata{
+		SchemaVersion: schemaVersion,
+		Tool:          "khmer-go",
+		Version:       "(devel)",
+		GoVersion:     runtime.Version(),
+		Format:        strings.ToLower(opts.format),
+		Inputs:        opts.inputPaths,
+		Options:       opts.setFlags,
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		meta.Version = info.Main.Version
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				meta.Revision = s.Value
+			}
+		}
+	}
+	if !opts.deterministic {
+		meta.Created = time.Now().UTC().Format(time.RFC3339)
+	}
+	for _, spec := range specs {
+		d := dictionaryMetadata{Domain: spec.name, Dict: spec.dictPath, Words: domains[spec.name].Size()}
+		var err error
+		if d.DictSHA256, err = fileSHA256(spec.dictPath); err != nil {
+			return err
+		}
+		// A compiled dictionary has no frequency file, and a missing one is
+		// allowed (every word then costs DefaultCost)
+		if !khmer.IsCompiledDictionary(spec.dictPath) {
+			if sum, err := fileSHA256(spec.freqPath); err == nil {
+				d.Freq, d.FreqSHA256 = spec.freqPath, sum
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		meta.Dictionaries = append(meta.Dictionaries, d)
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := metadataPath(opts.outputPath, len(opts.inputPaths) > 1)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	logger.Info("Saved metadata", "path", path)
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// latencyBuckets are the Prometheus histogram bucket bounds in seconds
+var latencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// histogram is a Prometheus-style cumulative histogram
+type histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// metrics holds the counters exposed on /metrics in Prometheus text format
+type metrics struct {
+	mu       sync.Mutex
+	requests map[string]uint64 // keyed by "endpoint\x00code"
+
+	latency   *histogram
+	lines     atomic.Uint64
+	tokens    atomic.Uint64
+	oovTokens atomic.Uint64
+
+	reloads      atomic.Uint64
+	reloadErrors atomic.Uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests: make(map[string]uint64),
+		latency:  newHistogram(latencyBuckets),
+	}
+}
+
+// observeRequest records one finished HTTP request
+func (m *metrics) observeRequest(endpoint string, code int, seconds float64) {
+	m.mu.Lock()
+	m.requests[fmt.Sprintf("%s\x00%d", endpoint, code)]++
+	m.mu.Unlock()
+	m.latency.observe(seconds)
+}
+
+// observeSegments records the token and OOV counts of one segmented line
+func (m *metrics) observeSegments(tokens, oov int) {
+	m.lines.Add(1)
+	m.tokens.Add(uint64(tokens))
+	m.oovTokens.Add(uint64(oov))
+}
+
+// observeReload records one dictionary reload
+func (m *metrics) observeReload(ok bool) {
+	if ok {
+		m.reloads.Add(1)
+	} else {
+		m.reloadErrors.Add(1)
+	}
+}
+
+// writeTo renders all metrics in the Prometheus text exposition format
+func (m *metrics) writeTo(w io.Writer) {
+	fmt.Fprintln(w, "# HELP khmer_requests_total HTTP requests by endpoint and status code.")
+	fmt.Fprintln(w, "# TYPE khmer_requests_total counter")
+	m.mu.Lock()
+	keys := make([]string, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		endpoint, code, _ := strings.Cut(k, "\x00")
+		fmt.Fprintf(w, "khmer_requests_total{endpoint=%q,code=%q} %d\n", endpoint, code, m.requests[k])
+	}
+	m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP khmer_request_duration_seconds HTTP request latency.")
+	fmt.Fprintln(w, "# TYPE khmer_request_duration_seconds histogram")
+	h := m.latency
+	h.mu.Lock()
+	for i, b := range h.bounds {
+		fmt.Fprintf(w, "khmer_request_duration_seconds_bucket{le=\"%g\"} %d\n", b, h.counts[i])
+	}
+	fmt.Fprintf(w, "khmer_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", h.count)
+	fmt.Fprintf(w, "khmer_request_duration_seconds_sum %g\n", h.sum)
+	fmt.Fprintf(w, "khmer_request_duration_seconds_count %d\n", h.count)
+	h.mu.Unlock()
+
+	lines, tokens, oov := m.lines.Load(), m.tokens.Load(), m.oovTokens.Load()
+	fmt.Fprintln(w, "# HELP khmer_lines_total Lines segmented.")
+	fmt.Fprintln(w, "# TYPE khmer_lines_total counter")
+	fmt.Fprintf(w, "khmer_lines_total %d\n", lines)
+	fmt.Fprintln(w, "# HELP khmer_tokens_total Word tokens produced (separators excluded).")
+	fmt.Fprintln(w, "# TYPE khmer_tokens_total counter")
+	fmt.Fprintf(w, "khmer_tokens_total %d\n", tokens)
+	fmt.Fprintln(w, "# HELP khmer_oov_tokens_total Word tokens not found in the dictionary.")
+	fmt.Fprintln(w, "# TYPE khmer_oov_tokens_total counter")
+	fmt.Fprintf(w, "khmer_oov_tokens_total %d\n", oov)
+	fmt.Fprintln(w, "# HELP khmer_oov_rate Fraction of word tokens not found in the dictionary since start.")
+	fmt.Fprintln(w, "# TYPE khmer_oov_rate gauge")
+	rate := 0.0
+	if tokens > 0 {
+		rate = float64(oov) / float64(tokens)
+	}
+	fmt.Fprintf(w, "khmer_oov_rate %g\n", rate)
+	fmt.Fprintln(w, "# HELP khmer_dictionary_reloads_total Dictionary reloads by result.")
+	fmt.Fprintln(w, "# TYPE khmer_dictionary_reloads_total counter")
+	fmt.Fprintf(w, "khmer_dictionary_reloads_total{result=\"ok\"} %d\n", m.reloads.Load())
+	fmt.Fprintf(w, "khmer_dictionary_reloads_total{result=\"error\"} %d\n", m.reloadErrors.Load())
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// inputFile is one input of a multi-file run and its per-line results
+type inputFile struct {
+	path       string
+	dictionary *khmer.Dictionary
+	outputPath string
+	lines      []string
+	ids        []int
+	results    []string
+	times      []int64
+	// remaining counts lines not yet segmented; the worker that finishes the
+	// last one writes the file
+	remaining atomic.Int64
+}
+
+// multiJob is one line of one input file
+type multiJob struct {
+	file *inputFile
+	line int
+}
+
+// outputPathFor names the output of inputPath inside outputDir: the input's
+// base name with its extension replaced by ext
+func outputPathFor(outputDir, inputPath, ext string) string {
+	base := filepath.Base(inputPath)
+	return filepath.Join(outputDir, strings.TrimSuffix(base, filepath.Ext(base))+ext)
+}
+
+// runMulti segments several input files, each with its domain's dictionary
+// (see --domain), loaded once. Lines of all files share a single worker pool,
+// so a mix of large and small files keeps every worker busy; each file is
+// written to <output>/<name><ext> (e.g. name.json) as soon as its last line is
+// done. --skip and --limit apply to each file.
+func runMulti(opts options, proc *processor, numWorkers int) error {
+	proc.resetReports()
+	files := make([]*inputFile, 0, len(opts.inputPaths))
+	outputs := make(map[string]string, len(opts.inputPaths))
+	for i, path := range opts.inputPaths {
+		f := &inputFile{path: path, dictionary: proc.domains[opts.inputDomains[i]]}
+		if opts.outputPath != "" {
+			f.outputPath = outputPathFor(opts.outputPath, path, proc.format.ext)
+			if prev, ok := outputs[f.outputPath]; ok {
+				return fmt.Errorf("inputs %s and %s would both be written to %s", prev, path, f.outputPath)
+			}
+			outputs[f.outputPath] = path
+		}
+		files = append(files, f)
+	}
+	if opts.outputPath != "" {
+		if err := os.MkdirAll(opts.outputPath, 0o755); err != nil {
+			return fmt.Errorf("could not create output directory: %w", err)
+		}
+	}
+
+	numLines := 0
+	for _, f := range files {
+		logger.Info("Reading source", "path", f.path)
+		lines, ids, err := readLines(f.path, opts.skip, opts.limit, opts.maxLine(), proc.input)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+		if opts.shuffle {
+			shuffleLines(lines, ids, opts.seed)
+		}
+		f.lines, f.ids = lines, ids
+		f.results = make([]string, len(lines))
+		if opts.timing {
+			f.times = make([]int64, len(lines))
+		}
+		f.remaining.Store(int64(len(lines)))
+		numLines += len(lines)
+	}
+
+	logger.Info("Processing lines", "files", len(files), "lines", numLines)
+	logger.Info("Starting workers", "workers", numWorkers)
+
+	startProcess := time.Now()
+
+	var writeErrs []error
+	var errMu sync.Mutex
+	finish := func(f *inputFile) {
+		if f.outputPath == "" {
+			return
+		}
+		if err := writeResults(f.outputPath, proc.format.header, opts.shardSize, f.results); err != nil {
+			errMu.Lock()
+			writeErrs = append(writeErrs, fmt.Errorf("%s: %w", f.path, err))
+			errMu.Unlock()
+		}
+	}
+
+	var wg sync.WaitGroup
+	jobs := make(chan multiJob, numWorkers*64)
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wk := proc.newWorker()
+			defer wk.close()
+
+			for j := range jobs {
+				f := j.file
+				wk.segmenter.Dictionary = f.dictionary
+				out, timeUs := wk.process(f.ids[j.line], f.lines[j.line])
+				f.results[j.line] = out
+				if opts.timing {
+					f.times[j.line] = timeUs
+				}
+				if f.remaining.Add(-1) == 0 {
+					finish(f)
+				}
+			}
+		}()
+	}
+
+	for _, f := range files {
+		if len(f.lines) == 0 {
+			finish(f)
+			continue
+		}
+		for i := range f.lines {
+			jobs <- multiJob{file: f, line: i}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(writeErrs) > 0 {
+		return writeErrs[0]
+	}
+
+	duration := time.Since(startProcess).Seconds()
+	fmt.Printf("Time taken: %.2fs\n", duration)
+	fmt.Printf("Speed: %.2f lines/sec\n", float64(numLines)/duration)
+
+	if opts.timing {
+		for _, f := range files {
+			fmt.Printf("\n%s:", f.path)
+			printTimingSummary(f.times, f.lines, f.ids)
+		}
+	}
+	return proc.writeReports(opts)
+}
+
+// writeResults writes header and the encoded records to path (or its
+// shards) in order
+func writeResults(path, header string, shardSize int, results []string) error {
+	output, err := createOutput(path, header, shardSize)
+	if err != nil {
+		return err
+	}
+	for _, out := range results {
+		if err := output.write(out); err != nil {
+			output.close()
+			return err
+		}
+	}
+	if err := output.close(); err != nil {
+		return err
+	}
+	output.logSaved()
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// recordField is a field of an NDJSON input record, kept as raw JSON so it is
+// copied to the output unchanged
+type recordField struct {
+	name  string
+	value json.RawMessage
+}
+
+// generatedFields are the output fields written by the segmenter; input
+// fields with these names are not copied, so no key appears twice
+var generatedFields = map[string]bool{
+	"segments": true, "romanized": true, "types": true, "compounds": true,
+	"values": true, "currencies": true, "time_us": true, "error": true,
+}
+
+// ndjsonTextField returns the field to segment with --input-format ndjson,
+// or "" for plain text input
+func ndjsonTextField(opts options) string {
+	if opts.inputFormat != "ndjson" {
+		return ""
+	}
+	return opts.textField
+}
+
+// parseNDJSON splits an NDJSON input line into its fields, in input order,
+// and returns the string in textField
+func parseNDJSON(line, textField string) ([]recordField, string, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, "", fmt.Errorf("invalid JSON record: not an object")
+	}
+	var fields []recordField
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid JSON record: %w", err)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, "", fmt.Errorf("invalid JSON record: %w", err)
+		}
+		fields = append(fields, recordField{name: tok.(string), value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, "", fmt.Errorf("invalid JSON record: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, "", fmt.Errorf("invalid JSON record: data after the object")
+	}
+
+	for _, f := range fields {
+		if f.name != textField {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(f.value, &text); err != nil {
+			return nil, "", fmt.Errorf("field %q is not a string", textField)
+		}
+		return fields, text, nil
+	}
+	return nil, "", fmt.Errorf("field %q is missing", textField)
+}
+
+// writeInputFields writes the fields of an NDJSON input record, preceded by
+// the line id unless the record has its own "id"
+func writeInputFields(sb *strings.Builder, id int, fields []recordField) {
+	hasID := false
+	for _, f := range fields {
+		hasID = hasID || f.name == "id"
+	}
+	first := true
+	if !hasID {
+		sb.WriteString(`"id":`)
+		writeInt(sb, id)
+		first = false
+	}
+	for _, f := range fields {
+		if generatedFields[f.name] {
+			continue
+		}
+		if !first {
+			sb.WriteByte(',')
+		}
+		first = false
+		sb.WriteByte('"')
+		writeEscapedJSON(sb, f.name)
+		sb.WriteString(`":`)
+		sb.Write(f.value)
+	}
+}
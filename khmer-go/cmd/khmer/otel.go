@@ -0,0 +1,70 @@
+//go:build otel
+
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+	"github.com/khmer-segmenter/pkg/khmerotel"
+)
+
+func init() { newTracing = newOtelTracing }
+
+// otelTracing exports spans over OTLP/HTTP, configured with the standard
+// OTEL_EXPORTER_OTLP_* environment variables
+type otelTracing struct {
+	provider   *sdktrace.TracerProvider
+	t          trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func newOtelTracing() (tracing, error) {
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "khmer"))),
+	)
+	otel.SetTracerProvider(provider)
+	return &otelTracing{
+		provider:   provider,
+		t:          provider.Tracer("github.com/khmer-segmenter"),
+		propagator: propagation.TraceContext{},
+	}, nil
+}
+
+func (o *otelTracing) wrap(endpoint string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := o.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := o.t.Start(ctx, endpoint, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.method", r.Method)))
+		defer span.End()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.status_code", rec.code))
+		if rec.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.code))
+		}
+	})
+}
+
+func (o *otelTracing) tracer(ctx context.Context) khmer.Tracer {
+	return khmerotel.New(ctx, o.t)
+}
+
+func (o *otelTracing) shutdown(ctx context.Context) error {
+	return o.provider.Shutdown(ctx)
+}
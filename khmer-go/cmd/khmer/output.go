@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// outputWriter writes the format header and encoded records to the output
+// file. With a shard size it writes numbered shards of at most that many
+// records instead (out-00001.jsonl, out-00002.jsonl, ...), each starting with
+// the header, so training jobs can ingest them in parallel. A path ending in
+// .gz (or, built with -tags zstd, .zst) is written compressed.
+type outputWriter struct {
+	path      string
+	header    string
+	shardSize int
+	// shards counts the files created; records counts those in the current one
+	shards  int
+	records int
+	// size is the number of bytes written to the current file, before compression
+	size int64
+	file *os.File
+	// comp compresses into file when the path has a compression extension
+	comp   io.WriteCloser
+	writer *bufio.Writer
+}
+
+// gzipExt marks an output path to compress with gzip
+const gzipExt = ".gz"
+
+// compression writes and reads one compressed format
+type compression struct {
+	newWriter func(w io.Writer) (io.WriteCloser, error)
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// compressions are the output compressions by path extension. Building with
+// -tags zstd adds .zst and .zstd (see zstd.go), keeping the default build
+// free of dependencies.
+var compressions = map[string]compression{
+	gzipExt: {
+		newWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+		newReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	},
+}
+
+// compressionExt returns the compression extension path ends in, or ""
+func compressionExt(path string) string {
+	for ext := range compressions {
+		if strings.HasSuffix(path, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// checkOutputPath rejects output paths asking for a compression this build
+// cannot write
+func checkOutputPath(path string) error {
+	if compressionExt(path) == "" && (strings.HasSuffix(path, ".zst") || strings.HasSuffix(path, ".zstd")) {
+		return fmt.Errorf("zstd output needs a build with -tags zstd; use %s", gzipExt)
+	}
+	return nil
+}
+
+// createOutput opens the output for writing. A shard size of 0 writes a
+// single file at path.
+func createOutput(path, header string, shardSize int) (*outputWriter, error) {
+	o := &outputWriter{path: path, header: header, shardSize: shardSize}
+	if shardSize <= 0 {
+		if err := o.open(path); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// appendOutput opens an unsharded output written up to size bytes by an
+// interrupted run, dropping anything after that, to continue writing records.
+// A compressed output continues in a new member after the last one marked.
+func appendOutput(path string, size int64) (*outputWriter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not open output file: %w", err)
+	}
+	if err := file.Truncate(size); err == nil {
+		_, err = file.Seek(size, 0)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("could not resume output file: %w", err)
+	}
+	o := &outputWriter{path: path, shards: 1, size: size, file: file}
+	var w io.Writer = file
+	if ext := compressionExt(path); ext != "" {
+		if o.comp, err = compressions[ext].newWriter(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("could not resume output file: %w", err)
+		}
+		w = o.comp
+	}
+	o.writer = bufio.NewWriterSize(w, 256*1024)
+	return o, nil
+}
+
+// shardPath names shard n (from 1) of path: the index goes before the
+// extension, and before the format extension of a compressed path
+// (out-00001.jsonl.gz)
+func shardPath(path string, n int) string {
+	comp := compressionExt(path)
+	base := strings.TrimSuffix(path, comp)
+	ext := filepath.Ext(base)
+	return fmt.Sprintf("%s-%05d%s%s", strings.TrimSuffix(base, ext), n, ext, comp)
+}
+
+// open creates the file at path and writes the header
+func (o *outputWriter) open(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create output file: %w", err)
+	}
+	o.file = file
+	o.shards++
+	o.records = 0
+	// 1BRC optimization: Use larger buffer for output (256KB vs default 4KB)
+	if ext := compressionExt(path); ext != "" {
+		if o.comp, err = compressions[ext].newWriter(file); err != nil {
+			file.Close()
+			return fmt.Errorf("could not create output file: %w", err)
+		}
+		o.writer = bufio.NewWriterSize(o.comp, 256*1024)
+	} else {
+		o.writer = bufio.NewWriterSize(file, 256*1024)
+	}
+	o.writer.WriteString(o.header)
+	o.size = int64(len(o.header))
+	return nil
+}
+
+// flush writes buffered records to the current file
+func (o *outputWriter) flush() error {
+	if o.writer == nil {
+		return nil
+	}
+	return o.writer.Flush()
+}
+
+// mark writes the buffered records to an unsharded output and returns how
+// many bytes of the file hold them, for appendOutput to continue from. A
+// compressed output ends its current member there and starts a new one;
+// gzip and zstd readers read the members as one stream.
+func (o *outputWriter) mark() (int64, error) {
+	if err := o.flush(); err != nil {
+		return 0, err
+	}
+	if o.comp == nil {
+		return o.size, nil
+	}
+	if err := o.comp.Close(); err != nil {
+		return 0, err
+	}
+	size, err := o.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if o.comp, err = compressions[compressionExt(o.path)].newWriter(o.file); err != nil {
+		return 0, err
+	}
+	o.writer.Reset(o.comp)
+	return size, nil
+}
+
+// closeFile flushes and closes the current file
+func (o *outputWriter) closeFile() error {
+	if o.file == nil {
+		return nil
+	}
+	err := o.writer.Flush()
+	if o.comp != nil {
+		if cerr := o.comp.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := o.file.Close(); err == nil {
+		err = cerr
+	}
+	o.file, o.comp, o.writer = nil, nil, nil
+	return err
+}
+
+// write appends one encoded record, starting a new shard when the current
+// one is full
+func (o *outputWriter) write(out string) error {
+	if out == "" {
+		return nil
+	}
+	if o.shardSize > 0 && (o.file == nil || o.records >= o.shardSize) {
+		if err := o.closeFile(); err != nil {
+			return err
+		}
+		if err := o.open(shardPath(o.path, o.shards+1)); err != nil {
+			return err
+		}
+	}
+	o.records++
+	o.size += int64(len(out))
+	_, err := o.writer.WriteString(out)
+	return err
+}
+
+// close flushes the output. A sharded output with no records still gets
+// its first shard, holding only the header.
+func (o *outputWriter) close() error {
+	if o.shardSize > 0 && o.shards == 0 {
+		if err := o.open(shardPath(o.path, 1)); err != nil {
+			return err
+		}
+	}
+	return o.closeFile()
+}
+
+// logSaved logs where the output went
+func (o *outputWriter) logSaved() {
+	if o.shardSize > 0 {
+		logger.Info("Saved output", "path", shardPath(o.path, 1), "shards", o.shards)
+		return
+	}
+	logger.Info("Saved output", "path", o.path)
+}
+
+// writerPool splits the output over n files (out-00001.jsonl, ...) with their
+// own buffered writers, which workers write to directly instead of funnelling
+// every record through one writer goroutine. Records land in whichever file
+// their worker feeds, so the order is not preserved.
+type writerPool struct {
+	path    string
+	writers []*outputWriter
+	mus     []sync.Mutex
+	// errs holds the first write error of each writer
+	errs []error
+}
+
+// createWriterPool opens the n files of a pool writing to path
+func createWriterPool(path, header string, n int) (*writerPool, error) {
+	p := &writerPool{path: path, writers: make([]*outputWriter, n), mus: make([]sync.Mutex, n), errs: make([]error, n)}
+	for i := range p.writers {
+		w, err := createOutput(shardPath(path, i+1), header, 0)
+		if err != nil {
+			p.close()
+			return nil, err
+		}
+		p.writers[i] = w
+	}
+	return p, nil
+}
+
+// write appends a record to the file of worker slot. Workers sharing a file
+// take turns; with at least as many files as workers there is no contention.
+func (p *writerPool) write(slot int, out string) {
+	i := slot % len(p.writers)
+	p.mus[i].Lock()
+	if p.errs[i] == nil {
+		p.errs[i] = p.writers[i].write(out)
+	}
+	p.mus[i].Unlock()
+}
+
+// close flushes and closes every file, returning the first error
+func (p *writerPool) close() error {
+	var err error
+	for i, w := range p.writers {
+		if w == nil {
+			continue
+		}
+		if cerr := w.close(); p.errs[i] == nil {
+			p.errs[i] = cerr
+		}
+		if err == nil {
+			err = p.errs[i]
+		}
+	}
+	return err
+}
+
+// logSaved logs where the output went
+func (p *writerPool) logSaved() {
+	logger.Info("Saved output", "path", shardPath(p.path, 1), "files", len(p.writers))
+}
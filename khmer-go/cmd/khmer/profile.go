@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	_ "net/http/pprof" // registers /debug/pprof handlers on http.DefaultServeMux
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling starts CPU profiling and/or a pprof HTTP listener as
+// requested. The returned stop function finishes the CPU profile and writes
+// the heap profile; it must be called before exit.
+func startProfiling(cpuPath, memPath, pprofAddr string) (func() error, error) {
+	if pprofAddr != "" {
+		go func() {
+			logger.Info("pprof listening", "url", "http://"+pprofAddr+"/debug/pprof/")
+			if err := http.ListenAndServe(pprofAddr, nil); err != nil {
+				logger.Error("pprof listener failed", "err", err)
+			}
+		}()
+	}
+
+	var cpuFile *os.File
+	if cpuPath != "" {
+		f, err := os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not start CPU profile: %w", err)
+		}
+		cpuFile = f
+	}
+
+	stop := func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return err
+			}
+			logger.Info("CPU profile written", "path", cpuPath)
+		}
+		if memPath != "" {
+			f, err := os.Create(memPath)
+			if err != nil {
+				return fmt.Errorf("could not create memory profile: %w", err)
+			}
+			defer f.Close()
+			runtime.GC() // up-to-date heap statistics
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				return fmt.Errorf("could not write memory profile: %w", err)
+			}
+			logger.Info("Memory profile written", "path", memPath)
+		}
+		return nil
+	}
+	return stop, nil
+}
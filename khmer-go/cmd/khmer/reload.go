@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// reload loads every domain's dictionary and frequency files again and swaps
+// the new dictionaries in. Requests in flight keep the dictionary they
+// started with, so they finish unaffected; a failed load leaves that domain's
+// current dictionary in place.
+func (s *server) reload() (map[string]int, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	words := make(map[string]int, len(s.domains))
+	var firstErr error
+	for name, d := range s.domains {
+		start := time.Now()
+		dictionary, err := loadDictionary(d.dictPath, d.freqPath)
+		if err != nil {
+			s.metrics.observeReload(false)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("domain %s: %w", name, err)
+			}
+			continue
+		}
+		d.dict.Store(dictionary)
+		// The domain is complete even when a --lazy load is still running
+		d.complete.Store(true)
+		s.metrics.observeReload(true)
+		words[name] = dictionary.Size()
+		logger.Info("Reloaded dictionary", "domain", name, "words", dictionary.Size(), "elapsed", time.Since(start).Round(time.Millisecond))
+	}
+	return words, firstErr
+}
+
+// reloadOnSignal reloads the dictionaries on each SIGHUP
+func (s *server) reloadOnSignal() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if _, err := s.reload(); err != nil {
+				logger.Error("Reloading dictionary", "error", err)
+			}
+		}
+	}()
+}
+
+// handleReload serves POST /admin/reload, answering with the word count of
+// each domain
+func (s *server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	words, err := s.reload()
+	if err != nil {
+		logger.Error("Reloading dictionary", "error", err)
+		httpError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]map[string]int{"words": words})
+}
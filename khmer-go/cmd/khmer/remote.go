@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// isRemoteInput reports whether an input path is an http://, https:// or
+// s3:// URL rather than a local file
+func isRemoteInput(path string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// s3URL maps s3://bucket/key to the object's HTTPS URL: path-style under
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL when set (MinIO, R2, ...), else the
+// bucket's virtual host in AWS_REGION
+func s3URL(uri string) (string, error) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(uri, "s3://"), "/")
+	if bucket == "" || key == "" {
+		return "", fmt.Errorf("invalid S3 URL %q (want s3://bucket/key)", uri)
+	}
+	key = s3Escape(key)
+	for _, env := range []string{"AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"} {
+		if endpoint := os.Getenv(env); endpoint != "" {
+			return strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + key, nil
+		}
+	}
+	if os.Getenv("AWS_REGION") == "" && os.Getenv("AWS_DEFAULT_REGION") == "" {
+		return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+	}
+	return "https://" + bucket + ".s3." + s3Region() + ".amazonaws.com/" + key, nil
+}
+
+// s3Region is the region requests are signed for, us-east-1 by default
+func s3Region() string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region
+		}
+	}
+	return "us-east-1"
+}
+
+// s3Escape percent-encodes an object key the way SigV4 canonicalizes it:
+// everything but unreserved characters and "/"
+func s3Escape(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// emptySHA256 is the hex SHA-256 of an empty body, the payload of a GET
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signS3 signs a GET request with AWS Signature Version 4 for the s3 service,
+// over the host and every header already set on req
+func signS3(req *http.Request, accessKey, secretKey, sessionToken, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	canonical.WriteString(req.Method + "\n" + req.URL.EscapedPath() + "\n" + req.URL.RawQuery + "\n")
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+	canonical.WriteString("\n" + signed + "\n" + emptySHA256)
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical.String()))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+	hmacSHA256 := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+hex.EncodeToString(hmacSHA256(key, toSign)))
+}
+
+// openInput opens a local input file, or streams a remote one straight from
+// the server with no local copy. s3:// requests are signed when
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are set (with
+// AWS_SESSION_TOKEN for temporary credentials), and unsigned otherwise.
+func openInput(path string) (io.ReadCloser, error) {
+	if !isRemoteInput(path) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("input file not found: %w", err)
+		}
+		return file, nil
+	}
+	target := path
+	isS3 := strings.HasPrefix(path, "s3://")
+	if isS3 {
+		var err error
+		if target, err = s3URL(path); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching input: %w", err)
+	}
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	signed := isS3 && accessKey != "" && secretKey != ""
+	if signed {
+		signS3(req, accessKey, secretKey, os.Getenv("AWS_SESSION_TOKEN"), s3Region(), time.Now())
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching input: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if isS3 && !signed && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized) {
+			return nil, fmt.Errorf("fetching input %s: %s (the request was unsigned: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or pass a presigned https:// URL)", path, resp.Status)
+		}
+		return nil, fmt.Errorf("fetching input %s: %s", path, resp.Status)
+	}
+	return resp.Body, nil
+}
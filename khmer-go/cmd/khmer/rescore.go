@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// rescoreRequest is what --rescorer's command reads for each ambiguous span,
+// one JSON object per line; it answers with a JSON array of one score per
+// alternative, the highest winning
+type rescoreRequest struct {
+	Text         string               `json:"text"`
+	Start        int                  `json:"start"`
+	End          int                  `json:"end"`
+	Span         string               `json:"span"`
+	Alternatives []rescoreAlternative `json:"alternatives"`
+}
+
+type rescoreAlternative struct {
+	Segments []string `json:"segments"`
+	Cost     float32  `json:"cost"`
+}
+
+// processRescorer is a khmer.Rescorer backed by a long-running command
+// (an ONNX or CRF scorer, say), shared by all workers
+type processRescorer struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	w      *bufio.Writer
+	r      *bufio.Reader
+	failed bool
+}
+
+// startRescorer starts the command line of --rescorer
+func startRescorer(command string) (*processRescorer, error) {
+	argv := strings.Fields(command)
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("--rescorer: empty command")
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("--rescorer: %w", err)
+	}
+	return &processRescorer{cmd: cmd, stdin: stdin, w: bufio.NewWriter(stdin), r: bufio.NewReader(stdout)}, nil
+}
+
+// Rescore sends the span to the command and reads back its scores. After a
+// failure, which is logged once, every span keeps its Viterbi choice.
+func (p *processRescorer) Rescore(text string, amb khmer.Ambiguity) []float64 {
+	req := rescoreRequest{Text: text, Start: amb.Start, End: amb.End, Span: amb.Text}
+	for _, alt := range amb.Alternatives {
+		req.Alternatives = append(req.Alternatives, rescoreAlternative{Segments: alt.Segments, Cost: alt.Cost})
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failed {
+		return nil
+	}
+	scores, err := p.exchange(data)
+	if err == nil && len(scores) != len(amb.Alternatives) {
+		err = fmt.Errorf("got %d scores for %d alternatives", len(scores), len(amb.Alternatives))
+	}
+	if err != nil {
+		p.failed = true
+		logger.Error("Rescorer failed; keeping Viterbi segmentations", "error", err)
+		return nil
+	}
+	return scores
+}
+
+// exchange writes one request line and reads one response line
+func (p *processRescorer) exchange(req []byte) ([]float64, error) {
+	p.w.Write(req)
+	p.w.WriteByte('\n')
+	if err := p.w.Flush(); err != nil {
+		return nil, err
+	}
+	line, err := p.r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var scores []float64
+	if err := json.Unmarshal(line, &scores); err != nil {
+		return nil, fmt.Errorf("invalid response %q: %w", strings.TrimSpace(string(line)), err)
+	}
+	return scores, nil
+}
+
+// close ends the command's input and waits for it to exit
+func (p *processRescorer) close() error {
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// maxRequestBody caps the size of a segmentation request
+const maxRequestBody = 1 << 20 // 1MB
+
+// server serves segmentation over HTTP. Segmenters keep per-instance DP
+// buffers and are not safe for concurrent use, so they are pooled.
+type server struct {
+	// domains is fixed at start; each domain's dictionary is swapped in place
+	domains     map[string]*serverDomain
+	pool        sync.Pool
+	metrics     *metrics
+	enablePprof bool
+	enableAdmin bool
+	maxBatch    int
+	// limits applies to the segmentation endpoints; nil is unlimited
+	limits *limiter
+	// tracing gives requests and segmentation stages spans (--trace)
+	tracing tracing
+	// lineTimeout bounds the segmentation of one line (--line-timeout);
+	// longer lines than chunkRunes are segmented in chunks (--chunk-runes)
+	lineTimeout time.Duration
+	chunkRunes  int
+
+	// reloadMu keeps concurrent reloads from racing to store an older dictionary
+	reloadMu sync.Mutex
+}
+
+func newServer(domains []domainSpec, pipeline khmer.Pipeline, recognizers []khmer.Recognizer) *server {
+	s := &server{domains: make(map[string]*serverDomain, len(domains)), metrics: newMetrics(), maxBatch: defaultMaxBatch, tracing: noTracing{}}
+	for _, d := range domains {
+		s.domains[d.name] = &serverDomain{domainSpec: d}
+	}
+	s.pool.New = func() interface{} {
+		seg := khmer.NewKhmerSegmenter(nil)
+		seg.PostProcessors = pipeline
+		seg.Recognizers = recognizers
+		seg.Timeout = s.lineTimeout
+		seg.ChunkRunes = s.chunkRunes
+		return seg
+	}
+	return s
+}
+
+// segment runs one line through a pooled segmenter with dict and records
+// token metrics. Its stages are traced under the request span in ctx.
+func (s *server) segment(ctx context.Context, text string, dict *khmer.Dictionary) []string {
+	seg := s.pool.Get().(*khmer.KhmerSegmenter)
+	seg.Dictionary = dict
+	seg.Tracer = s.tracing.tracer(ctx)
+	segments := seg.Segment(text)
+	seg.Tracer = nil
+	s.pool.Put(seg)
+	tokens, oov := khmer.CountOOV(segments, dict)
+	s.metrics.observeSegments(tokens, oov)
+	return segments
+}
+
+// errDictionaryLoading is returned for a domain whose dictionary is not
+// loaded yet
+var errDictionaryLoading = errors.New("dictionary loading")
+
+// dictionary returns the current dictionary of the named domain (the default
+// one for ""). A request keeps it throughout, so a reload never changes the
+// dictionary under it.
+func (s *server) dictionary(name string) (*khmer.Dictionary, error) {
+	if name == "" {
+		name = defaultDomain
+	}
+	d, ok := s.domains[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown dictionary %s", strconv.Quote(name))
+	}
+	dict := d.dict.Load()
+	if dict == nil {
+		return nil, errDictionaryLoading
+	}
+	return dict, nil
+}
+
+// dictionaryFor is dictionary for HTTP handlers: when the domain is unknown
+// or still loading, the error response is written and nil returned.
+func (s *server) dictionaryFor(w http.ResponseWriter, name string) *khmer.Dictionary {
+	dict, err := s.dictionary(name)
+	if errors.Is(err, errDictionaryLoading) {
+		w.Header().Set("Retry-After", "1")
+		httpError(w, http.StatusServiceUnavailable, err.Error())
+	} else if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+	}
+	return dict
+}
+
+// routes builds the HTTP handler
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/segment", s.instrument("/segment", s.limits.wrap(http.HandlerFunc(s.handleSegment))))
+	mux.Handle("/segment/batch", s.instrument("/segment/batch", s.limits.wrap(http.HandlerFunc(s.handleBatch))))
+	mux.Handle("/segment/stream", s.instrument("/segment/stream", s.limits.wrap(http.HandlerFunc(s.handleStream))))
+	mux.Handle("/_analyze", s.instrument("/_analyze", s.limits.wrap(http.HandlerFunc(s.handleAnalyze))))
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/readyz", s.handleReady)
+	if s.enableAdmin {
+		mux.Handle("/admin/reload", s.instrument("/admin/reload", http.HandlerFunc(s.handleReload)))
+	}
+	if s.enablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+// statusRecorder captures the response code for metrics
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the connection, for flushing
+func (r *statusRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// instrument records request counts and latency for an endpoint and traces
+// its requests
+func (s *server) instrument(endpoint string, next http.Handler) http.Handler {
+	next = s.tracing.wrap(endpoint, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.metrics.observeRequest(endpoint, rec.code, time.Since(start).Seconds())
+	})
+}
+
+type segmentRequest struct {
+	Text  string `json:"text"`
+	Types bool   `json:"types"`
+	Dict  string `json:"dict"`
+}
+
+type segmentResponse struct {
+	Segments []string          `json:"segments"`
+	Types    []khmer.TokenType `json:"types,omitempty"`
+}
+
+// handleSegment accepts GET ?text=..., a JSON body {"text": "..."}, or a plain
+// text body. Token types are added with ?types=true or {"types": true}, and
+// a --domain dictionary is selected with ?dict=NAME or {"dict": "NAME"}.
+func (s *server) handleSegment(w http.ResponseWriter, r *http.Request) {
+	var text string
+	domain := r.URL.Query().Get("dict")
+	withTypes, _ := strconv.ParseBool(r.URL.Query().Get("types"))
+	switch r.Method {
+	case http.MethodGet:
+		text = r.URL.Query().Get("text")
+	case http.MethodPost:
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		if err != nil {
+			httpError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			var req segmentRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				httpError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+				return
+			}
+			text = req.Text
+			withTypes = withTypes || req.Types
+			if req.Dict != "" {
+				domain = req.Dict
+			}
+		} else {
+			text = string(body)
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	dict := s.dictionaryFor(w, domain)
+	if dict == nil {
+		return
+	}
+	resp := segmentResponse{Segments: s.segment(r.Context(), strings.TrimSpace(text), dict)}
+	if withTypes {
+		resp.Types = khmer.ClassifyTokens(resp.Segments, dict)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleHealth answers 200 while the process serves requests (liveness)
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady answers 200 once every dictionary is completely loaded and 503
+// before (readiness), listing the domains still loading
+func (s *server) handleReady(w http.ResponseWriter, r *http.Request) {
+	loading := []string{}
+	for name, d := range s.domains {
+		if !d.complete.Load() {
+			loading = append(loading, name)
+		}
+	}
+	if len(loading) > 0 {
+		sort.Strings(loading)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "loading", "loading": loading})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.writeTo(w)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+func httpError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+// loadInBackground loads a domain for --lazy, serving its frequent words
+// until the complete dictionary is in. A failed load is sent to fail, which
+// shuts the server down, unless a reload has completed the domain meanwhile.
+func (s *server) loadInBackground(d *serverDomain, memReport bool, fail chan<- error) {
+	loader := newDictionary().LoadInBackground(d.dictPath, d.freqPath)
+	<-loader.Ready()
+	quick := loader.Dictionary()
+	if quick != nil {
+		d.dict.CompareAndSwap(nil, quick)
+	}
+	<-loader.Done()
+	if err := loader.Err(); err != nil {
+		if d.complete.Load() {
+			logger.Error("Loading dictionary, keeping the reloaded one", "domain", d.name, "error", err)
+			return
+		}
+		fail <- fmt.Errorf("domain %s: %w", d.name, err)
+		return
+	}
+	// A reload that finished meanwhile is newer; keep it
+	d.dict.CompareAndSwap(quick, loader.Dictionary())
+	d.complete.Store(true)
+	if memReport {
+		printMemReport(loader.Dictionary())
+	}
+}
+
+// runServe implements `khmer serve`: load the dictionary once and segment over HTTP
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "Listen address")
+	listenSpec := fs.String("listen", "", "Listen on unix:///path/to.sock, tcp://host:port or host:port (overrides --addr)")
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file (text or compiled)")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	disablePasses := fs.String("disable-passes", "", "Comma-separated post-processing passes to skip")
+	withPprof := fs.Bool("pprof", false, "Expose net/http/pprof under /debug/pprof/")
+	memReport := fs.Bool("mem-report", false, "Print dictionary size and memory use after loading")
+	withAdmin := fs.Bool("admin", false, "Expose POST /admin/reload to reload the dictionaries")
+	maxBatch := fs.Int("max-batch", defaultMaxBatch, "Most texts accepted by one /segment/batch request")
+	tlsCert := fs.String("tls-cert", "", "Serve HTTPS with this PEM certificate (needs --tls-key)")
+	tlsKey := fs.String("tls-key", "", "PEM private key for --tls-cert")
+	tlsClientCA := fs.String("tls-client-ca", "", "Require client certificates signed by this PEM CA (mutual TLS)")
+	maxConcurrent := fs.Int("max-concurrent", 0, "Most segmentation requests in progress at once; more get 429 (0 = unlimited)")
+	rateLimit := fs.Float64("rate-limit", 0, "Segmentation requests per second across all clients; more get 429 (0 = unlimited)")
+	rateBurst := fs.Int("rate-burst", 0, "Requests allowed at once above --rate-limit (default: one second's worth)")
+	withTrace := fs.Bool("trace", false, "Export OpenTelemetry spans over OTLP/HTTP (needs a build with -tags otel)")
+	lineTimeout := fs.Duration("line-timeout", 0, "Cluster-split the rest of a line once its segmentation takes this long (0 = no limit)")
+	chunkRunes := fs.Int("chunk-runes", 0, "Segment lines longer than this many runes in chunks split at safe boundaries")
+	lazy := fs.Bool("lazy", false, "Listen at once and serve from the frequent words while the full dictionary loads")
+	grpcAddr := fs.String("grpc-addr", "", "Also serve the gRPC SegmentStream service on this address (needs a build with -tags grpc)")
+	fs.BoolVar(&noVariants, "no-variants", false, "Skip Coeng Ta/Da and Coeng Ro variant generation")
+	var domainFlags stringList
+	fs.Var(&domainFlags, "domain", "Extra dictionary as NAME=dict[,freq], selected with ?dict=NAME (repeatable)")
+	var patterns stringList
+	fs.Var(&patterns, "pattern", "Custom token pattern as name=regex (repeatable)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	tlsCfg, err := tlsConfig(*tlsCert, *tlsKey, *tlsClientCA)
+	if err != nil {
+		return err
+	}
+	domains, err := parseDomains(domainFlags, *dictPath, *freqPath)
+	if err != nil {
+		return err
+	}
+	pipeline, err := buildPipeline(splitList(*disablePasses))
+	if err != nil {
+		return err
+	}
+	recognizers, err := parseRecognizers(patterns)
+	if err != nil {
+		return err
+	}
+
+	s := newServer(domains, pipeline, recognizers)
+	if *withTrace {
+		if s.tracing, err = newTracing(); err != nil {
+			return err
+		}
+		loadTracer = s.tracing.tracer(context.Background())
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.tracing.shutdown(ctx); err != nil {
+				logger.Warn("Flushing traces failed", "error", err)
+			}
+		}()
+	}
+	var gs grpcServer
+	if *grpcAddr != "" {
+		if gs, err = newGRPCServer(s, tlsCfg); err != nil {
+			return err
+		}
+	}
+	s.enablePprof = *withPprof
+	s.enableAdmin = *withAdmin
+	s.maxBatch = *maxBatch
+	s.lineTimeout = *lineTimeout
+	s.chunkRunes = *chunkRunes
+	s.limits = newLimiter(*maxConcurrent, *rateLimit, *rateBurst)
+	// fail stops the server when a background load or something serving
+	// beside it fails; each sends at most once
+	fail := make(chan error, len(domains)+1)
+	for _, spec := range domains {
+		d := s.domains[spec.name]
+		if *lazy {
+			go s.loadInBackground(d, *memReport, fail)
+			continue
+		}
+		dictionary, err := loadDictionary(d.dictPath, d.freqPath)
+		if err != nil {
+			return fmt.Errorf("domain %s: %w", d.name, err)
+		}
+		d.dict.Store(dictionary)
+		d.complete.Store(true)
+		if *memReport {
+			printMemReport(dictionary)
+		}
+	}
+	s.reloadOnSignal()
+	if *listenSpec == "" {
+		*listenSpec = *addr
+	}
+	ln, url, err := listen(*listenSpec)
+	if err != nil {
+		return err
+	}
+	if tlsCfg != nil {
+		ln = tls.NewListener(ln, tlsCfg)
+		url = strings.Replace(url, "http://", "https://", 1)
+	}
+	if gs != nil {
+		gln, grpcURL, err := listen(*grpcAddr)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := gs.Serve(gln); err != nil {
+				fail <- fmt.Errorf("gRPC: %w", err)
+			}
+		}()
+		defer func() {
+			// Streams may run for as long as their clients send; cut them off
+			// after the HTTP server's grace period
+			stopped := make(chan struct{})
+			go func() {
+				gs.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(10 * time.Second):
+				gs.Stop()
+			}
+		}()
+		logger.Info("Serving gRPC", "addr", strings.TrimPrefix(grpcURL, "http://"), "service", "khmer.v1.Segmenter")
+	}
+	logger.Info("Listening", "url", url, "endpoints", "POST /segment, POST /segment/batch, POST /segment/stream, POST /_analyze, GET /metrics")
+	return serveUntilSignal(ln, s.routes(), fail)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// runStats implements `khmer stats`: summarize a corpus before committing to
+// a long segmentation run
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	inputPath := fs.String("input", "", "Input text file (required)")
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	limit := fs.Int("limit", 0, "Limit number of lines (0 = unlimited)")
+	threads := fs.Int("threads", 0, "Number of worker threads (0 = use all CPUs)")
+	noOOV := fs.Bool("no-oov", false, "Skip segmentation and the OOV report (no dictionary load)")
+	topOOV := fs.Int("top-oov", 20, "Number of most frequent OOV segments to list")
+	fs.StringVar(inputPath, "i", "", "Input text file (short)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	if *inputPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: khmer stats --input <file> [options]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	var dictionary *khmer.Dictionary
+	if !*noOOV {
+		var err error
+		if dictionary, err = loadDictionary(*dictPath, *freqPath); err != nil {
+			return err
+		}
+	}
+
+	inputFile, err := os.Open(*inputPath)
+	if err != nil {
+		return fmt.Errorf("input file not found: %w", err)
+	}
+	defer inputFile.Close()
+
+	numWorkers := *threads
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+
+	start := time.Now()
+	lines := make(chan string, numWorkers*64)
+	partials := make([]*khmer.CorpusStats, numWorkers)
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		partials[w] = khmer.NewCorpusStats()
+		wg.Add(1)
+		go func(stats *khmer.CorpusStats) {
+			defer wg.Done()
+			var segmenter *khmer.KhmerSegmenter
+			if dictionary != nil {
+				segmenter = khmer.NewKhmerSegmenter(dictionary)
+			}
+			for line := range lines {
+				stats.AddLine(line)
+				if segmenter != nil {
+					stats.AddSegments(segmenter.Segment(line), dictionary)
+				}
+			}
+		}(partials[w])
+	}
+
+	totalLines, emptyLines := 0, 0
+	scanner := bufio.NewScanner(inputFile)
+	const maxCapacity = 1024 * 1024 // 1MB
+	scanner.Buffer(make([]byte, maxCapacity), maxCapacity)
+	for scanner.Scan() {
+		totalLines++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			emptyLines++
+		} else {
+			lines <- line
+		}
+		if *limit > 0 && totalLines-emptyLines >= *limit {
+			break
+		}
+	}
+	close(lines)
+	wg.Wait()
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	stats := khmer.NewCorpusStats()
+	for _, p := range partials {
+		stats.Merge(p)
+	}
+
+	fmt.Printf("\nCorpus: %s\n", *inputPath)
+	fmt.Printf("Lines: %d (%d non-empty, %d empty)\n", totalLines, stats.Lines, emptyLines)
+	fmt.Printf("Characters: %d\n", stats.Chars)
+	if stats.Lines > 0 {
+		fmt.Printf("Average line length: %.1f chars\n", float64(stats.Chars)/float64(stats.Lines))
+	}
+
+	fmt.Println("\nCharacter classes:")
+	for _, class := range khmer.CharClasses {
+		count := stats.CharClasses[class]
+		if count == 0 {
+			continue
+		}
+		fmt.Printf("  %-18s %10d  %5.1f%%\n", class, count, percent(count, stats.Chars))
+	}
+
+	fmt.Printf("\nKhmer clusters: %d (average length %.2f)\n", stats.Clusters, stats.AverageClusterLength())
+
+	if dictionary != nil {
+		fmt.Printf("\nWord tokens: %d\n", stats.Tokens)
+		fmt.Printf("OOV tokens: %d (%.2f%%), distinct: %d\n",
+			stats.OOVTokens, stats.OOVRate()*100, len(stats.OOVTypes))
+		if *topOOV > 0 && len(stats.OOVTypes) > 0 {
+			fmt.Println("Most frequent OOV segments:")
+			for _, word := range topCounts(stats.OOVTypes, *topOOV) {
+				fmt.Printf("  %-20s %d\n", word, stats.OOVTypes[word])
+			}
+		}
+	}
+
+	fmt.Printf("\nTime taken: %.2fs\n", time.Since(start).Seconds())
+	return nil
+}
+
+// percent returns part as a percentage of total
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
+
+// topCounts returns up to n keys ordered by descending count, then by key
+func topCounts(counts map[string]int, n int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// streamLine is one line of a /segment/stream request: a JSON object with
+// an optional id, or plain text
+type streamLine struct {
+	ID   json.RawMessage `json:"id"`
+	Text string          `json:"text"`
+}
+
+type streamResult struct {
+	ID       json.RawMessage   `json:"id"`
+	Segments []string          `json:"segments,omitempty"`
+	Types    []khmer.TokenType `json:"types,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// handleStream serves POST /segment/stream: the client streams lines and
+// reads results as they finish, over one full-duplex request. Lines are
+// segmented in parallel, so results can come back out of order; each keeps
+// the line's "id", or its 0-based line number when it has none.
+func (s *server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	dict := s.dictionaryFor(w, r.URL.Query().Get("dict"))
+	if dict == nil {
+		return
+	}
+	withTypes, _ := strconv.ParseBool(r.URL.Query().Get("types"))
+
+	// HTTP/1.1 responses otherwise close the request body once they start;
+	// HTTP/2 is full duplex already, so an error here is not fatal
+	rc := http.NewResponseController(w)
+	rc.EnableFullDuplex()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	results := make(chan streamResult, 64)
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for res := range results {
+			enc.Encode(res)
+			rc.Flush()
+		}
+	}()
+
+	jobs := make(chan streamLine, 64)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for line := range jobs {
+				res := streamResult{ID: line.ID, Segments: s.segment(r.Context(), strings.TrimSpace(line.Text), dict)}
+				if withTypes {
+					res.Types = khmer.ClassifyTokens(res.Segments, dict)
+				}
+				results <- res
+			}
+		}()
+	}
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxRequestBody)
+	for n := 0; scanner.Scan(); n++ {
+		text := scanner.Text()
+		line := streamLine{Text: text}
+		if strings.HasPrefix(strings.TrimSpace(text), "{") {
+			line = streamLine{}
+			if err := json.Unmarshal([]byte(text), &line); err != nil {
+				results <- streamResult{ID: lineID(n), Error: "invalid JSON: " + err.Error()}
+				continue
+			}
+		}
+		if line.ID == nil {
+			line.ID = lineID(n)
+		}
+		jobs <- line
+	}
+	close(jobs)
+	wg.Wait()
+	if err := scanner.Err(); err != nil {
+		results <- streamResult{Error: err.Error()}
+	}
+	close(results)
+	<-writerDone
+}
+
+// lineID is the default id of line n of a stream
+func lineID(n int) json.RawMessage {
+	return json.RawMessage(strconv.Itoa(n))
+}
+
+// grpcServer serves the SegmentStream RPC of proto/segmenter.proto, the gRPC
+// counterpart of /segment/stream (--grpc-addr)
+type grpcServer interface {
+	Serve(ln net.Listener) error
+	GracefulStop()
+	Stop()
+}
+
+// newGRPCServer sets up --grpc-addr. Building with -tags grpc replaces it
+// with the service in grpc.go, keeping gRPC out of the default build.
+var newGRPCServer = func(s *server, tlsCfg *tls.Config) (grpcServer, error) {
+	return nil, fmt.Errorf("--grpc-addr needs a build with -tags grpc")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// timingBuckets are the upper bounds (exclusive, in microseconds) of the latency histogram
+var timingBuckets = []int64{10, 100, 1000, 10000, 100000}
+
+// slowestLines is how many of the slowest lines the timing summary lists
+const slowestLines = 5
+
+// printTimingSummary prints a per-line latency histogram, percentiles and the
+// slowest lines, so pathological inputs that dominate runtime stand out.
+// Line i is reported with its record id, ids[i].
+func printTimingSummary(times []int64, lines []string, ids []int) {
+	if len(times) == 0 {
+		return
+	}
+
+	counts := make([]int, len(timingBuckets)+1)
+	var total int64
+	for _, t := range times {
+		total += t
+		b := sort.Search(len(timingBuckets), func(i int) bool { return t < timingBuckets[i] })
+		counts[b]++
+	}
+
+	fmt.Println("\nPer-line timing (µs):")
+	maxCount := 0
+	for _, c := range counts {
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+	for i, c := range counts {
+		var label string
+		if i < len(timingBuckets) {
+			label = fmt.Sprintf("< %d", timingBuckets[i])
+		} else {
+			label = fmt.Sprintf(">= %d", timingBuckets[len(timingBuckets)-1])
+		}
+		bar := strings.Repeat("#", c*40/maxCount)
+		fmt.Printf("  %-10s %8d  %s\n", label, c, bar)
+	}
+
+	order := make([]int, len(times))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return times[order[a]] < times[order[b]] })
+	percentile := func(p float64) int64 {
+		return times[order[int(p*float64(len(order)-1))]]
+	}
+	fmt.Printf("  mean %d, p50 %d, p90 %d, p99 %d, max %d\n",
+		total/int64(len(times)), percentile(0.50), percentile(0.90), percentile(0.99), times[order[len(order)-1]])
+
+	fmt.Println("Slowest lines:")
+	for k := 0; k < slowestLines && k < len(order); k++ {
+		i := order[len(order)-1-k]
+		fmt.Printf("  id %-8d %8d µs  %d chars\n", ids[i], times[i], len([]rune(lines[i])))
+	}
+}
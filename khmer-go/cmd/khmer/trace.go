@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// tracing reports requests and segmentation stages to a tracing system
+type tracing interface {
+	// wrap gives each request to next a span, carried in its context
+	wrap(endpoint string, next http.Handler) http.Handler
+	// tracer returns the segmenter tracer for a request context
+	tracer(ctx context.Context) khmer.Tracer
+	shutdown(ctx context.Context) error
+}
+
+// newTracing sets up --trace. Building with -tags otel replaces it with
+// OpenTelemetry (see otel.go).
+var newTracing = func() (tracing, error) {
+	return nil, fmt.Errorf("--trace needs a build with -tags otel")
+}
+
+// noTracing is used without --trace
+type noTracing struct{}
+
+func (noTracing) wrap(_ string, next http.Handler) http.Handler { return next }
+func (noTracing) tracer(context.Context) khmer.Tracer           { return nil }
+func (noTracing) shutdown(context.Context) error                { return nil }
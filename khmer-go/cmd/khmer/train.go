@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// runTrain implements `khmer train`: count dictionary words in a corpus and
+// write a frequency file usable with --freq.
+func runTrain(args []string) error {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Existing frequency file used to segment the corpus")
+	outPath := fs.String("out", "", "Output frequency JSON file (required)")
+	mode := fs.String("mode", "auto", "Tokenization: segment, zwsp (trust U+200B boundaries), or auto (zwsp when present)")
+	limit := fs.Int("limit", 0, "Limit number of lines (0 = unlimited)")
+	threads := fs.Int("threads", 0, "Number of worker threads (0 = use all CPUs)")
+	var corpora stringList
+	fs.Var(&corpora, "corpus", "Corpus text file (repeatable)")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	if len(corpora) == 0 || *outPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: khmer train --corpus <file> [--corpus <file>...] --out <file> [options]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	if *mode != "auto" && *mode != "segment" && *mode != "zwsp" {
+		return fmt.Errorf("invalid --mode %q: expected auto, segment or zwsp", *mode)
+	}
+
+	dictionary, err := loadDictionary(*dictPath, *freqPath)
+	if err != nil {
+		return err
+	}
+
+	numWorkers := *threads
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+
+	start := time.Now()
+	lines := make(chan string, numWorkers*64)
+	counters := make([]*khmer.FrequencyCounter, numWorkers)
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		counters[w] = khmer.NewFrequencyCounter(dictionary)
+		wg.Add(1)
+		go func(counter *khmer.FrequencyCounter) {
+			defer wg.Done()
+			segmenter := khmer.NewKhmerSegmenter(dictionary)
+			for line := range lines {
+				annotated := strings.Contains(line, "\u200b")
+				if *mode == "zwsp" || (*mode == "auto" && annotated) {
+					counter.Add(khmer.SplitAnnotated(line))
+				} else {
+					counter.Add(segmenter.Segment(line))
+				}
+			}
+		}(counters[w])
+	}
+
+	processed := 0
+	var readErr error
+	for _, path := range corpora {
+		if *limit > 0 && processed >= *limit {
+			break
+		}
+		n, err := feedLines(path, lines, *limit-processed, *limit > 0)
+		processed += n
+		if err != nil {
+			readErr = err
+			break
+		}
+	}
+	close(lines)
+	wg.Wait()
+	if readErr != nil {
+		return readErr
+	}
+
+	total := khmer.NewFrequencyCounter(dictionary)
+	for _, c := range counters {
+		total.Merge(c)
+	}
+
+	outFile, err := os.Create(*outPath)
+	if err != nil {
+		return fmt.Errorf("could not create output file: %w", err)
+	}
+	if err := khmer.WriteFrequencies(outFile, total.Counts); err != nil {
+		outFile.Close()
+		return err
+	}
+	if err := outFile.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Processed %d lines in %.2fs\n", processed, time.Since(start).Seconds())
+	fmt.Printf("Wrote frequencies for %d words to %s\n", len(total.Counts), *outPath)
+	return nil
+}
+
+// feedLines sends the non-empty trimmed lines of path to out, stopping after
+// max lines when limited. It returns the number of lines sent.
+func feedLines(path string, out chan<- string, max int, limited bool) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("corpus file not found: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	const maxCapacity = 1024 * 1024 // 1MB
+	scanner.Buffer(make([]byte, maxCapacity), maxCapacity)
+	sent := 0
+	for scanner.Scan() {
+		if limited && sent >= max {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		out <- line
+		sent++
+	}
+	return sent, scanner.Err()
+}
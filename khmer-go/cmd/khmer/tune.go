@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// tuneFactors are the changes tried on each cost in a hill-climbing round
+var tuneFactors = []float32{0.5, 0.8, 1.25, 2}
+
+// costConfig is the cost file written by `khmer tune` and read by --costs
+type costConfig struct {
+	DefaultCost float32         `json:"default_cost"`
+	UnknownCost float32         `json:"unknown_cost"`
+	StepCosts   khmer.StepCosts `json:"step_costs"`
+	// F1 and Exact are what the costs scored on the gold set, for reference
+	F1    float64 `json:"f1,omitempty"`
+	Exact float64 `json:"exact_pct,omitempty"`
+}
+
+// readCostConfig reads a cost file
+func readCostConfig(path string) (*costConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c costConfig
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.DefaultCost <= 0 || c.UnknownCost <= 0 {
+		return nil, fmt.Errorf("%s: default_cost and unknown_cost must be positive", path)
+	}
+	return &c, nil
+}
+
+// apply sets the dictionary costs of c on dictionary
+func (c *costConfig) apply(dictionary *khmer.Dictionary) {
+	dictionary.SetDefaultCost(c.DefaultCost)
+	dictionary.UnknownCost = c.UnknownCost
+}
+
+// params returns pointers to the costs the tuner varies
+func (c *costConfig) params() []*float32 {
+	s := &c.StepCosts
+	return []*float32{&c.DefaultCost, &c.UnknownCost, &s.Separator, &s.Number, &s.Acronym, &s.Lunar, &s.Repair, &s.InvalidSingle}
+}
+
+// runTune implements `khmer tune`: hill-climb the dictionary and step costs
+// to maximize boundary F1 on a gold set, and write the best as a cost file
+func runTune(args []string) error {
+	fs := flag.NewFlagSet("tune", flag.ExitOnError)
+	goldPath := fs.String("gold", "../data/test_cases.json", "Gold test cases to maximize boundary F1 on")
+	dictPath := fs.String("dict", "../data/khmer_dictionary_words.txt", "Path to dictionary file")
+	freqPath := fs.String("freq", "../data/khmer_word_frequencies.json", "Path to frequency file")
+	outPath := fs.String("out", "costs.json", "Cost file to write, for use with --costs")
+	rounds := fs.Int("rounds", 10, "Most hill-climbing rounds")
+	applyLogFlags := addLogFlags(fs)
+	fs.Parse(args)
+	if err := applyLogFlags(); err != nil {
+		return err
+	}
+
+	gold, err := readGoldCases(*goldPath)
+	if err != nil {
+		return err
+	}
+	if len(gold) == 0 {
+		return fmt.Errorf("%s has no test cases", *goldPath)
+	}
+	dictionary, err := loadDictionary(*dictPath, *freqPath)
+	if err != nil {
+		return err
+	}
+
+	segmenter := khmer.NewKhmerSegmenter(dictionary)
+	score := func(c *costConfig) benchResult {
+		c.apply(dictionary)
+		segmenter.Costs = &c.StepCosts
+		segments := make([][]string, len(gold))
+		for i, g := range gold {
+			segments[i] = segmenter.Segment(g.Input)
+		}
+		var res benchResult
+		scoreSegmentations(segments, gold, &res)
+		return res
+	}
+
+	best := costConfig{DefaultCost: dictionary.DefaultCost, UnknownCost: dictionary.UnknownCost, StepCosts: khmer.DefaultStepCosts()}
+	bestRes := score(&best)
+	baseline := bestRes
+	logger.Info("Baseline", "f1", fmt.Sprintf("%.4f", bestRes.F1), "exact", fmt.Sprintf("%.2f%%", bestRes.GoldExact))
+
+	// Each round tries every factor on every cost in turn, keeping each change
+	// that scores better
+	for round := 1; round <= *rounds; round++ {
+		improved := false
+		for p := range best.params() {
+			for _, f := range tuneFactors {
+				cand := best
+				*cand.params()[p] *= f
+				res := score(&cand)
+				if res.F1 > bestRes.F1 || (res.F1 == bestRes.F1 && res.GoldExact > bestRes.GoldExact) {
+					best, bestRes, improved = cand, res, true
+				}
+			}
+		}
+		logger.Info("Tuning round", "round", round, "f1", fmt.Sprintf("%.4f", bestRes.F1), "exact", fmt.Sprintf("%.2f%%", bestRes.GoldExact))
+		if !improved {
+			break
+		}
+	}
+
+	best.F1, best.Exact = bestRes.F1, bestRes.GoldExact
+	data, err := json.MarshalIndent(best, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*outPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("could not write cost file: %w", err)
+	}
+	fmt.Printf("Boundary F1 on %d gold cases: %.4f -> %.4f (exact %.2f%% -> %.2f%%)\n",
+		len(gold), baseline.F1, bestRes.F1, baseline.GoldExact, bestRes.GoldExact)
+	fmt.Printf("Wrote costs to %s (use with --costs)\n", *outPath)
+	return nil
+}
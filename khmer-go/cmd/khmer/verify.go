@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// maxVerifyReport caps the mismatching ids --verify prints
+const maxVerifyReport = 20
+
+// unverifiedFields differ between runs of the same segmenter and are not
+// compared by --verify
+var unverifiedFields = []string{"time_us"}
+
+// verifyRecord is an output record as its fields, keyed by name, so records
+// written by other implementations compare equal whatever their field order
+// or escaping
+type verifyRecord struct {
+	id     int
+	fields map[string]any
+}
+
+func nextVerifyRecord(r *recordReader) (*verifyRecord, error) {
+	text, err := r.nextLine()
+	if text == nil || err != nil {
+		return nil, err
+	}
+	rec := &verifyRecord{}
+	if err := json.Unmarshal(text, &rec.fields); err != nil {
+		return nil, fmt.Errorf("%s:%d: %w", r.path, r.line, err)
+	}
+	id, ok := rec.fields["id"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s:%d: record has no id", r.path, r.line)
+	}
+	rec.id = int(id)
+	for _, name := range unverifiedFields {
+		delete(rec.fields, name)
+	}
+	return rec, nil
+}
+
+// differingFields names the fields that differ between two records, in
+// sorted order
+func differingFields(a, b *verifyRecord) []string {
+	var names []string
+	for name, v := range a.fields {
+		if w, ok := b.fields[name]; !ok || !reflect.DeepEqual(v, w) {
+			names = append(names, name)
+		}
+	}
+	for name := range b.fields {
+		if _, ok := a.fields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// verifyOutput implements --verify: compare the records of an output with a
+// reference file by id, report the ids that differ or are missing from
+// either, and fail unless every record matches
+func verifyOutput(outputPath, referencePath string) error {
+	out, err := openRecords(outputPath)
+	if err != nil {
+		return err
+	}
+	defer out.close()
+	ref, err := openRecords(referencePath)
+	if err != nil {
+		return fmt.Errorf("reference: %w", err)
+	}
+	defer ref.close()
+
+	// Records are usually in the same order in both files; those that are
+	// not wait here for their counterpart
+	pendingOut := make(map[int]*verifyRecord)
+	pendingRef := make(map[int]*verifyRecord)
+	compared := 0
+	mismatched := make(map[int][]string)
+	for {
+		o, err := nextVerifyRecord(out)
+		if err != nil {
+			return err
+		}
+		r, err := nextVerifyRecord(ref)
+		if err != nil {
+			return err
+		}
+		if o == nil && r == nil {
+			break
+		}
+		if o != nil {
+			if match, ok := pendingRef[o.id]; ok {
+				delete(pendingRef, o.id)
+				compared++
+				if fields := differingFields(o, match); len(fields) > 0 {
+					mismatched[o.id] = fields
+				}
+			} else {
+				pendingOut[o.id] = o
+			}
+		}
+		if r != nil {
+			if match, ok := pendingOut[r.id]; ok {
+				delete(pendingOut, r.id)
+				compared++
+				if fields := differingFields(match, r); len(fields) > 0 {
+					mismatched[r.id] = fields
+				}
+			} else {
+				pendingRef[r.id] = r
+			}
+		}
+	}
+
+	if len(mismatched) == 0 && len(pendingOut) == 0 && len(pendingRef) == 0 {
+		logger.Info("Verified output against reference", "reference", referencePath, "records", compared)
+		return nil
+	}
+	ids := sortedIDs(mismatched)
+	for i, id := range ids {
+		if i == maxVerifyReport {
+			fmt.Printf("  ... and %d more\n", len(ids)-maxVerifyReport)
+			break
+		}
+		fmt.Printf("  id %d: %v differ\n", id, mismatched[id])
+	}
+	reportMissing := func(what string, pending map[int]*verifyRecord) {
+		if len(pending) == 0 {
+			return
+		}
+		missing := sortedIDs(pending)
+		list := ""
+		for i, id := range missing {
+			if i == maxVerifyReport {
+				list += fmt.Sprintf(" ... and %d more", len(missing)-maxVerifyReport)
+				break
+			}
+			list += " " + strconv.Itoa(id)
+		}
+		fmt.Printf("  %s:%s\n", what, list)
+	}
+	reportMissing("only in output", pendingOut)
+	reportMissing("only in reference", pendingRef)
+	return fmt.Errorf("output differs from %s: %d of %d records mismatch, %d only in output, %d only in reference",
+		referencePath, len(mismatched), compared, len(pendingOut), len(pendingRef))
+}
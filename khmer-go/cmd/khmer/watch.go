@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// inputWatcher reports changes to the input of --watch
+type inputWatcher interface {
+	// changes receives a value once the file has changed and then stopped
+	// changing for the settle interval, so partial writes are not segmented
+	changes() <-chan struct{}
+	close() error
+}
+
+// newInputWatcher watches path, settling changes for interval. It polls the
+// file, which needs no dependency and also sees editors that replace the
+// file on save; building with -tags fsnotify replaces it with filesystem
+// notifications (see fsnotify.go).
+var newInputWatcher = newPollWatcher
+
+// fileState is the part of a file's metadata that --watch compares between polls
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func statInput(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// pollWatcher checks the input's size and modification time every interval
+// and reports a change once they are the same at two polls in a row
+type pollWatcher struct {
+	c    chan struct{}
+	stop chan struct{}
+}
+
+func newPollWatcher(path string, interval time.Duration) (inputWatcher, error) {
+	last, err := statInput(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &pollWatcher{c: make(chan struct{}, 1), stop: make(chan struct{})}
+	go w.run(path, interval, last)
+	return w, nil
+}
+
+func (w *pollWatcher) run(path string, interval time.Duration, last fileState) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	pending := false
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		cur, err := statInput(path)
+		if err != nil {
+			// The file may be briefly missing while an editor replaces it
+			continue
+		}
+		if cur != last {
+			last = cur
+			pending = true
+			continue
+		}
+		if pending {
+			pending = false
+			notifyChange(w.c)
+		}
+	}
+}
+
+func (w *pollWatcher) changes() <-chan struct{} { return w.c }
+
+func (w *pollWatcher) close() error {
+	close(w.stop)
+	return nil
+}
+
+// notifyChange signals c unless a change is already waiting there
+func notifyChange(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// watchInput segments the input once, then re-runs segmentation each time it
+// changes, reusing the loaded dictionary. Segmentation errors are logged and
+// watching continues.
+func watchInput(opts options, proc *processor, numWorkers int) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	interval := opts.watchInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	w, err := newInputWatcher(opts.inputPath, interval)
+	if err != nil {
+		return err
+	}
+	defer w.close()
+	if err := segmentInput(opts, proc, numWorkers); err != nil {
+		logger.Error("Segmentation failed", "err", err)
+	}
+	logger.Info("Watching for changes", "path", opts.inputPath, "interval", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Stopped watching")
+			return nil
+		case <-w.changes():
+		}
+		logger.Info("Input changed, re-segmenting", "path", opts.inputPath)
+		if err := segmentInput(opts, proc, numWorkers); err != nil {
+			logger.Error("Segmentation failed", "err", err)
+		}
+	}
+}
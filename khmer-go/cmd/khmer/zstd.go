@@ -0,0 +1,24 @@
+//go:build zstd
+
+package main
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	c := compression{
+		newWriter: func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	}
+	compressions[".zst"] = c
+	compressions[".zstd"] = c
+}
@@ -0,0 +1,114 @@
+package khmer
+
+// DefaultAffixPenalty is the cost added to a stem's cost for an affixed form.
+// It is below the cost of every default affix, so with affixes enabled
+// prefix+stem reads as one word rather than two.
+const DefaultAffixPenalty = float32(1.0)
+
+// DefaultMinStemCost keeps affixes off function words: ការ+ទៅ or អ្នក+ដែល
+// are two words, and every stem more frequent than this is a particle,
+// pronoun or preposition in the bundled frequencies
+const DefaultMinStemCost = float32(3.0)
+
+// minAffixStem is the shortest stem, in runes, an affix attaches to
+const minAffixStem = 2
+
+// Affixes are derivational prefixes and suffixes. With Segmenter.Affixes set,
+// a prefix followed by a dictionary word, or a dictionary word followed by a
+// suffix, segments as one token at the word's cost plus Penalty even when
+// the derived form is not in the dictionary.
+type Affixes struct {
+	// Penalty is added to the stem's cost (default DefaultAffixPenalty)
+	Penalty float32
+	// MinStemCost is the lowest dictionary cost a stem may have (default
+	// DefaultMinStemCost)
+	MinStemCost float32
+
+	prefixes, suffixes [][]rune
+}
+
+// NewAffixes returns an affix set with DefaultAffixPenalty and DefaultMinStemCost
+func NewAffixes(prefixes, suffixes []string) *Affixes {
+	a := &Affixes{Penalty: DefaultAffixPenalty, MinStemCost: DefaultMinStemCost}
+	for _, p := range prefixes {
+		a.prefixes = append(a.prefixes, []rune(p))
+	}
+	for _, s := range suffixes {
+		a.suffixes = append(a.suffixes, []rune(s))
+	}
+	return a
+}
+
+// maxLen is the length in runes of the longest prefix or suffix
+func (a *Affixes) maxLen() int {
+	longest := 0
+	for _, p := range a.prefixes {
+		longest = max(longest, len(p))
+	}
+	for _, s := range a.suffixes {
+		longest = max(longest, len(s))
+	}
+	return longest
+}
+
+// DefaultAffixes returns the common nominalizing prefixes (ការ-, សេចក្ដី-,
+// ភាព-, អំពើ-) and the suffixes -ករ, -កម្ម, -ភាព, -និយម and -វិទ្យា. The
+// agent prefix អ្នក- is left out: it is also the pronoun "you", and in
+// narrative text អ្នក+verb is far more often two words than one.
+func DefaultAffixes() *Affixes {
+	return NewAffixes(
+		[]string{"ការ", "សេចក្ដី", "សេចក្តី", "ភាព", "អំពើ"},
+		[]string{"ករ", "កម្ម", "ភាព", "និយម", "វិទ្យា"},
+	)
+}
+
+// hasAt reports whether runes[pos:] starts with affix
+func hasAt(runes []rune, pos int, affix []rune) bool {
+	if pos+len(affix) > len(runes) {
+		return false
+	}
+	for k, r := range affix {
+		if runes[pos+k] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup calls visit for every affixed form starting at runes[start]: a
+// prefix plus a dictionary stem, or a dictionary stem plus a suffix
+func (a *Affixes) lookup(d *Dictionary, runes []rune, start int, visit func(end int, cost float32)) {
+	n := len(runes)
+	for _, p := range a.prefixes {
+		if !hasAt(runes, start, p) {
+			continue
+		}
+		stem := start + len(p)
+		limit := stem + d.MaxWordLength
+		if limit > n {
+			limit = n
+		}
+		d.matchRunes(runes, stem, limit, func(j int, c float32) {
+			if j >= stem+minAffixStem && c >= a.MinStemCost {
+				visit(j, c+a.Penalty)
+			}
+		})
+	}
+	if len(a.suffixes) == 0 {
+		return
+	}
+	limit := start + d.MaxWordLength
+	if limit > n {
+		limit = n
+	}
+	d.matchRunes(runes, start, limit-1, func(k int, c float32) {
+		if k < start+minAffixStem || c < a.MinStemCost {
+			return
+		}
+		for _, s := range a.suffixes {
+			if hasAt(runes, k, s) {
+				visit(k+len(s), c+a.Penalty)
+			}
+		}
+	})
+}
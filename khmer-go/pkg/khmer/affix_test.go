@@ -0,0 +1,38 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAffixes(t *testing.T) {
+	dict := NewDictionary()
+	for word, cost := range map[string]float32{"ការ": 2, "ស្រាវជ្រាវ": 4, "ពាណិជ្ជ": 4, "កម្ម": 3, "ទៅ": 2} {
+		dict.words[word] = true
+		dict.wordCosts[word] = cost
+	}
+	dict.MaxWordLength = 10
+	dict.buildTrie()
+
+	seg := NewKhmerSegmenter(dict)
+	seg.PostProcessors = Pipeline{}
+	cases := []struct {
+		input      string
+		off, affix []string
+	}{
+		{"ការស្រាវជ្រាវ", []string{"ការ", "ស្រាវជ្រាវ"}, []string{"ការស្រាវជ្រាវ"}},
+		{"ពាណិជ្ជកម្ម", []string{"ពាណិជ្ជ", "កម្ម"}, []string{"ពាណិជ្ជកម្ម"}},
+		// Function words are not stems
+		{"ការទៅ", []string{"ការ", "ទៅ"}, []string{"ការ", "ទៅ"}},
+	}
+	for _, tc := range cases {
+		seg.Affixes = nil
+		if got := seg.Segment(tc.input); !reflect.DeepEqual(got, tc.off) {
+			t.Errorf("%s without affixes: got %v, want %v", tc.input, got, tc.off)
+		}
+		seg.Affixes = DefaultAffixes()
+		if got := seg.Segment(tc.input); !reflect.DeepEqual(got, tc.affix) {
+			t.Errorf("%s with affixes: got %v, want %v", tc.input, got, tc.affix)
+		}
+	}
+}
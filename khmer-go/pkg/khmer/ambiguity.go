@@ -0,0 +1,205 @@
+package khmer
+
+import (
+	"math"
+	"sort"
+)
+
+// MaxAlternatives caps the segmentations reported per ambiguous span
+const MaxAlternatives = 8
+
+// costSlack absorbs rounding when comparing path costs summed in different
+// orders, so exact ties are found with epsilon 0
+const costSlack = 1e-4
+
+// maxAmbiguityPaths stops the enumeration of a span after this many paths
+const maxAmbiguityPaths = 256
+
+// Alternative is one way to segment an ambiguous span
+type Alternative struct {
+	Segments []string
+	// Cost is how much more the whole path costs than the best one
+	Cost float32
+}
+
+// Ambiguity is a span whose segmentations within epsilon of the best path
+// disagree. Start and End are rune offsets in the text with zero-width spaces
+// removed.
+type Ambiguity struct {
+	Start, End int
+	Text       string
+	// Alternatives[0] is the Viterbi choice (before post-processing); the
+	// rest follow cheapest first, at most MaxAlternatives in total
+	Alternatives []Alternative
+}
+
+// ambiguityEdge is a token from one position to another in the lattice
+type ambiguityEdge struct {
+	to   int
+	cost float32
+}
+
+// Ambiguities returns the spans of text where more than one segmentation
+// costs within epsilon of the best path, with those alternatives. Spans are
+// separated by boundaries every near-best path shares, so each can be
+// reviewed independently.
+func (s *KhmerSegmenter) Ambiguities(text string, epsilon float32) []Ambiguity {
+	ln := newLine(s.normalize(text))
+	runes := ln.runes
+	n := len(runes)
+	if n == 0 {
+		return nil
+	}
+
+	// The lattice, keeping the cheapest token between any two positions
+	out := make([][]ambiguityEdge, n)
+	for i := 0; i < n; i++ {
+		s.edges(ln, i, func(j int, cost float32) {
+			for k := range out[i] {
+				if out[i][k].to == j {
+					if cost < out[i][k].cost {
+						out[i][k].cost = cost
+					}
+					return
+				}
+			}
+			out[i] = append(out[i], ambiguityEdge{to: j, cost: cost})
+		})
+	}
+
+	// The Viterbi path, found exactly as Segment does (float32, same tie-break)
+	inf32 := float32(math.Inf(1))
+	dpCost := make([]float32, n+1)
+	parent := make([]int, n+1)
+	count := make([]int, n+1)
+	for i := range dpCost {
+		dpCost[i], parent[i] = inf32, -1
+	}
+	dpCost[0] = 0
+	for i := 0; i < n; i++ {
+		if dpCost[i] == inf32 {
+			continue
+		}
+		for _, e := range out[i] {
+			c := dpCost[i] + e.cost
+			if c < dpCost[e.to] || (s.TieBreak == TieBreakFewestSegments && c == dpCost[e.to] && count[i]+1 < count[e.to]) {
+				dpCost[e.to], parent[e.to], count[e.to] = c, i, count[i]+1
+			}
+		}
+	}
+
+	// Cheapest cost from the start and to the end, in float64 so long paths
+	// compare without rounding noise
+	inf := math.Inf(1)
+	fwd := make([]float64, n+1)
+	bwd := make([]float64, n+1)
+	for i := range fwd {
+		fwd[i], bwd[i] = inf, inf
+	}
+	fwd[0], bwd[n] = 0, 0
+	for i := 0; i < n; i++ {
+		for _, e := range out[i] {
+			if c := fwd[i] + float64(e.cost); c < fwd[e.to] {
+				fwd[e.to] = c
+			}
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for _, e := range out[i] {
+			if c := float64(e.cost) + bwd[e.to]; c < bwd[i] {
+				bwd[i] = c
+			}
+		}
+	}
+	best := fwd[n]
+	if best == inf {
+		return nil
+	}
+	limit := best + float64(epsilon) + costSlack
+
+	// crossed[k] counts near-best tokens spanning position k
+	crossed := make([]int, n+1)
+	for i := 0; i < n; i++ {
+		if fwd[i] == inf {
+			continue
+		}
+		for _, e := range out[i] {
+			if fwd[i]+float64(e.cost)+bwd[e.to] <= limit && e.to > i+1 {
+				crossed[i+1]++
+				crossed[e.to]--
+			}
+		}
+	}
+	for k := 1; k <= n; k++ {
+		crossed[k] += crossed[k-1]
+	}
+
+	var spans []Ambiguity
+	for k := 1; k < n; k++ {
+		if crossed[k] == 0 {
+			continue
+		}
+		start := k - 1
+		for k < n && crossed[k] > 0 {
+			k++
+		}
+		alts := spanAlternatives(runes, out, fwd, bwd, parent, start, k, best, limit)
+		if len(alts) > 1 {
+			spans = append(spans, Ambiguity{Start: start, End: k, Text: string(runes[start:k]), Alternatives: alts})
+		}
+	}
+	return spans
+}
+
+// spanAlternatives enumerates the near-best segmentations of runes[a:b], the
+// Viterbi one first
+func spanAlternatives(runes []rune, out [][]ambiguityEdge, fwd, bwd []float64, parent []int, a, b int, best, limit float64) []Alternative {
+	type found struct {
+		ends    []int
+		cost    float64
+		viterbi bool
+	}
+	var paths []found
+	var ends []int
+	var walk func(i int, acc float64, viterbi bool)
+	walk = func(i int, acc float64, viterbi bool) {
+		if len(paths) >= maxAmbiguityPaths {
+			return
+		}
+		if i == b {
+			paths = append(paths, found{ends: append([]int(nil), ends...), cost: acc + bwd[b] - best, viterbi: viterbi})
+			return
+		}
+		for _, e := range out[i] {
+			c := acc + float64(e.cost)
+			if e.to > b || c+bwd[e.to] > limit {
+				continue
+			}
+			ends = append(ends, e.to)
+			walk(e.to, c, viterbi && parent[e.to] == i)
+			ends = ends[:len(ends)-1]
+		}
+	}
+	walk(a, fwd[a], true)
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		if paths[i].viterbi != paths[j].viterbi {
+			return paths[i].viterbi
+		}
+		return paths[i].cost < paths[j].cost
+	})
+	if len(paths) > MaxAlternatives {
+		paths = paths[:MaxAlternatives]
+	}
+	alts := make([]Alternative, len(paths))
+	for i, p := range paths {
+		segs := make([]string, len(p.ends))
+		prev := a
+		for k, end := range p.ends {
+			segs[k] = string(runes[prev:end])
+			prev = end
+		}
+		alts[i] = Alternative{Segments: segs, Cost: float32(math.Max(p.cost, 0))}
+	}
+	return alts
+}
@@ -0,0 +1,59 @@
+package khmer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAmbiguitiesTie(t *testing.T) {
+	dict := NewDictionary()
+	for word, cost := range map[string]float32{"ក": 1, "ខ": 1, "គឃ": 1, "កខគ": 2, "ឃ": 1, "ង": 1} {
+		dict.words[word] = true
+		dict.wordCosts[word] = cost
+	}
+	dict.MaxWordLength = 3
+	dict.buildTrie()
+	seg := NewKhmerSegmenter(dict)
+
+	got := seg.Ambiguities("ង កខគឃ", 0)
+	want := []Ambiguity{{
+		Start: 2, End: 6, Text: "កខគឃ",
+		Alternatives: []Alternative{{Segments: []string{"ក", "ខ", "គឃ"}}, {Segments: []string{"កខគ", "ឃ"}}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// The Viterbi choice comes first whatever the tie-break rule
+	seg.TieBreak = TieBreakFewestSegments
+	if got := seg.Ambiguities("ង កខគឃ", 0); len(got) != 1 || !reflect.DeepEqual(got[0].Alternatives[0].Segments, []string{"កខគ", "ឃ"}) {
+		t.Errorf("fewest-segments: got %+v", got)
+	}
+}
+
+func TestAmbiguitiesEpsilon(t *testing.T) {
+	input := "ថ្ងៃនេះ"
+	if got := testSegmenter.Ambiguities(input, 0); len(got) != 0 {
+		t.Errorf("Expected no exact ties in %q, got %+v", input, got)
+	}
+	got := testSegmenter.Ambiguities(input, 1)
+	if len(got) != 1 {
+		t.Fatalf("Expected one ambiguous span within cost 1, got %+v", got)
+	}
+	amb := got[0]
+	if amb.Text != input || len(amb.Alternatives) < 2 {
+		t.Fatalf("Unexpected span %+v", amb)
+	}
+	if first := amb.Alternatives[0]; first.Cost != 0 || !reflect.DeepEqual(first.Segments, testSegmenter.Segment(input)) {
+		t.Errorf("First alternative should be the chosen path, got %+v", first)
+	}
+	for _, alt := range amb.Alternatives {
+		if strings.Join(alt.Segments, "") != amb.Text {
+			t.Errorf("Alternative %v does not cover %q", alt.Segments, amb.Text)
+		}
+		if alt.Cost > 1 {
+			t.Errorf("Alternative %v costs %v, beyond epsilon", alt.Segments, alt.Cost)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package khmer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBeam(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(testDataDir, "test_subset.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(data), "\n")
+	for _, tc := range testCases {
+		lines = append(lines, tc.Input)
+	}
+
+	for _, beam := range []float32{1, 5, 100} {
+		seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+		seg.Beam = beam
+		differ := 0
+		for _, line := range lines {
+			got, want := seg.Segment(line), testSegmenter.Segment(line)
+			if strings.Join(got, "") != strings.Join(want, "") {
+				t.Fatalf("Beam %v: segments of %q do not join to the line", beam, line)
+			}
+			if !reflect.DeepEqual(got, want) {
+				differ++
+			}
+		}
+		if differ > len(lines)/20 || (beam == 100 && differ > 0) {
+			t.Errorf("Beam %v: %d of %d lines differ from the full search", beam, differ, len(lines))
+		}
+	}
+}
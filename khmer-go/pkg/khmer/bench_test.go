@@ -0,0 +1,90 @@
+package khmer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchCorpusEnv names a corpus file to benchmark instead of test_subset.txt
+const benchCorpusEnv = "KHMER_BENCH_CORPUS"
+
+// loadCorpus returns the non-empty lines of the benchmark corpus
+func loadCorpus(b *testing.B) []string {
+	b.Helper()
+	path := os.Getenv(benchCorpusEnv)
+	if path == "" {
+		path = filepath.Join(testDataDir, "test_subset.txt")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		b.Fatal(err)
+	}
+	if len(lines) == 0 {
+		b.Fatalf("corpus %s is empty", path)
+	}
+	return lines
+}
+
+// benchmarkSegment segments text b.N times with a fresh segmenter
+func benchmarkSegment(b *testing.B, text string) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	b.SetBytes(int64(len(text)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		seg.Segment(text)
+	}
+}
+
+func BenchmarkSegmentShort(b *testing.B) {
+	benchmarkSegment(b, "ខ្ញុំស្រលាញ់កម្ពុជា")
+}
+
+func BenchmarkSegmentLong(b *testing.B) {
+	benchmarkSegment(b, strings.Join(loadCorpus(b), " "))
+}
+
+func BenchmarkSegmentCorpus(b *testing.B) {
+	lines := loadCorpus(b)
+	size := 0
+	for _, line := range lines {
+		size += len(line)
+	}
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	b.SetBytes(int64(size))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			seg.Segment(line)
+		}
+	}
+	b.ReportMetric(float64(len(lines)*b.N)/b.Elapsed().Seconds(), "lines/s")
+}
+
+func BenchmarkDictionaryLoad(b *testing.B) {
+	dictPath := filepath.Join(testDataDir, "khmer_dictionary_words.txt")
+	freqPath := filepath.Join(testDataDir, "khmer_word_frequencies.json")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := NewDictionary().Load(dictPath, freqPath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
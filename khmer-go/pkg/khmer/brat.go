@@ -0,0 +1,70 @@
+package khmer
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// BratWriter writes segmented lines as a brat standoff document, so the
+// segmentation can be reviewed and corrected in brat or another tool reading
+// its format: the lines go to the .txt, one per line, and each token becomes
+// a text-bound annotation "T<n><TAB><type> <start> <end><TAB><text>" in the
+// .ann. Offsets count characters (code points) from the start of the .txt.
+// Whitespace tokens are not annotated.
+type BratWriter struct {
+	txt, ann *bufio.Writer
+	// offset is the number of characters written to the .txt
+	offset int
+	// ids counts the annotations written
+	ids int
+	buf []byte
+}
+
+// NewBratWriter returns a writer of the .txt and .ann files of a document
+func NewBratWriter(txt, ann io.Writer) *BratWriter {
+	return &BratWriter{txt: bufio.NewWriter(txt), ann: bufio.NewWriter(ann)}
+}
+
+// WriteLine adds a line with its tokens, located by byte offsets into line
+// as SegmentSpans returns them, and their types from ClassifyTokens
+func (w *BratWriter) WriteLine(line string, spans []Span, types []TokenType) error {
+	// Characters are counted up to each token as the line is walked
+	pos, chars := 0, w.offset
+	for i, sp := range spans {
+		chars += utf8.RuneCountInString(line[pos:sp.Start])
+		start := chars
+		chars += utf8.RuneCountInString(line[sp.Start:sp.End])
+		pos = sp.End
+		if types[i] == TokenSpace {
+			continue
+		}
+		w.ids++
+		w.buf = append(w.buf[:0], 'T')
+		w.buf = strconv.AppendInt(w.buf, int64(w.ids), 10)
+		w.buf = append(w.buf, '\t')
+		w.buf = append(w.buf, types[i]...)
+		w.buf = append(w.buf, ' ')
+		w.buf = strconv.AppendInt(w.buf, int64(start), 10)
+		w.buf = append(w.buf, ' ')
+		w.buf = strconv.AppendInt(w.buf, int64(chars), 10)
+		w.buf = append(w.buf, '\t')
+		w.buf = append(w.buf, line[sp.Start:sp.End]...)
+		w.buf = append(w.buf, '\n')
+		if _, err := w.ann.Write(w.buf); err != nil {
+			return err
+		}
+	}
+	w.offset += utf8.RuneCountInString(line) + 1
+	w.txt.WriteString(line)
+	return w.txt.WriteByte('\n')
+}
+
+// Flush writes buffered text and annotations
+func (w *BratWriter) Flush() error {
+	if err := w.txt.Flush(); err != nil {
+		return err
+	}
+	return w.ann.Flush()
+}
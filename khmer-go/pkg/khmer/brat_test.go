@@ -0,0 +1,54 @@
+package khmer
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBratWriter(t *testing.T) {
+	lines := []string{"ខ្ញុំ\u200bទៅសាលា ១២៣!", "", "abc ថ្ងៃនេះ"}
+	var txt, ann strings.Builder
+	w := NewBratWriter(&txt, &ann)
+	for _, line := range lines {
+		spans := testSegmenter.SegmentSpans(line)
+		segments := make([]string, len(spans))
+		for i, sp := range spans {
+			segments[i] = sp.Text
+		}
+		if err := w.WriteLine(line, spans, ClassifyTokens(segments, testSegmenter.Dictionary)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(lines, "\n") + "\n"; txt.String() != want {
+		t.Fatalf(".txt = %q, want %q", txt.String(), want)
+	}
+
+	// Every annotation covers its text at its character offsets
+	doc := []rune(txt.String())
+	scanner := bufio.NewScanner(strings.NewReader(ann.String()))
+	n := 0
+	for scanner.Scan() {
+		n++
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 || fields[0] != "T"+strconv.Itoa(n) {
+			t.Fatalf("Malformed annotation %q", scanner.Text())
+		}
+		typeSpan := strings.Fields(fields[1])
+		start, _ := strconv.Atoi(typeSpan[1])
+		end, _ := strconv.Atoi(typeSpan[2])
+		if got := string(doc[start:end]); got != fields[2] {
+			t.Errorf("%s covers %q, want %q", fields[0], got, fields[2])
+		}
+		if typeSpan[0] == string(TokenSpace) {
+			t.Errorf("%s annotates whitespace", fields[0])
+		}
+	}
+	if n < 8 {
+		t.Errorf("Only %d annotations:\n%s", n, ann.String())
+	}
+}
@@ -0,0 +1,89 @@
+package khmer
+
+import (
+	"container/list"
+	"sync"
+)
+
+// SegmentCache is a least-recently-used cache of segmented lines, so
+// duplicate lines (common in crawled and social media corpora) are segmented
+// once. Entries are keyed by line and dictionary; share a cache only between
+// segmenters with the same options. It is safe for concurrent use.
+type SegmentCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[cacheKey]*list.Element
+	order    *list.List // most recently used first
+	hits     uint64
+	misses   uint64
+}
+
+type cacheKey struct {
+	dict *Dictionary
+	text string
+}
+
+type cacheEntry struct {
+	key      cacheKey
+	segments []string
+}
+
+// CacheStats counts the lookups of a SegmentCache
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// HitRate is the share of lookups that were hits, or 0 before any lookup
+func (st CacheStats) HitRate() float64 {
+	if total := st.Hits + st.Misses; total > 0 {
+		return float64(st.Hits) / float64(total)
+	}
+	return 0
+}
+
+// NewSegmentCache returns a cache holding up to capacity lines
+func NewSegmentCache(capacity int) *SegmentCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &SegmentCache{capacity: capacity, items: make(map[cacheKey]*list.Element), order: list.New()}
+}
+
+// get returns a copy of the cached segments of text under dict
+func (c *SegmentCache) get(dict *Dictionary, text string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[cacheKey{dict, text}]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return append([]string(nil), el.Value.(*cacheEntry).segments...), true
+}
+
+// add caches a copy of segments, evicting the least recently used line when full
+func (c *SegmentCache) add(dict *Dictionary, text string, segments []string) {
+	key := cacheKey{dict, text}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; ok {
+		return
+	}
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, segments: append([]string(nil), segments...)})
+}
+
+// Stats returns the hits and misses so far and the number of cached lines
+func (c *SegmentCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+}
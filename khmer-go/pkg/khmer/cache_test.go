@@ -0,0 +1,56 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegmentCache(t *testing.T) {
+	cache := NewSegmentCache(2)
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.Cache = cache
+
+	want := testSegmenter.Segment("ខ្ញុំទៅសាលារៀន")
+	for i := 0; i < 3; i++ {
+		if got := seg.Segment("ខ្ញុំទៅសាលារៀន"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Run %d: got %q, want %q", i, got, want)
+		}
+	}
+	if st := cache.Stats(); st.Hits != 2 || st.Misses != 1 || st.Entries != 1 {
+		t.Errorf("Stats = %+v, want 2 hits, 1 miss, 1 entry", st)
+	}
+
+	// Callers may change the slice they get without changing the cache
+	got := seg.Segment("ខ្ញុំទៅសាលារៀន")
+	got[0] = "changed"
+	if again := seg.Segment("ខ្ញុំទៅសាលារៀន"); !reflect.DeepEqual(again, want) {
+		t.Errorf("Cached segments changed: %q", again)
+	}
+
+	// The least recently used line is evicted
+	seg.Segment("សួស្តី")
+	seg.Segment("ខ្ញុំទៅសាលារៀន")
+	seg.Segment("ភ្នំពេញ")
+	before := cache.Stats()
+	seg.Segment("ខ្ញុំទៅសាលារៀន")
+	seg.Segment("សួស្តី")
+	if st := cache.Stats(); st.Hits != before.Hits+1 || st.Misses != before.Misses+1 {
+		t.Errorf("Expected a hit for the recent line and a miss for the evicted one, got %+v after %+v", st, before)
+	}
+}
+
+func TestSegmentCacheDictionary(t *testing.T) {
+	// The same line under another dictionary is not a hit
+	cache := NewSegmentCache(10)
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.Cache = cache
+	seg.Segment("ខ្ញុំទៅសាលារៀន")
+	seg.Dictionary = NewDictionary()
+	seg.Segment("ខ្ញុំទៅសាលារៀន")
+	if st := cache.Stats(); st.Hits != 0 || st.Entries != 2 {
+		t.Errorf("Stats = %+v, want no hits and 2 entries", st)
+	}
+	if rate := (CacheStats{Hits: 3, Misses: 1}).HitRate(); rate != 0.75 {
+		t.Errorf("HitRate = %v, want 0.75", rate)
+	}
+}
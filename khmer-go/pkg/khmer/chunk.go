@@ -0,0 +1,53 @@
+package khmer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// chunkText splits text into pieces of at most limit runes that join back
+// into it. Each cut goes after the last sentence mark (។ ៕) or whitespace
+// no token can cross within the limit or, failing that, before the last
+// Khmer cluster, so words are only split in a piece with no boundary at all.
+// Zero-width spaces are no boundary: they are removed before the Viterbi
+// loop, so words span them. Nor is a space in a number (១៤ ០០០) or before a
+// vowel or sign, which joins the cluster (see runContinues).
+func chunkText(text string, limit int) []string {
+	var chunks []string
+	start, startRune := 0, 0
+	// Byte offsets and rune indexes of the last cut candidates
+	safe, safeRune := -1, 0
+	cluster, clusterRune := -1, 0
+	// space is set after whitespace no run continues into, a candidate once
+	// no run continues from it into the next rune either
+	space := false
+	var prev rune
+	for i, n := 0, 0; i < len(text); n++ {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if space && !runContinues(prev, r) {
+			safe, safeRune = i, n
+		}
+		space = false
+		if n-startRune >= limit {
+			cut, cutRune := i, n
+			if safe > start {
+				cut, cutRune = safe, safeRune
+			} else if cluster > start {
+				cut, cutRune = cluster, clusterRune
+			}
+			chunks = append(chunks, text[start:cut])
+			start, startRune = cut, cutRune
+		}
+		if isClusterStart(r) && prev != '\u17D2' {
+			cluster, clusterRune = i, n
+		}
+		i += size
+		if r == '\u17D4' || r == '\u17D5' {
+			safe, safeRune = i, n+1
+		} else if unicode.IsSpace(r) && (n == 0 || !runContinues(prev, r)) {
+			space = true
+		}
+		prev = r
+	}
+	return append(chunks, text[start:])
+}
@@ -0,0 +1,77 @@
+package khmer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkText(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  []string
+	}{
+		{"ខ្ញុំ ទៅ សាលា", 6, []string{"ខ្ញុំ ", "ទៅ ", "សាលា"}},
+		{"ខ្ញុំទៅ។សាលា", 8, []string{"ខ្ញុំទៅ។", "សាលា"}},
+		// Zero-width spaces are no boundary
+		{"ខ្ញុំ\u200bទៅ\u200bសាលា", 8, []string{"ខ្ញុំ\u200b", "ទៅ\u200bសាលា"}},
+		// Nor are spaces in a number or before a sign
+		{"ក ខ ១៤ ០០០", 8, []string{"ក ", "ខ ១៤ ០០០"}},
+		{"ក ខ ់ក", 5, []string{"ក ", "ខ ់ក"}},
+		// No boundary: cut before a cluster, never after a coeng
+		{"ខ្ញុំទៅសាលា", 6, []string{"ខ្ញុំ", "ទៅសាលា"}},
+		{"abcdefg", 3, []string{"abc", "def", "g"}},
+		{"short", 10, []string{"short"}},
+	}
+	for _, tt := range tests {
+		if got := chunkText(tt.text, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("chunkText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestChunkRunes(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(testDataDir, "test_subset.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each line with its words marked by zero-width spaces, as annotated
+	// text has them, then numbers with their digit groups spaced
+	var b strings.Builder
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			b.WriteString(strings.Join(testSegmenter.Segment(line), "\u200b"))
+			b.WriteString(" ")
+		}
+	}
+	annotated := b.String()
+	numbers := strings.Repeat("តម្លៃ ១៤ ០០០ រៀល និង 1 250 000.50 ដុល្លារ ", 100)
+
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.ChunkRunes = 200
+	for name, text := range map[string]string{"annotated": annotated, "numbers": numbers} {
+		chunks := chunkText(text, seg.ChunkRunes)
+		if len(chunks) < 10 {
+			t.Fatalf("%s: only %d chunks", name, len(chunks))
+		}
+		for _, chunk := range chunks {
+			if n := utf8.RuneCountInString(chunk); n > seg.ChunkRunes {
+				t.Fatalf("%s: chunk of %d runes, limit %d", name, n, seg.ChunkRunes)
+			}
+		}
+		got, want := seg.Segment(text), testSegmenter.Segment(text)
+		if !reflect.DeepEqual(got, want) {
+			for i := range want {
+				if i >= len(got) || got[i] != want[i] {
+					t.Errorf("%s: chunked segments differ from the whole line at segment %d: %q, want %q",
+						name, i, got[i:min(i+5, len(got))], want[i:min(i+5, len(want))])
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package khmer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Compiled dictionary format (little endian):
+//
+//	magic       [8]byte  "KHMTRIE1"
+//	defaultCost float32
+//	unknownCost float32
+//	maxWordLen  uint32
+//	wordCount   uint32
+//	root        node
+//
+// node := flags byte (1 = word, 2 = cost from frequencies), [cost float32 if word],
+// childCount uvarint, then childCount × (rune uvarint, node).
+const compiledMagic = "KHMTRIE1"
+
+const (
+	nodeFlagWord    = 1
+	nodeFlagHasFreq = 2
+)
+
+// maxCompiledWordLen bounds the maxWordLen header field, and with it the
+// depth of the trie, so a corrupt file cannot make loading recurse or
+// allocate without limit
+const maxCompiledWordLen = 1024
+
+// ErrNotCompiled is returned when a file does not start with the compiled dictionary magic
+var ErrNotCompiled = errors.New("not a compiled dictionary")
+
+// IsCompiledDictionary reports whether path holds a compiled trie artifact
+func IsCompiledDictionary(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	var magic [len(compiledMagic)]byte
+	if _, err := io.ReadFull(file, magic[:]); err != nil {
+		return false
+	}
+	return string(magic[:]) == compiledMagic
+}
+
+// WriteCompiled serializes the loaded dictionary (after variant generation,
+// filtering and cost calculation) as a binary trie
+func (d *Dictionary) WriteCompiled(w io.Writer) error {
+	bw := bufio.NewWriterSize(w, 256*1024)
+	bw.WriteString(compiledMagic)
+	var hdr [16]byte
+	binary.LittleEndian.PutUint32(hdr[0:], math.Float32bits(d.DefaultCost))
+	binary.LittleEndian.PutUint32(hdr[4:], math.Float32bits(d.UnknownCost))
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(d.MaxWordLength))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(len(d.words)))
+	bw.Write(hdr[:])
+
+	path := make([]rune, 0, d.MaxWordLength)
+	if err := d.writeNode(bw, d.trie, path); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (d *Dictionary) writeNode(bw *bufio.Writer, node *TrieNode, path []rune) error {
+	var flags byte
+	if node.isWord {
+		flags |= nodeFlagWord
+		if _, ok := d.wordCosts[string(path)]; ok {
+			flags |= nodeFlagHasFreq
+		}
+	}
+	bw.WriteByte(flags)
+	if node.isWord {
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(node.cost))
+		bw.Write(buf[:])
+	}
+
+	children := node.sortedChildren()
+	var vbuf [binary.MaxVarintLen64]byte
+	bw.Write(vbuf[:binary.PutUvarint(vbuf[:], uint64(len(children)))])
+	for _, r := range children {
+		bw.Write(vbuf[:binary.PutUvarint(vbuf[:], uint64(r))])
+		if err := d.writeNode(bw, node.getChild(r), append(path, r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedChildren returns the runes of all children in ascending order
+func (n *TrieNode) sortedChildren() []rune {
+	var runes []rune
+	n.eachChild(func(r rune, _ *TrieNode) {
+		runes = append(runes, r)
+	})
+	return runes
+}
+
+// LoadCompiled loads a dictionary written by WriteCompiled, skipping variant
+// generation and frequency processing
+func (d *Dictionary) LoadCompiled(path string) error {
+	defer startSpan(d.Tracer, SpanLoadCompiled)()
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("compiled dictionary not found at %s: %w", path, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReaderSize(file, 256*1024)
+	var magic [len(compiledMagic)]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil || string(magic[:]) != compiledMagic {
+		return fmt.Errorf("%s: %w", path, ErrNotCompiled)
+	}
+	var hdr [16]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return fmt.Errorf("error reading compiled dictionary header: %w", err)
+	}
+	d.DefaultCost = math.Float32frombits(binary.LittleEndian.Uint32(hdr[0:]))
+	d.UnknownCost = math.Float32frombits(binary.LittleEndian.Uint32(hdr[4:]))
+	maxWordLen := binary.LittleEndian.Uint32(hdr[8:])
+	wordCount := int64(binary.LittleEndian.Uint32(hdr[12:]))
+	// Every word takes at least a byte of the file
+	if maxWordLen > maxCompiledWordLen || wordCount > info.Size() {
+		return fmt.Errorf("compiled dictionary is corrupt: header claims %d words of up to %d runes in %d bytes", wordCount, maxWordLen, info.Size())
+	}
+	d.MaxWordLength = int(maxWordLen)
+
+	// The maps and path grow with what is read rather than trusting the
+	// header
+	d.words = make(map[string]bool)
+	d.wordCosts = make(map[string]float32)
+	d.variants = make(map[string]bool)
+	d.trie = &TrieNode{}
+	if err := d.readNode(br, d.trie, nil); err != nil {
+		return fmt.Errorf("error reading compiled dictionary: %w", err)
+	}
+	if int64(len(d.words)) != wordCount {
+		return fmt.Errorf("compiled dictionary is corrupt: expected %d words, found %d", wordCount, len(d.words))
+	}
+
+	d.logger().Info("Loaded compiled dictionary", "path", path, "words", len(d.words), "max_length", d.MaxWordLength)
+	return nil
+}
+
+func (d *Dictionary) readNode(br *bufio.Reader, node *TrieNode, path []rune) error {
+	flags, err := br.ReadByte()
+	if err != nil {
+		return err
+	}
+	if flags&nodeFlagWord != 0 {
+		var buf [4]byte
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			return err
+		}
+		node.isWord = true
+		node.cost = math.Float32frombits(binary.LittleEndian.Uint32(buf[:]))
+		word := string(path)
+		d.words[word] = true
+		if flags&nodeFlagHasFreq != 0 {
+			d.wordCosts[word] = node.cost
+		}
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		r, err := binary.ReadUvarint(br)
+		if err != nil {
+			return err
+		}
+		if r > math.MaxInt32 {
+			return fmt.Errorf("invalid rune %d", r)
+		}
+		if len(path) == d.MaxWordLength {
+			return fmt.Errorf("trie deeper than the maximum word length %d", d.MaxWordLength)
+		}
+		child := node.getOrCreateChild(rune(r))
+		if err := d.readNode(br, child, append(path, rune(r))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
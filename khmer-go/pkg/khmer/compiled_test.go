@@ -0,0 +1,82 @@
+package khmer
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompiledDictionaryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dict.trie")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testSegmenter.Dictionary.WriteCompiled(file); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if !IsCompiledDictionary(path) {
+		t.Fatal("Expected compiled dictionary to be detected")
+	}
+	dict := NewDictionary()
+	if err := dict.LoadCompiled(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(dict.words) != len(testSegmenter.Dictionary.words) {
+		t.Errorf("Expected %d words, got %d", len(testSegmenter.Dictionary.words), len(dict.words))
+	}
+
+	seg := NewKhmerSegmenter(dict)
+	for _, tc := range testCases {
+		if result := seg.Segment(tc.Input); !reflect.DeepEqual(result, tc.Expected) {
+			t.Errorf("[%d] Expected %v, got %v", tc.ID, tc.Expected, result)
+		}
+	}
+}
+
+func TestLoadCompiledRejectsTextDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	os.WriteFile(path, []byte("សួស្តី\n"), 0o644)
+	if IsCompiledDictionary(path) {
+		t.Error("Text dictionary detected as compiled")
+	}
+	if err := NewDictionary().LoadCompiled(path); err == nil {
+		t.Error("Expected error loading text dictionary as compiled")
+	}
+}
+
+func TestLoadCompiledRejectsCorruptHeader(t *testing.T) {
+	header := func(maxWordLen, wordCount uint32) []byte {
+		b := []byte(compiledMagic)
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(5))
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(10))
+		b = binary.LittleEndian.AppendUint32(b, maxWordLen)
+		return binary.LittleEndian.AppendUint32(b, wordCount)
+	}
+	// A chain of single-child nodes, one level deeper than maxWordLen allows
+	deep := header(2, 0)
+	for i := 0; i < 3; i++ {
+		deep = append(deep, 0, 1, 'a')
+	}
+	deep = append(deep, 0, 0)
+
+	tests := map[string][]byte{
+		"huge max word length":  append(header(0xF0000000, 0), 0, 0),
+		"more words than bytes": append(header(4, 0xFFFFFFF), 0, 0),
+		"trie deeper than max":  deep,
+	}
+	for name, data := range tests {
+		path := filepath.Join(t.TempDir(), "dict.trie")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewDictionary().LoadCompiled(path); err == nil {
+			t.Errorf("%s: expected error loading corrupt dictionary", name)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package khmer
+
+import "sort"
+
+// completion is a candidate found by CompletePrefix
+type completion struct {
+	word string
+	cost float32
+}
+
+// CompletePrefix returns up to limit dictionary words starting with prefix,
+// most frequent (lowest cost) first, for autocomplete. The prefix itself is
+// included when it is a word. A limit of zero or less returns every match.
+func (d *Dictionary) CompletePrefix(prefix string, limit int) []string {
+	if d.trie == nil {
+		return nil
+	}
+	node := d.trie
+	for i := 0; i < len(prefix); i++ {
+		if node = node.byteChild(prefix[i]); node == nil {
+			return nil
+		}
+	}
+
+	var found []completion
+	node.eachWord([]byte(prefix), func(word []byte, node *TrieNode) bool {
+		found = append(found, completion{word: string(word), cost: node.cost})
+		return true
+	})
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].cost != found[j].cost {
+			return found[i].cost < found[j].cost
+		}
+		return found[i].word < found[j].word
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	words := make([]string, len(found))
+	for i, c := range found {
+		words[i] = c.word
+	}
+	return words
+}
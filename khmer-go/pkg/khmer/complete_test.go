@@ -0,0 +1,41 @@
+package khmer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompletePrefix(t *testing.T) {
+	dict := NewDictionary()
+	words := map[string]float32{"ក": 1, "កខ": 3, "កខគ": 2, "កគ": 3, "ខ": 1}
+	for word, cost := range words {
+		dict.words[word] = true
+		dict.wordCosts[word] = cost
+	}
+	dict.MaxWordLength = 3
+	dict.buildTrie()
+
+	// Lowest cost first, ties in word order
+	if got, want := dict.CompletePrefix("ក", 0), []string{"ក", "កខគ", "កខ", "កគ"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CompletePrefix(ក) = %q, want %q", got, want)
+	}
+	if got, want := dict.CompletePrefix("ក", 2), []string{"ក", "កខគ"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CompletePrefix(ក, 2) = %q, want %q", got, want)
+	}
+	if got := dict.CompletePrefix("គ", 5); len(got) != 0 {
+		t.Errorf("Expected no completions, got %q", got)
+	}
+}
+
+func TestCompletePrefixDictionary(t *testing.T) {
+	got := testSegmenter.Dictionary.CompletePrefix("កម្ពុ", 10)
+	if len(got) == 0 || len(got) > 10 {
+		t.Fatalf("Expected 1 to 10 completions, got %q", got)
+	}
+	for _, word := range got {
+		if !strings.HasPrefix(word, "កម្ពុ") {
+			t.Errorf("%q does not start with the prefix", word)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package khmer
+
+import "math"
+
+// minCompoundPart is the shortest constituent, in runes, SplitCompound
+// considers; single letters are dictionary words but not useful index terms
+const minCompoundPart = 2
+
+// compoundPart is a dictionary word that may be part of a compound
+type compoundPart struct {
+	end  int
+	cost float32
+}
+
+// SplitCompound splits a dictionary word into shorter dictionary words, or
+// returns nil when word is not in the dictionary or is not a compound.
+// Following Koehn & Knight's frequency-based splitting, a split is only
+// accepted when the geometric mean frequency of its parts is above the whole
+// word's (their mean cost is lower). The split with the fewest such parts is
+// chosen, so "សាលារៀន" gives "សាលា", "រៀន" rather than three syllables; among
+// equally many parts the cheapest wins. Parts start on cluster boundaries and
+// are at least two runes long.
+func (d *Dictionary) SplitCompound(word string) []string {
+	if !d.Has(word) {
+		return nil
+	}
+	runes := []rune(word)
+	n := len(runes)
+	if n < 2*minCompoundPart {
+		return nil
+	}
+
+	// Cluster starts are the only places a part may begin or end
+	boundary := make([]bool, n+1)
+	for i := 0; i < n; i += getKhmerClusterLength(runes, i, n) {
+		boundary[i] = true
+	}
+	boundary[n] = true
+
+	// The dictionary words between boundaries, found once with a trie walk
+	// from each boundary rather than looked up for every part count
+	parts := make([][]compoundPart, n)
+	for i := 0; i < n; i++ {
+		if !boundary[i] {
+			continue
+		}
+		d.matchRunes(runes, i, n, func(j int, c float32) {
+			if j >= i+minCompoundPart && boundary[j] && !(i == 0 && j == n) {
+				parts[i] = append(parts[i], compoundPart{end: j, cost: c})
+			}
+		})
+	}
+
+	// cost[k][j] is the cheapest split of runes[:j] into k parts
+	maxParts := n / minCompoundPart
+	inf := float32(math.Inf(1))
+	cost := make([][]float32, maxParts+1)
+	parent := make([][]int, maxParts+1)
+	for k := range cost {
+		cost[k] = make([]float32, n+1)
+		parent[k] = make([]int, n+1)
+		for j := range cost[k] {
+			cost[k][j] = inf
+		}
+	}
+	cost[0][0] = 0
+	for k := 1; k <= maxParts; k++ {
+		for i := 0; i < n; i++ {
+			if cost[k-1][i] == inf {
+				continue
+			}
+			for _, p := range parts[i] {
+				if c := cost[k-1][i] + p.cost; c < cost[k][p.end] {
+					cost[k][p.end] = c
+					parent[k][p.end] = i
+				}
+			}
+		}
+	}
+
+	bestK, wordCost := 0, d.Cost(word)
+	for k := 2; k <= maxParts && bestK == 0; k++ {
+		if cost[k][n]/float32(k) < wordCost {
+			bestK = k
+		}
+	}
+	if bestK == 0 {
+		return nil
+	}
+
+	split := make([]string, bestK)
+	for k, j := bestK, n; k > 0; k-- {
+		i := parent[k][j]
+		split[k-1] = string(runes[i:j])
+		j = i
+	}
+	return split
+}
+
+// SplitCompounds returns SplitCompound for each segment, nil for the
+// segments that are not compounds
+func SplitCompounds(segments []string, dict *Dictionary) [][]string {
+	parts := make([][]string, len(segments))
+	for i, seg := range segments {
+		parts[i] = dict.SplitCompound(seg)
+	}
+	return parts
+}
@@ -0,0 +1,33 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCompound(t *testing.T) {
+	dict := testSegmenter.Dictionary
+	cases := map[string][]string{
+		"សាលារៀន":    {"សាលា", "រៀន"},
+		"ពីព្រេងនាយ": {"ពី", "ព្រេងនាយ"},
+		"ចាប់ត្រី":   {"ចាប់", "ត្រី"},
+		// Parts rarer than the whole word are not a compound
+		"និយាយ":   nil,
+		"កម្ពុជា": nil,
+		// Neither are words outside the dictionary
+		"ប្រទេសកម្ពុជា": nil,
+	}
+	for word, want := range cases {
+		if got := dict.SplitCompound(word); !reflect.DeepEqual(got, want) {
+			t.Errorf("SplitCompound(%q) = %v, want %v", word, got, want)
+		}
+	}
+}
+
+func TestSplitCompounds(t *testing.T) {
+	got := SplitCompounds([]string{"ខ្ញុំ", "ទៅ", "សាលារៀន"}, testSegmenter.Dictionary)
+	want := [][]string{nil, nil, {"សាលា", "រៀន"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
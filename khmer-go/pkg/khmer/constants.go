@@ -1,5 +1,7 @@
 package khmer
 
+import "unicode"
+
 // Unicode character classification utilities for Khmer script.
 // Khmer Unicode Block: U+1780 - U+17FF (main), U+19E0 - U+19FF (symbols)
 
@@ -55,7 +57,9 @@ func IsCurrencySymbol(r rune) bool {
 	return CurrencySymbols[r]
 }
 
-// IsSeparator checks if character is a separator/punctuation
+// IsSeparator checks if character is a separator/punctuation. Every Unicode
+// space (tab, no-break space, thin space, ideographic space, ...) counts,
+// as ASCII space does.
 func IsSeparator(r rune) bool {
 	// Khmer punctuation range
 	if r >= 0x17D4 && r <= 0x17DA {
@@ -71,7 +75,13 @@ func IsSeparator(r rune) bool {
 			return true
 		}
 	}
-	return false
+	return unicode.IsSpace(r)
+}
+
+// IsLunarSymbol checks if character is a Khmer lunar date or divination
+// symbol (U+19E0 - U+19FF); each is a token of its own
+func IsLunarSymbol(r rune) bool {
+	return r >= 0x19E0 && r <= 0x19FF
 }
 
 // IsValidSingleWord checks if character can be a single-character word
@@ -0,0 +1,54 @@
+package khmer
+
+// StepCosts are the costs the Viterbi loop gives tokens found by rule rather
+// than by dictionary lookup. DefaultStepCosts holds the hand-tuned values;
+// `khmer tune` searches for better ones on a gold set.
+type StepCosts struct {
+	// Separator is the cost of a space or punctuation mark
+	Separator float32 `json:"separator"`
+	// Number is the cost of a digit group or currency amount
+	Number float32 `json:"number"`
+	// Acronym is the cost of an acronym such as ស.ភ.ក.
+	Acronym float32 `json:"acronym"`
+	// Lunar is the cost of a lunar date symbol
+	Lunar float32 `json:"lunar"`
+	// Repair is added to UnknownCost for a character that cannot start a
+	// token (a dependent vowel, or the character after a coeng)
+	Repair float32 `json:"repair"`
+	// InvalidSingle is added to UnknownCost for a lone consonant that is
+	// not a valid single-letter word
+	InvalidSingle float32 `json:"invalid_single"`
+}
+
+// DefaultStepCosts returns the built-in step costs
+func DefaultStepCosts() StepCosts {
+	return defaultStepCosts
+}
+
+var defaultStepCosts = StepCosts{
+	Separator:     0.1,
+	Number:        1.0,
+	Acronym:       1.0,
+	Lunar:         1.0,
+	Repair:        50.0,
+	InvalidSingle: 10.0,
+}
+
+// stepCosts returns the segmenter's step costs
+func (s *KhmerSegmenter) stepCosts() *StepCosts {
+	if s.Costs != nil {
+		return s.Costs
+	}
+	return &defaultStepCosts
+}
+
+// SetDefaultCost changes the cost of dictionary words without a frequency.
+// UnknownCost is left as it is.
+func (d *Dictionary) SetDefaultCost(cost float32) {
+	d.DefaultCost = cost
+	for word := range d.words {
+		if _, ok := d.wordCosts[word]; !ok {
+			d.insertIntoTrie(word, cost)
+		}
+	}
+}
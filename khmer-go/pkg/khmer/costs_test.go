@@ -0,0 +1,39 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStepCosts(t *testing.T) {
+	text := "ខ្ញុំទៅសាលារៀន ១២៣ ABC"
+
+	// The defaults are what a segmenter with no Costs uses
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	costs := DefaultStepCosts()
+	seg.Costs = &costs
+	if got, want := seg.Segment(text), testSegmenter.Segment(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Segment with DefaultStepCosts = %q, want %q", got, want)
+	}
+	if seg := NewKhmerSegmenter(testSegmenter.Dictionary); *seg.stepCosts() != defaultStepCosts {
+		t.Errorf("stepCosts() with no Costs = %+v, want the defaults", seg.stepCosts())
+	}
+}
+
+func TestSetDefaultCost(t *testing.T) {
+	// "កខ" has no frequency, so its cost follows DefaultCost
+	d := mergeDict(map[string]float32{"ក": 3, "ខ": 3}, 4)
+	d.addWordWithVariants("កខ")
+	d.buildTrie()
+	seg := NewKhmerSegmenter(d)
+	if got := seg.Segment("កខ"); !reflect.DeepEqual(got, []string{"កខ"}) {
+		t.Fatalf("Segment = %q, want the whole word", got)
+	}
+	d.SetDefaultCost(10)
+	if d.DefaultCost != 10 || d.Cost("ក") != 3 {
+		t.Errorf("after SetDefaultCost(10): DefaultCost %v, Cost(ក) %v", d.DefaultCost, d.Cost("ក"))
+	}
+	if got := seg.Segment("កខ"); !reflect.DeepEqual(got, []string{"ក", "ខ"}) {
+		t.Errorf("Segment after SetDefaultCost(10) = %q, want the two costed words", got)
+	}
+}
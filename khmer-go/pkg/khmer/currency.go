@@ -0,0 +1,59 @@
+package khmer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CurrencyCodes maps each of CurrencySymbols to its ISO 4217 code
+var CurrencyCodes = map[rune]string{
+	'$':      "USD",
+	'\u17DB': "KHR", // ៛
+	'\u20AC': "EUR",
+	'\u00A3': "GBP",
+	'\u00A5': "JPY",
+}
+
+// Currency is the parsed form of a CURRENCY token such as "$5" or "១០០០៛"
+type Currency struct {
+	Code  string
+	Value float64
+}
+
+// ParseCurrency splits a currency token into its code and amount. The
+// symbol may come before or after the number, which is parsed with
+// ParseNumber.
+func ParseCurrency(token string) (Currency, error) {
+	runes := []rune(token)
+	if len(runes) < 2 {
+		return Currency{}, fmt.Errorf("invalid currency amount %q", token)
+	}
+	code, amount := CurrencyCodes[runes[0]], string(runes[1:])
+	if code == "" {
+		code, amount = CurrencyCodes[runes[len(runes)-1]], string(runes[:len(runes)-1])
+	}
+	if code == "" {
+		return Currency{}, fmt.Errorf("invalid currency amount %q: no currency symbol", token)
+	}
+	value, err := ParseNumber(strings.TrimSpace(amount))
+	if err != nil {
+		return Currency{}, fmt.Errorf("invalid currency amount %q: %w", token, err)
+	}
+	return Currency{Code: code, Value: value}, nil
+}
+
+// currencyLength returns the length in runes of a currency amount starting
+// at runes[i]: a symbol then a number, or a number then a symbol. It is 0
+// when there is none.
+func currencyLength(runes []rune, i, n int) int {
+	if IsCurrencySymbol(runes[i]) {
+		if i+1 < n && IsDigit(runes[i+1]) {
+			return 1 + getNumberLength(runes, i+1, n)
+		}
+		return 0
+	}
+	if numLen := getNumberLength(runes, i, n); numLen > 0 && i+numLen < n && IsCurrencySymbol(runes[i+numLen]) {
+		return numLen + 1
+	}
+	return 0
+}
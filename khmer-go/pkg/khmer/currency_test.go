@@ -0,0 +1,48 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCurrency(t *testing.T) {
+	cases := map[string]Currency{
+		"$5":        {"USD", 5},
+		"$1,250.50": {"USD", 1250.5},
+		"១០០០៛":     {"KHR", 1000},
+		"៛២.០០០":    {"KHR", 2000},
+		"5€":        {"EUR", 5},
+	}
+	for token, want := range cases {
+		if got, err := ParseCurrency(token); err != nil || got != want {
+			t.Errorf("ParseCurrency(%q) = %v, %v; want %v", token, got, err, want)
+		}
+	}
+	for _, token := range []string{"", "$", "5", "$abc", "៛5$"} {
+		if got, err := ParseCurrency(token); err == nil {
+			t.Errorf("ParseCurrency(%q) = %v, want an error", token, got)
+		}
+	}
+}
+
+func TestGroupCurrency(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	input := "តម្លៃ $5 ឬ ១០០០៛"
+	if got := seg.Segment(input); got[2] != "$" {
+		t.Errorf("Currency grouped without GroupCurrency: %v", got)
+	}
+
+	seg.GroupCurrency = true
+	want := []Token{
+		{"តម្លៃ", TokenKhmerWord},
+		{" ", TokenSpace},
+		{"$5", TokenCurrency},
+		{" ", TokenSpace},
+		{"ឬ", TokenKhmerWord},
+		{" ", TokenSpace},
+		{"១០០០៛", TokenCurrency},
+	}
+	if got := seg.Tokenize(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package khmer
+
+import (
+	"math"
+	"sort"
+)
+
+// CostChange is a word present in both dictionaries whose cost differs
+type CostChange struct {
+	Word    string
+	OldCost float32
+	NewCost float32
+}
+
+// DictionaryDiff lists the differences between two loaded dictionaries
+type DictionaryDiff struct {
+	Added   []string
+	Removed []string
+	Changed []CostChange
+}
+
+// Empty reports whether the dictionaries are equivalent
+func (d *DictionaryDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffDictionaries compares the effective word sets and trie costs of two
+// dictionaries. Either side may be loaded from text or a compiled artifact.
+// Cost changes smaller than epsilon are ignored. Results are sorted by word.
+func DiffDictionaries(old, new *Dictionary, epsilon float32) *DictionaryDiff {
+	diff := &DictionaryDiff{}
+	for word := range new.words {
+		if !old.words[word] {
+			diff.Added = append(diff.Added, word)
+		}
+	}
+	for word := range old.words {
+		if !new.words[word] {
+			diff.Removed = append(diff.Removed, word)
+			continue
+		}
+		runes := []rune(word)
+		oldCost, _ := old.LookupRunes(runes)
+		newCost, _ := new.LookupRunes(runes)
+		if math.Abs(float64(newCost-oldCost)) > float64(epsilon) {
+			diff.Changed = append(diff.Changed, CostChange{Word: word, OldCost: oldCost, NewCost: newCost})
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Word < diff.Changed[j].Word })
+	return diff
+}
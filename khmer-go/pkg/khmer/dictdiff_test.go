@@ -0,0 +1,47 @@
+package khmer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadTestDictionary(t *testing.T, words, freqs string) *Dictionary {
+	t.Helper()
+	dir := t.TempDir()
+	dictPath := filepath.Join(dir, "words.txt")
+	freqPath := filepath.Join(dir, "freq.json")
+	os.WriteFile(dictPath, []byte(words), 0o644)
+	os.WriteFile(freqPath, []byte(freqs), 0o644)
+	dict := NewDictionary()
+	if err := dict.Load(dictPath, freqPath); err != nil {
+		t.Fatal(err)
+	}
+	return dict
+}
+
+func TestDiffDictionaries(t *testing.T) {
+	old := loadTestDictionary(t, "សួស្តី\nបង\nទៅ\n", `{"សួស្តី": 100, "បង": 10, "ទៅ": 10}`)
+	new := loadTestDictionary(t, "សួស្តី\nបង\nសាលា\n", `{"សួស្តី": 100, "បង": 50, "សាលា": 10}`)
+
+	diff := DiffDictionaries(old, new, 0.01)
+	if !reflect.DeepEqual(diff.Added, []string{"សាលា"}) {
+		t.Errorf("Added: got %v", diff.Added)
+	}
+	if !reflect.DeepEqual(diff.Removed, []string{"ទៅ"}) {
+		t.Errorf("Removed: got %v", diff.Removed)
+	}
+	var bong *CostChange
+	for i := range diff.Changed {
+		if diff.Changed[i].Word == "បង" {
+			bong = &diff.Changed[i]
+		}
+	}
+	if bong == nil || bong.NewCost >= bong.OldCost {
+		t.Errorf("Expected cheaper cost for បង, got %+v", diff.Changed)
+	}
+	if !DiffDictionaries(old, old, 0).Empty() {
+		t.Error("Expected empty diff against itself")
+	}
+}
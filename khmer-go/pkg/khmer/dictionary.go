@@ -4,103 +4,192 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math"
 	"os"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
-const (
-	khmerStart = 0x1780
-	khmerEnd   = 0x17FF
-	khmerRange = khmerEnd - khmerStart + 1 // 128
-)
+// denseChildren is the number of children from which a node also indexes
+// them by byte
+const denseChildren = 8
 
-// TrieNode represents a node in the trie with flat array optimization for Khmer range
+// TrieNode is a node of a trie keyed by UTF-8 bytes: a rune of n bytes is n
+// levels deep, and only nodes at rune boundaries end a word. Lookups walk the
+// bytes of the input text directly, with no rune decoding. Children are kept
+// as parallel slices in byte order; most nodes have one or two, so a short
+// scan finds them, in a fraction of the memory of per-node child arrays. The
+// few wide nodes near the root also get a table indexed by byte.
 type TrieNode struct {
-	// Flat array for O(1) Khmer character lookup (0x1780-0x17FF)
-	khmerChildren [khmerRange]*TrieNode
-	// Fallback map for non-Khmer characters
-	otherChildren map[rune]*TrieNode
-	isWord        bool
-	cost          float32
+	labels   []byte
+	children []*TrieNode
+	dense    *[256]*TrieNode
+	isWord   bool
+	cost     float32
 }
 
-// getChild returns child for rune using O(1) array access for Khmer range
+// byteChild returns the child for byte b, or nil
 //
 //go:inline
-func (n *TrieNode) getChild(r rune) *TrieNode {
-	if r >= khmerStart && r <= khmerEnd {
-		return n.khmerChildren[r-khmerStart]
+func (n *TrieNode) byteChild(b byte) *TrieNode {
+	if n.dense != nil {
+		return n.dense[b]
 	}
-	if n.otherChildren == nil {
-		return nil
+	for k, l := range n.labels {
+		if l == b {
+			return n.children[k]
+		}
 	}
-	return n.otherChildren[r]
+	return nil
 }
 
-// getOrCreateChild gets or creates child using O(1) array access for Khmer range
-func (n *TrieNode) getOrCreateChild(r rune) *TrieNode {
-	if r >= khmerStart && r <= khmerEnd {
-		idx := r - khmerStart
-		if n.khmerChildren[idx] == nil {
-			n.khmerChildren[idx] = &TrieNode{}
+// getChild returns the node one rune r below n, or nil
+func (n *TrieNode) getChild(r rune) *TrieNode {
+	if r < utf8.RuneSelf {
+		return n.byteChild(byte(r))
+	}
+	var buf [utf8.UTFMax]byte
+	node := n
+	for _, b := range buf[:utf8.EncodeRune(buf[:], r)] {
+		if node = node.byteChild(b); node == nil {
+			return nil
 		}
-		return n.khmerChildren[idx]
 	}
-	// Non-Khmer: use map
-	if n.otherChildren == nil {
-		n.otherChildren = make(map[rune]*TrieNode)
+	return node
+}
+
+// getOrCreateChild gets or creates the node one rune r below n
+func (n *TrieNode) getOrCreateChild(r rune) *TrieNode {
+	var buf [utf8.UTFMax]byte
+	node := n
+	for _, b := range buf[:utf8.EncodeRune(buf[:], r)] {
+		node = node.getOrCreateByteChild(b)
 	}
-	child, exists := n.otherChildren[r]
-	if !exists {
-		child = &TrieNode{}
-		n.otherChildren[r] = child
+	return node
+}
+
+// getOrCreateByteChild gets or creates the child for byte b, keeping the
+// children in byte order
+func (n *TrieNode) getOrCreateByteChild(b byte) *TrieNode {
+	k := 0
+	for k < len(n.labels) && n.labels[k] < b {
+		k++
+	}
+	if k < len(n.labels) && n.labels[k] == b {
+		return n.children[k]
+	}
+	child := &TrieNode{}
+	n.labels = append(n.labels, 0)
+	n.children = append(n.children, nil)
+	copy(n.labels[k+1:], n.labels[k:])
+	copy(n.children[k+1:], n.children[k:])
+	n.labels[k], n.children[k] = b, child
+	if n.dense != nil {
+		n.dense[b] = child
+	} else if len(n.labels) >= denseChildren {
+		n.dense = new([256]*TrieNode)
+		for k, l := range n.labels {
+			n.dense[l] = n.children[k]
+		}
 	}
 	return child
 }
 
-// Dictionary holds the word set and frequency costs
+// eachChild calls visit with every rune one level below n and its node, in
+// ascending rune order (UTF-8 byte order is code point order)
+func (n *TrieNode) eachChild(visit func(r rune, child *TrieNode)) {
+	var buf [utf8.UTFMax]byte
+	n.eachRuneChild(buf[:0], visit)
+}
+
+func (n *TrieNode) eachRuneChild(prefix []byte, visit func(r rune, child *TrieNode)) {
+	for k, b := range n.labels {
+		p := append(prefix, b)
+		if utf8.FullRune(p) {
+			r, _ := utf8.DecodeRune(p)
+			visit(r, n.children[k])
+		} else {
+			n.children[k].eachRuneChild(p, visit)
+		}
+	}
+}
+
+// eachWord calls visit with every word at or below n, path being the bytes
+// that lead to n, in byte (and so code point) order. It stops, returning
+// false, as soon as visit does.
+func (n *TrieNode) eachWord(path []byte, visit func(word []byte, node *TrieNode) bool) bool {
+	if n.isWord && !visit(path, n) {
+		return false
+	}
+	for k, b := range n.labels {
+		if !n.children[k].eachWord(append(path, b), visit) {
+			return false
+		}
+	}
+	return true
+}
+
+// Dictionary holds the word set and frequency costs. Read it through Has,
+// Cost, Size and MaxLen; the maps behind them are internal and may change.
 type Dictionary struct {
-	Words         map[string]bool
-	WordCosts     map[string]float32
+	words         map[string]bool
+	wordCosts     map[string]float32
 	MaxWordLength int
 	DefaultCost   float32
 	UnknownCost   float32
-	// Optimized Trie for fast rune lookups
+	// Logger receives load progress; nil discards it
+	Logger *slog.Logger
+	// Tracer, when set, receives the stages of Load and LoadCompiled
+	Tracer Tracer
+	// VariantGenerators, set before loading, produce the spelling variants
+	// added for each word and frequency; nil means
+	// DefaultVariantGenerators. A compiled dictionary keeps the variants it
+	// was compiled with.
+	VariantGenerators []VariantGenerator
+	// DisableVariants, set before loading, skips variant generation, for
+	// corpora that are already normalized
+	DisableVariants bool
+	// trie holds every word with its cost, keyed by UTF-8 bytes
 	trie *TrieNode
+	// variants holds the words present only through variant expansion
+	variants map[string]bool
 }
 
 const minFreqFloor = 5.0
 
-// Precompiled patterns as simple string operations
-var (
-	coengTa = "\u17D2\u178F"
-	coengDa = "\u17D2\u178D"
-	coengRo = "\u17D2\u179A"
-)
-
 // NewDictionary creates a new empty dictionary
 func NewDictionary() *Dictionary {
 	return &Dictionary{
-		Words:         make(map[string]bool),
-		WordCosts:     make(map[string]float32),
+		words:         make(map[string]bool),
+		wordCosts:     make(map[string]float32),
 		MaxWordLength: 0,
 		DefaultCost:   10.0,
 		UnknownCost:   20.0,
 		trie:          &TrieNode{},
+		variants:      make(map[string]bool),
 	}
 }
 
 // Load loads dictionary and frequency files
 func (d *Dictionary) Load(dictPath, freqPath string) error {
-	if err := d.loadDictionary(dictPath); err != nil {
+	end := startSpan(d.Tracer, SpanLoadDictionary)
+	err := d.loadDictionary(dictPath)
+	end()
+	if err != nil {
 		return err
 	}
-	if err := d.loadFrequencies(freqPath); err != nil {
+	end = startSpan(d.Tracer, SpanLoadFrequencies)
+	err = d.loadFrequencies(freqPath)
+	end()
+	if err != nil {
 		return err
 	}
 	// Build trie after loading
+	end = startSpan(d.Tracer, SpanBuildTrie)
 	d.buildTrie()
+	end()
 	return nil
 }
 
@@ -116,6 +205,7 @@ func (d *Dictionary) loadDictionary(path string) error {
 		validSingleWords[string(r)] = true
 	}
 
+	skipped := 0
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		word := strings.TrimSpace(scanner.Text())
@@ -126,6 +216,7 @@ func (d *Dictionary) loadDictionary(path string) error {
 		// Filter invalid single-char words
 		runes := []rune(word)
 		if len(runes) == 1 && !validSingleWords[word] {
+			skipped++
 			continue
 		}
 
@@ -138,32 +229,9 @@ func (d *Dictionary) loadDictionary(path string) error {
 
 	// Post-process: remove compound words with OR, repetition mark, Coeng starts
 	toRemove := make(map[string]bool)
-	for word := range d.Words {
-		// Contains ឬ (OR)
-		if strings.Contains(word, "\u17AC") && len([]rune(word)) > 1 {
-			if strings.HasPrefix(word, "\u17AC") {
-				suffix := strings.TrimPrefix(word, "\u17AC")
-				if d.Words[suffix] {
-					toRemove[word] = true
-				}
-			} else if strings.HasSuffix(word, "\u17AC") {
-				prefix := strings.TrimSuffix(word, "\u17AC")
-				if d.Words[prefix] {
-					toRemove[word] = true
-				}
-			} else {
-				parts := strings.Split(word, "\u17AC")
-				allValid := true
-				for _, p := range parts {
-					if p != "" && !d.Words[p] {
-						allValid = false
-						break
-					}
-				}
-				if allValid {
-					toRemove[word] = true
-				}
-			}
+	for word := range d.words {
+		if isOrCompound(word, d.words) {
+			toRemove[word] = true
 		}
 
 		// Contains repetition mark
@@ -178,25 +246,59 @@ func (d *Dictionary) loadDictionary(path string) error {
 	}
 
 	for word := range toRemove {
-		delete(d.Words, word)
+		// Only entries of the file count as dropped, not their variants
+		if !d.variants[word] {
+			skipped++
+		}
+		delete(d.words, word)
+		delete(d.variants, word)
 	}
-	delete(d.Words, "\u17D7")
+	delete(d.words, "\u17D7")
+	delete(d.variants, "\u17D7")
 
 	// Recalculate max word length
 	d.MaxWordLength = 0
-	for word := range d.Words {
+	for word := range d.words {
 		wordLen := len([]rune(word))
 		if wordLen > d.MaxWordLength {
 			d.MaxWordLength = wordLen
 		}
 	}
 
-	fmt.Printf("Loaded %d words. Max length: %d\n", len(d.Words), d.MaxWordLength)
+	d.logger().Info("Loaded dictionary", "path", path, "words", len(d.words), "max_length", d.MaxWordLength)
+	if skipped > 0 {
+		d.logger().Info("Dropped invalid dictionary entries (run `khmer dict validate` for details)", "count", skipped)
+	}
 	return nil
 }
 
+// isOrCompound reports whether word joins other words of the dictionary
+// with ឬ ("or"), e.g. "ឬទេ" or "បាទឬចាស", and is dropped so the parts are
+// segmented separately
+func isOrCompound(word string, words map[string]bool) bool {
+	if !strings.Contains(word, "\u17AC") || len([]rune(word)) <= 1 {
+		return false
+	}
+	if strings.HasPrefix(word, "\u17AC") {
+		return words[strings.TrimPrefix(word, "\u17AC")]
+	}
+	if strings.HasSuffix(word, "\u17AC") {
+		return words[strings.TrimSuffix(word, "\u17AC")]
+	}
+	for _, p := range strings.Split(word, "\u17AC") {
+		if p != "" && !words[p] {
+			return false
+		}
+	}
+	return true
+}
+
 func (d *Dictionary) addWordWithVariants(word string) {
-	d.Words[word] = true
+	if d.variants == nil {
+		d.variants = make(map[string]bool)
+	}
+	d.words[word] = true
+	delete(d.variants, word)
 	wordLen := len([]rune(word))
 	if wordLen > d.MaxWordLength {
 		d.MaxWordLength = wordLen
@@ -204,7 +306,10 @@ func (d *Dictionary) addWordWithVariants(word string) {
 
 	variants := d.generateVariants(word)
 	for _, v := range variants {
-		d.Words[v] = true
+		if !d.words[v] {
+			d.variants[v] = true
+		}
+		d.words[v] = true
 		vLen := len([]rune(v))
 		if vLen > d.MaxWordLength {
 			d.MaxWordLength = vLen
@@ -212,83 +317,10 @@ func (d *Dictionary) addWordWithVariants(word string) {
 	}
 }
 
-func (d *Dictionary) generateVariants(word string) []string {
-	variants := make(map[string]bool)
-
-	// 1. Coeng Ta <-> Coeng Da swap
-	if strings.Contains(word, coengTa) {
-		variants[strings.ReplaceAll(word, coengTa, coengDa)] = true
-	}
-	if strings.Contains(word, coengDa) {
-		variants[strings.ReplaceAll(word, coengDa, coengTa)] = true
-	}
-
-	// 2. Coeng Ro ordering swaps
-	baseSet := make(map[string]bool)
-	baseSet[word] = true
-	for v := range variants {
-		baseSet[v] = true
-	}
-
-	for w := range baseSet {
-		swapped := swapCoengRoOrder(w)
-		if swapped != w {
-			variants[swapped] = true
-		}
-	}
-
-	result := make([]string, 0, len(variants))
-	for v := range variants {
-		result = append(result, v)
-	}
-	return result
-}
-
-// swapCoengRoOrder swaps Coeng+Ro with adjacent Coeng+X patterns
-func swapCoengRoOrder(word string) string {
-	runes := []rune(word)
-	n := len(runes)
-	if n < 4 {
-		return word
-	}
-
-	result := make([]rune, 0, n)
-	i := 0
-	changed := false
-
-	for i < n {
-		// Look for pattern: Coeng + Ro + Coeng + X
-		if i+3 < n &&
-			runes[i] == 0x17D2 && runes[i+1] == 0x179A &&
-			runes[i+2] == 0x17D2 && runes[i+3] != 0x179A {
-			result = append(result, runes[i+2], runes[i+3], runes[i], runes[i+1])
-			i += 4
-			changed = true
-			continue
-		}
-		// Look for pattern: Coeng + X + Coeng + Ro
-		if i+3 < n &&
-			runes[i] == 0x17D2 && runes[i+1] != 0x179A &&
-			runes[i+2] == 0x17D2 && runes[i+3] == 0x179A {
-			result = append(result, runes[i+2], runes[i+3], runes[i], runes[i+1])
-			i += 4
-			changed = true
-			continue
-		}
-		result = append(result, runes[i])
-		i++
-	}
-
-	if changed {
-		return string(result)
-	}
-	return word
-}
-
 func (d *Dictionary) loadFrequencies(path string) error {
 	file, err := os.Open(path)
 	if err != nil {
-		fmt.Printf("Frequency file not found at %s. Using default costs.\n", path)
+		d.logger().Warn("Frequency file not found, using default costs", "path", path)
 		return nil
 	}
 	defer file.Close()
@@ -299,10 +331,19 @@ func (d *Dictionary) loadFrequencies(path string) error {
 		return fmt.Errorf("error parsing frequency file: %w", err)
 	}
 
+	// Visit words in sorted order, so the float32 total and the count of a
+	// variant shared by several words come out the same on every load
+	words := make([]string, 0, len(data))
+	for word := range data {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
 	effectiveCounts := make(map[string]float32, len(data))
 	var totalTokens float32 = 0
 
-	for word, count := range data {
+	for _, word := range words {
+		count := data[word]
 		eff := float32(math.Max(count, minFreqFloor))
 		effectiveCounts[word] = eff
 
@@ -325,21 +366,21 @@ func (d *Dictionary) loadFrequencies(path string) error {
 		for word, count := range effectiveCounts {
 			prob := count / totalTokens
 			if prob > 0 {
-				d.WordCosts[word] = float32(-math.Log10(float64(prob)))
+				d.wordCosts[word] = float32(-math.Log10(float64(prob)))
 			}
 		}
 	}
 
-	fmt.Printf("Loaded frequencies for %d words.\n", len(d.WordCosts))
-	fmt.Printf("Default cost: %.2f (freq floor=%.0f), Unknown cost: %.2f\n",
-		d.DefaultCost, minFreqFloor, d.UnknownCost)
+	d.logger().Info("Loaded frequencies", "path", path, "words", len(d.wordCosts),
+		"default_cost", fmt.Sprintf("%.2f", d.DefaultCost), "freq_floor", minFreqFloor,
+		"unknown_cost", fmt.Sprintf("%.2f", d.UnknownCost))
 	return nil
 }
 
 // buildTrie builds the optimized trie from the dictionary
 func (d *Dictionary) buildTrie() {
-	for word := range d.Words {
-		cost := d.GetWordCost(word)
+	for word := range d.words {
+		cost := d.Cost(word)
 		d.insertIntoTrie(word, cost)
 	}
 }
@@ -354,17 +395,30 @@ func (d *Dictionary) insertIntoTrie(word string, cost float32) {
 	node.cost = cost
 }
 
-// LookupRuneRange looks up a slice range in the trie (zero allocation)
+// LookupByteRange looks up text[start:end] in the trie by its UTF-8 bytes
+// (zero allocation). start and end should be rune boundaries.
 //
 //go:inline
+func (d *Dictionary) LookupByteRange(text string, start, end int) (float32, bool) {
+	node := d.trie
+	for i := start; i < end; i++ {
+		if node = node.byteChild(text[i]); node == nil {
+			return 0, false
+		}
+	}
+	if node.isWord {
+		return node.cost, true
+	}
+	return 0, false
+}
+
+// LookupRuneRange looks up a slice range in the trie (zero allocation)
 func (d *Dictionary) LookupRuneRange(runes []rune, start, end int) (float32, bool) {
 	node := d.trie
 	for i := start; i < end; i++ {
-		child := node.getChild(runes[i])
-		if child == nil {
+		if node = node.getChild(runes[i]); node == nil {
 			return 0, false
 		}
-		node = child
 	}
 	if node.isWord {
 		return node.cost, true
@@ -372,23 +426,89 @@ func (d *Dictionary) LookupRuneRange(runes []rune, start, end int) (float32, boo
 	return 0, false
 }
 
+// matchRunes calls visit with the end and cost of every dictionary word
+// runes[start:j], j <= limit, in increasing j. Like matchPrefixes it keeps a
+// cursor in the trie, advancing it one rune per end instead of looking each
+// prefix up from the root, and stops where no word continues.
+func (d *Dictionary) matchRunes(runes []rune, start, limit int, visit func(j int, cost float32)) {
+	node := d.trie
+	for j := start + 1; j <= limit; j++ {
+		if node = node.getChild(runes[j-1]); node == nil {
+			return
+		}
+		if node.isWord {
+			visit(j, node.cost)
+		}
+	}
+}
+
+// LongestMatch returns the length in runes and the cost of the longest
+// dictionary word starting at runes[start], for greedy tokenizers and
+// validators. ok is false when no word starts there.
+func (d *Dictionary) LongestMatch(runes []rune, start int) (length int, cost float32, ok bool) {
+	if d.trie == nil || start < 0 || start >= len(runes) {
+		return 0, 0, false
+	}
+	d.matchRunes(runes, start, len(runes), func(j int, c float32) {
+		length, cost, ok = j-start, c, true
+	})
+	return length, cost, ok
+}
+
 // LookupRunes looks up a rune slice in the trie and returns (cost, found)
 func (d *Dictionary) LookupRunes(runes []rune) (float32, bool) {
 	return d.LookupRuneRange(runes, 0, len(runes))
 }
 
-// Contains checks if a word is in the dictionary
-func (d *Dictionary) Contains(word string) bool {
-	return d.Words[word]
+// Has reports whether word is in the dictionary, including the spelling
+// variants added when loading
+func (d *Dictionary) Has(word string) bool {
+	return d.words[word]
 }
 
-// GetWordCost returns the cost for a word
-func (d *Dictionary) GetWordCost(word string) float32 {
-	if cost, ok := d.WordCosts[word]; ok {
+// Cost returns the cost of word: its frequency cost, DefaultCost for a word
+// without a frequency, or UnknownCost when it is not in the dictionary
+func (d *Dictionary) Cost(word string) float32 {
+	if cost, ok := d.wordCosts[word]; ok {
 		return cost
 	}
-	if d.Words[word] {
+	if d.words[word] {
 		return d.DefaultCost
 	}
 	return d.UnknownCost
 }
+
+// Size returns the number of words, spelling variants included
+func (d *Dictionary) Size() int {
+	return len(d.words)
+}
+
+// MaxLen returns the length in runes of the longest word
+func (d *Dictionary) MaxLen() int {
+	return d.MaxWordLength
+}
+
+// Walk calls fn with every word in the trie and its cost in lexicographic
+// (code point) order, stopping early when fn returns false
+func (d *Dictionary) Walk(fn func(word string, cost float32) bool) {
+	if d.trie == nil {
+		return
+	}
+	d.trie.eachWord(nil, func(word []byte, node *TrieNode) bool {
+		return fn(string(word), node.cost)
+	})
+}
+
+// Contains checks if a word is in the dictionary
+//
+// Deprecated: use Has.
+func (d *Dictionary) Contains(word string) bool {
+	return d.Has(word)
+}
+
+// GetWordCost returns the cost for a word
+//
+// Deprecated: use Cost.
+func (d *Dictionary) GetWordCost(word string) float32 {
+	return d.Cost(word)
+}
@@ -0,0 +1,185 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestByteTrie(t *testing.T) {
+	dict := NewDictionary()
+	words := map[string]float32{"ក": 1, "កខ": 2, "ab": 3, "é": 4, "😀ក": 5}
+	for word, cost := range words {
+		dict.words[word] = true
+		dict.wordCosts[word] = cost
+	}
+	dict.MaxWordLength = 2
+	dict.buildTrie()
+
+	for word, want := range words {
+		if cost, ok := dict.LookupByteRange("x"+word+"x", 1, 1+len(word)); !ok || cost != want {
+			t.Errorf("LookupByteRange(%q) = %v, %v; want %v", word, cost, ok, want)
+		}
+		if cost, ok := dict.LookupRunes([]rune(word)); !ok || cost != want {
+			t.Errorf("LookupRunes(%q) = %v, %v; want %v", word, cost, ok, want)
+		}
+	}
+	// Prefixes inside a rune or of a word are not words
+	for _, miss := range []string{"ខ", "a", "\xe1\x9e", "😀"} {
+		if _, ok := dict.LookupByteRange(miss, 0, len(miss)); ok {
+			t.Errorf("LookupByteRange(%q) found a word", miss)
+		}
+	}
+
+	// Children come back as whole runes in code point order
+	if got, want := dict.trie.sortedChildren(), []rune{'a', 'é', 'ក', '😀'}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortedChildren = %q, want %q", got, want)
+	}
+}
+
+func TestByteTrieDense(t *testing.T) {
+	// Enough children under one node to index them by byte
+	dict := NewDictionary()
+	for r := 'a'; r <= 'z'; r++ {
+		dict.words[string(r)] = true
+	}
+	dict.MaxWordLength = 1
+	dict.buildTrie()
+	if dict.trie.dense == nil {
+		t.Fatal("Expected a dense child table at the root")
+	}
+	for r := 'a'; r <= 'z'; r++ {
+		if _, ok := dict.LookupByteRange(string(r), 0, 1); !ok {
+			t.Errorf("%q not found", r)
+		}
+	}
+	if _, ok := dict.LookupByteRange("A", 0, 1); ok {
+		t.Error("Found a word that was not added")
+	}
+}
+
+func TestSegmentInvalidUTF8(t *testing.T) {
+	// Each invalid byte is its own U+FFFD, as when decoding to runes
+	input := "ខ្ញុំ\xff\xfeទៅ"
+	segments := testSegmenter.Segment(input)
+	joined := ""
+	for _, seg := range segments {
+		if !utf8.ValidString(seg) {
+			t.Errorf("Segment %q is not valid UTF-8", seg)
+		}
+		joined += seg
+	}
+	if want := string([]rune(input)); joined != want {
+		t.Errorf("Segments join to %q, want %q", joined, want)
+	}
+}
+
+func TestMatchRunes(t *testing.T) {
+	dict := NewDictionary()
+	for _, word := range []string{"ក", "កខ", "កខគឃ", "ខ"} {
+		dict.words[word] = true
+	}
+	dict.MaxWordLength = 4
+	dict.buildTrie()
+
+	// Every word starting at the position, in increasing length, from one walk
+	runes := []rune("xកខគឃង")
+	var ends []int
+	dict.matchRunes(runes, 1, len(runes), func(j int, _ float32) { ends = append(ends, j) })
+	if want := []int{2, 3, 5}; !reflect.DeepEqual(ends, want) {
+		t.Errorf("Ends = %v, want %v", ends, want)
+	}
+	ln := newLine(string(runes))
+	ends = nil
+	dict.matchPrefixes(ln, 1, 4, func(j int, _ float32) { ends = append(ends, j) })
+	if want := []int{2, 3}; !reflect.DeepEqual(ends, want) {
+		t.Errorf("matchPrefixes ends = %v, want %v (limited to 4)", ends, want)
+	}
+}
+
+func TestLongestMatch(t *testing.T) {
+	dict := NewDictionary()
+	words := map[string]float32{"ក": 1, "កខ": 2, "កខគឃ": 3}
+	for word, cost := range words {
+		dict.words[word] = true
+		dict.wordCosts[word] = cost
+	}
+	dict.MaxWordLength = 4
+	dict.buildTrie()
+
+	runes := []rune("xកខគង")
+	if length, cost, ok := dict.LongestMatch(runes, 1); !ok || length != 2 || cost != 2 {
+		t.Errorf("LongestMatch = %d, %v, %v; want 2, 2, true", length, cost, ok)
+	}
+	for _, start := range []int{0, 3, len(runes), -1} {
+		if _, _, ok := dict.LongestMatch(runes, start); ok {
+			t.Errorf("LongestMatch(%d) found a word", start)
+		}
+	}
+}
+
+func TestDictionaryQuery(t *testing.T) {
+	dict := NewDictionary()
+	dict.addWordWithVariants("ក")
+	dict.addWordWithVariants("កខគ")
+	dict.wordCosts["ក"] = 2
+	dict.buildTrie()
+
+	if !dict.Has("ក") || dict.Has("ខ") {
+		t.Errorf("Has(ក) = %v, Has(ខ) = %v", dict.Has("ក"), dict.Has("ខ"))
+	}
+	if got := dict.Cost("ក"); got != 2 {
+		t.Errorf("Cost(ក) = %v, want 2", got)
+	}
+	if got := dict.Cost("កខគ"); got != dict.DefaultCost {
+		t.Errorf("Cost without a frequency = %v, want %v", got, dict.DefaultCost)
+	}
+	if got := dict.Cost("ខ"); got != dict.UnknownCost {
+		t.Errorf("Cost of an unknown word = %v, want %v", got, dict.UnknownCost)
+	}
+	if dict.Size() != 2 || dict.MaxLen() != 3 {
+		t.Errorf("Size = %d, MaxLen = %d; want 2, 3", dict.Size(), dict.MaxLen())
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dict := NewDictionary()
+	words := map[string]float32{"ខ": 1, "ក": 2, "កខ": 3, "ab": 4, "😀": 5}
+	for word, cost := range words {
+		dict.words[word] = true
+		dict.wordCosts[word] = cost
+	}
+	dict.buildTrie()
+
+	var got []string
+	dict.Walk(func(word string, cost float32) bool {
+		if cost != words[word] {
+			t.Errorf("Cost of %q = %v, want %v", word, cost, words[word])
+		}
+		got = append(got, word)
+		return true
+	})
+	if want := []string{"ab", "ក", "កខ", "ខ", "😀"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk order = %q, want %q", got, want)
+	}
+
+	n := 0
+	dict.Walk(func(string, float32) bool { n++; return n < 2 })
+	if n != 2 {
+		t.Errorf("Walk visited %d words after stopping at 2", n)
+	}
+}
+
+func TestDisableVariants(t *testing.T) {
+	dict := NewDictionary()
+	dict.DisableVariants = true
+	dict.addWordWithVariants("ស្តី")
+	if dict.Size() != 1 || dict.Stats().VariantWords != 0 {
+		t.Errorf("Size = %d, variants = %d; want only the word itself", dict.Size(), dict.Stats().VariantWords)
+	}
+	dict.DisableVariants = false
+	dict.addWordWithVariants("ស្តី")
+	if !dict.Has("ស្ឍី") {
+		t.Error("Expected the Coeng Da variant with variants enabled")
+	}
+}
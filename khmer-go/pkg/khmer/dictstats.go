@@ -0,0 +1,74 @@
+package khmer
+
+import "unsafe"
+
+// Approximate per-entry costs of Go maps: bucket slots sized for the key and
+// value plus a tophash byte, at the runtime's 6.5/8 average load
+const (
+	mapLoadFactor  = 6.5 / 8
+	mapHeaderBytes = 48
+)
+
+// DictionaryStats describes a loaded dictionary and the memory it holds
+type DictionaryStats struct {
+	Words int
+	// HeadWords came from the dictionary file; VariantWords were added
+	// only by variant expansion. A compiled dictionary does not record
+	// where words came from, so after LoadCompiled all are head words.
+	HeadWords    int
+	VariantWords int
+	// CostedWords have a cost from the frequency file
+	CostedWords   int
+	MaxWordLength int
+	TrieNodes     int
+	// TrieBytes, MapBytes and ApproxBytes (their sum) estimate the memory
+	// held by the trie and by the word and cost maps
+	TrieBytes   int64
+	MapBytes    int64
+	ApproxBytes int64
+}
+
+// Stats counts the dictionary's words and trie nodes and estimates its
+// memory use. The estimate covers the data structures, not allocator
+// overhead, so the Go heap after loading is somewhat larger.
+func (d *Dictionary) Stats() DictionaryStats {
+	st := DictionaryStats{
+		Words:         len(d.words),
+		VariantWords:  len(d.variants),
+		CostedWords:   len(d.wordCosts),
+		MaxWordLength: d.MaxWordLength,
+	}
+	st.HeadWords = st.Words - st.VariantWords
+
+	nodeSize := int64(unsafe.Sizeof(TrieNode{}))
+	childSize := int64(unsafe.Sizeof(&TrieNode{}))
+	if d.trie != nil {
+		d.trie.walkBytes(func(n *TrieNode) {
+			st.TrieNodes++
+			st.TrieBytes += nodeSize + int64(cap(n.labels)) + int64(cap(n.children))*childSize
+			if n.dense != nil {
+				st.TrieBytes += int64(unsafe.Sizeof(*n.dense))
+			}
+		})
+	}
+
+	var wordBytes, costBytes int64
+	for w := range d.words {
+		wordBytes += int64(len(w))
+	}
+	for w := range d.wordCosts {
+		costBytes += int64(len(w))
+	}
+	stringHeader := int64(unsafe.Sizeof(""))
+	st.MapBytes = 2*mapHeaderBytes +
+		mapBytes(len(d.words), stringHeader+int64(unsafe.Sizeof(true))) + wordBytes +
+		mapBytes(len(d.wordCosts), stringHeader+int64(unsafe.Sizeof(float32(0)))) + costBytes
+	st.ApproxBytes = st.TrieBytes + st.MapBytes
+	return st
+}
+
+// mapBytes estimates the bucket memory of a map with n entries of
+// entrySize bytes (key plus value)
+func mapBytes(n int, entrySize int64) int64 {
+	return int64(float64(int64(n)*(entrySize+1)) / mapLoadFactor)
+}
@@ -0,0 +1,37 @@
+package khmer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDictionaryStats(t *testing.T) {
+	dict := NewDictionary()
+	dict.addWordWithVariants("កខ")
+	dict.addWordWithVariants("កគ")
+	// Coeng Ta gains a coengDa variant
+	dict.addWordWithVariants("ស្តី")
+	variant := strings.ReplaceAll("ស្តី", coengTa, coengDa)
+	dict.wordCosts["កខ"] = 1
+	dict.buildTrie()
+
+	st := dict.Stats()
+	if st.Words != 4 || st.HeadWords != 3 || st.VariantWords != 1 || st.CostedWords != 1 {
+		t.Errorf("Got words %d (head %d, variants %d, costed %d), want 4 (3, 1, 1)",
+			st.Words, st.HeadWords, st.VariantWords, st.CostedWords)
+	}
+	// The root and three bytes per rune, less shared UTF-8 prefixes: ក, ខ
+	// and ស share E1 9E; ខ and គ, ត and ដ share E1 9E under their parents
+	if st.TrieNodes != 22 {
+		t.Errorf("Got %d trie nodes, want 22", st.TrieNodes)
+	}
+	if st.ApproxBytes != st.TrieBytes+st.MapBytes || st.TrieBytes <= 0 || st.MapBytes <= 0 {
+		t.Errorf("Inconsistent memory estimate: %+v", st)
+	}
+
+	// A variant listed later as a word of its own is a head word
+	dict.addWordWithVariants(variant)
+	if st := dict.Stats(); st.VariantWords != 0 {
+		t.Errorf("Got %d variants after adding the variant as a word, want 0", st.VariantWords)
+	}
+}
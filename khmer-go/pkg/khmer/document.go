@@ -0,0 +1,56 @@
+package khmer
+
+import "strings"
+
+// Paragraph is a run of consecutive non-blank lines of a document
+type Paragraph struct {
+	// Segments holds the segments of each line in order, with the line
+	// break between two lines ("\n" or "\r\n") as a segment of its own
+	Segments []string
+	// Start and End are the paragraph's byte offsets in the document, End
+	// exclusive and before the final line break
+	Start, End int
+}
+
+// SegmentDocument segments multi-line text line by line, keeping its
+// structure: lines are grouped into paragraphs at blank (empty or
+// whitespace-only) lines, and line breaks inside a paragraph are kept as
+// segments, so callers need not split the text themselves.
+func (s *KhmerSegmenter) SegmentDocument(text string) []Paragraph {
+	var paragraphs []Paragraph
+	var current *Paragraph
+	for pos := 0; pos < len(text); {
+		end, next := lineEnd(text, pos)
+		line := text[pos:end]
+		if strings.TrimSpace(strings.ReplaceAll(line, ZeroWidthSpace, "")) == "" {
+			current = nil
+			pos = next
+			continue
+		}
+		if current == nil {
+			paragraphs = append(paragraphs, Paragraph{Segments: []string{}, Start: pos})
+			current = &paragraphs[len(paragraphs)-1]
+		} else {
+			// The break after the previous line
+			current.Segments = append(current.Segments, text[current.End:pos])
+		}
+		current.Segments = append(current.Segments, s.Segment(line)...)
+		current.End = end
+		pos = next
+	}
+	return paragraphs
+}
+
+// lineEnd returns the end of the line starting at pos, before its line
+// break, and the start of the next line
+func lineEnd(text string, pos int) (end, next int) {
+	i := strings.IndexByte(text[pos:], '\n')
+	if i < 0 {
+		return len(text), len(text)
+	}
+	end, next = pos+i, pos+i+1
+	if end > pos && text[end-1] == '\r' {
+		end--
+	}
+	return end, next
+}
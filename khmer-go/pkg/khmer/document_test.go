@@ -0,0 +1,35 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegmentDocument(t *testing.T) {
+	doc := "ខ្ញុំទៅ\r\nសាលារៀន\n\n  \nសួស្តី\n"
+	paragraphs := testSegmenter.SegmentDocument(doc)
+	if len(paragraphs) != 2 {
+		t.Fatalf("Got %d paragraphs, want 2: %+v", len(paragraphs), paragraphs)
+	}
+
+	var want []string
+	want = append(want, testSegmenter.Segment("ខ្ញុំទៅ")...)
+	want = append(want, "\r\n")
+	want = append(want, testSegmenter.Segment("សាលារៀន")...)
+	if !reflect.DeepEqual(paragraphs[0].Segments, want) {
+		t.Errorf("First paragraph = %q, want %q", paragraphs[0].Segments, want)
+	}
+	if got := doc[paragraphs[0].Start:paragraphs[0].End]; got != "ខ្ញុំទៅ\r\nសាលារៀន" {
+		t.Errorf("First paragraph spans %q", got)
+	}
+	if got := doc[paragraphs[1].Start:paragraphs[1].End]; got != "សួស្តី" {
+		t.Errorf("Second paragraph spans %q", got)
+	}
+	if want := testSegmenter.Segment("សួស្តី"); !reflect.DeepEqual(paragraphs[1].Segments, want) {
+		t.Errorf("Second paragraph = %q, want %q", paragraphs[1].Segments, want)
+	}
+
+	if got := testSegmenter.SegmentDocument("\n \n"); len(got) != 0 {
+		t.Errorf("Blank document gave %+v", got)
+	}
+}
@@ -0,0 +1,149 @@
+package khmer
+
+import (
+	"fmt"
+	"math"
+)
+
+// EdgeKind is the rule of the Viterbi loop that proposed a token
+type EdgeKind uint8
+
+const (
+	EdgeDictionary EdgeKind = iota
+	EdgeNumber
+	EdgeSeparator
+	EdgeLunar
+	EdgeAcronym
+	EdgeRecognizer
+	EdgeGazetteer
+	EdgeMixed
+	EdgeFuzzy
+	EdgeAffix
+	// EdgeUnknown is an unknown Khmer cluster or a single other character
+	EdgeUnknown
+	// EdgeRepair consumes one character after a coeng or at a dependent
+	// vowel, at the Repair penalty
+	EdgeRepair
+)
+
+var edgeKindNames = [...]string{
+	EdgeDictionary: "dictionary",
+	EdgeNumber:     "number",
+	EdgeSeparator:  "separator",
+	EdgeLunar:      "lunar",
+	EdgeAcronym:    "acronym",
+	EdgeRecognizer: "recognizer",
+	EdgeGazetteer:  "gazetteer",
+	EdgeMixed:      "mixed",
+	EdgeFuzzy:      "fuzzy",
+	EdgeAffix:      "affix",
+	EdgeUnknown:    "unknown",
+	EdgeRepair:     "repair",
+}
+
+func (k EdgeKind) String() string {
+	if int(k) < len(edgeKindNames) {
+		return edgeKindNames[k]
+	}
+	return fmt.Sprintf("EdgeKind(%d)", int(k))
+}
+
+// DPEdge is a token the Viterbi loop considered from a position
+type DPEdge struct {
+	Kind EdgeKind
+	// End is the rune position after the token
+	End  int
+	Text string
+	// Cost is the token's step cost; PathCost the cost of the path to End
+	// through it
+	Cost, PathCost float32
+	// Won is set when this edge gives End its cheapest path
+	Won bool
+}
+
+// DPStep is one position of the Viterbi loop
+type DPStep struct {
+	Pos int
+	// Rune is the character at Pos
+	Rune rune
+	// Cost is the cheapest path cost to Pos (+Inf when unreachable), From
+	// and Kind the start and rule of its last token (From is -1 at 0)
+	Cost float32
+	From int
+	Kind EdgeKind
+	// OnPath is set for the positions the best path passes through
+	OnPath bool
+	Edges  []DPEdge
+}
+
+// DPTrace is the Viterbi loop over one line, for diagnosing a segmentation
+type DPTrace struct {
+	// Text is the line as segmented, with zero-width spaces removed;
+	// positions are rune offsets into it
+	Text  string
+	Steps []DPStep
+	// Path is the Viterbi segmentation, before post-processing, and Cost
+	// its total
+	Path []string
+	Cost float32
+}
+
+// DebugTrace runs the Viterbi loop over text recording, at each position,
+// every candidate token (dictionary word, digits, separator, repair,
+// unknown cluster, ...) with its cost, and which of them won. The path is
+// the one Segment finds before post-processing; Beam, Timeout and
+// ChunkRunes are not applied.
+func (s *KhmerSegmenter) DebugTrace(text string) DPTrace {
+	ln := newLine(s.normalize(text))
+	n := len(ln.runes)
+	tr := DPTrace{Text: ln.text, Steps: make([]DPStep, n+1)}
+
+	inf := float32(math.Inf(1))
+	count := make([]int, n+1)
+	// won[j] indexes the winning edge into j in Steps[From].Edges
+	won := make([]int, n+1)
+	for i := range tr.Steps {
+		tr.Steps[i] = DPStep{Pos: i, Cost: inf, From: -1}
+		if i < n {
+			tr.Steps[i].Rune = ln.runes[i]
+		}
+	}
+	tr.Steps[0].Cost = 0
+
+	fewest := s.TieBreak == TieBreakFewestSegments
+	for i := 0; i < n; i++ {
+		step := &tr.Steps[i]
+		if step.Cost == inf {
+			continue
+		}
+		s.edges(ln, i, func(j int, cost float32) {
+			c := step.Cost + cost
+			step.Edges = append(step.Edges, DPEdge{
+				Kind: s.edgeKind, End: j, Text: ln.text[ln.offsets[i]:ln.offsets[j]],
+				Cost: cost, PathCost: c,
+			})
+			to := &tr.Steps[j]
+			if c < to.Cost || (fewest && c == to.Cost && count[i]+1 < count[j]) {
+				to.Cost, to.From, to.Kind, count[j] = c, i, s.edgeKind, count[i]+1
+				won[j] = len(step.Edges) - 1
+			}
+		})
+	}
+
+	for j := 1; j <= n; j++ {
+		if from := tr.Steps[j].From; from >= 0 {
+			tr.Steps[from].Edges[won[j]].Won = true
+		}
+	}
+	tr.Cost = tr.Steps[n].Cost
+	tr.Steps[n].OnPath = true
+	for j := n; j > 0 && tr.Steps[j].From >= 0; j = tr.Steps[j].From {
+		from := &tr.Steps[tr.Steps[j].From]
+		from.OnPath = true
+		tr.Path = append(tr.Path, from.Edges[won[j]].Text)
+	}
+	for i, j := 0, len(tr.Path)-1; i < j; i, j = i+1, j-1 {
+		tr.Path[i], tr.Path[j] = tr.Path[j], tr.Path[i]
+	}
+	return tr
+}
@@ -0,0 +1,51 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDebugTrace(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.PostProcessors = nil
+	for _, input := range []string{"ខ្ញុំទៅសាលារៀន ១២៣", "ថ្ងៃនេះ", "\u200bក្ុ", ""} {
+		tr := seg.DebugTrace(input)
+		if got := seg.Segment(input); len(got)+len(tr.Path) > 0 && !reflect.DeepEqual(tr.Path, got) {
+			t.Errorf("DebugTrace(%q).Path = %q, want the Viterbi segments %q", input, tr.Path, got)
+		}
+		onPath := 0
+		for _, step := range tr.Steps {
+			won := 0
+			for _, e := range step.Edges {
+				if e.Won {
+					won++
+					if to := tr.Steps[e.End]; to.From != step.Pos || to.Cost != e.PathCost || to.Kind != e.Kind {
+						t.Errorf("%q: winning edge %+v does not match step %+v", input, e, to)
+					}
+				}
+			}
+			if step.OnPath && step.Pos < len(tr.Steps)-1 && won == 0 {
+				t.Errorf("%q: position %d is on the path with no winning edge", input, step.Pos)
+			}
+			if step.OnPath {
+				onPath++
+			}
+		}
+		if len(tr.Path) > 0 && onPath != len(tr.Path)+1 {
+			t.Errorf("%q: %d positions on the path, want %d", input, onPath, len(tr.Path)+1)
+		}
+	}
+
+	// Each rule names its edges
+	kinds := map[EdgeKind]bool{}
+	for _, step := range seg.DebugTrace("ខ្ញុំ ១២៣ ក្ុ").Steps {
+		for _, e := range step.Edges {
+			kinds[e.Kind] = true
+		}
+	}
+	for _, k := range []EdgeKind{EdgeDictionary, EdgeNumber, EdgeSeparator, EdgeUnknown, EdgeRepair} {
+		if !kinds[k] {
+			t.Errorf("no %s edge in the trace", k)
+		}
+	}
+}
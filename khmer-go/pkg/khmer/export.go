@@ -0,0 +1,78 @@
+package khmer
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Export writes the effective lexicon, the words the segmenter matches after
+// variant generation and filtering, one per line in code point order as
+// "word<TAB>cost<TAB>source". source is "variant" for a word present only as
+// a spelling variant of another, "default" for a word without a frequency
+// (costed at DefaultCost), or empty. A compiled dictionary does not record
+// which words are variants.
+func (d *Dictionary) Export(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	d.Walk(func(word string, cost float32) bool {
+		source := ""
+		if d.variants[word] {
+			source = "variant"
+		} else if _, ok := d.wordCosts[word]; !ok {
+			source = "default"
+		}
+		buf = append(buf[:0], word...)
+		buf = append(buf, '\t')
+		buf = strconv.AppendFloat(buf, float64(cost), 'f', -1, 32)
+		buf = append(buf, '\t')
+		buf = append(buf, source...)
+		buf = append(buf, '\n')
+		_, err := bw.Write(buf)
+		return err == nil
+	})
+	return bw.Flush()
+}
+
+// sentencePieceSpecials are the control pieces SentencePiece vocabularies
+// start with, scored 0
+var sentencePieceSpecials = []string{"<unk>", "<s>", "</s>"}
+
+// ExportSentencePiece writes the effective lexicon as a SentencePiece
+// vocabulary, "piece<TAB>score" per line, for bootstrapping a subword model
+// aligned with the segmenter's words. Scores are natural-log probabilities
+// derived from the costs (-cost·ln 10), best first, after the <unk>, <s> and
+// </s> control pieces. Words are written as they are, without the "▁" word
+// boundary mark, as Khmer text has no spaces between words; words containing
+// whitespace cannot be pieces and are left out.
+func (d *Dictionary) ExportSentencePiece(w io.Writer) error {
+	var pieces []completion
+	d.Walk(func(word string, cost float32) bool {
+		if strings.IndexFunc(word, unicode.IsSpace) < 0 {
+			pieces = append(pieces, completion{word: word, cost: cost})
+		}
+		return true
+	})
+	sort.SliceStable(pieces, func(i, j int) bool { return pieces[i].cost < pieces[j].cost })
+
+	bw := bufio.NewWriter(w)
+	for _, special := range sentencePieceSpecials {
+		bw.WriteString(special)
+		bw.WriteString("\t0\n")
+	}
+	var buf []byte
+	for _, p := range pieces {
+		buf = append(buf[:0], p.word...)
+		buf = append(buf, '\t')
+		buf = strconv.AppendFloat(buf, -float64(p.cost)*math.Ln10, 'f', 6, 32)
+		buf = append(buf, '\n')
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,51 @@
+package khmer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	dict := NewDictionary()
+	dict.addWordWithVariants("ក")
+	dict.addWordWithVariants("ស្តី") // adds its Coeng Da variant
+	dict.wordCosts["ក"] = 1.5
+	dict.buildTrie()
+
+	var sb strings.Builder
+	if err := dict.Export(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := "ក\t1.5\t\n" +
+		"ស\u17d2\u178dី\t10\tvariant\n" +
+		"ស្តី\t10\tdefault\n"
+	if sb.String() != want {
+		t.Errorf("Export = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestExportSentencePiece(t *testing.T) {
+	dict := NewDictionary()
+	for _, word := range []string{"ក", "ខ", "គ", "ឃ"} {
+		dict.addWordWithVariants(word)
+	}
+	dict.wordCosts["ខ"] = 1
+	dict.wordCosts["គ"] = 2
+	dict.wordCosts["ឃ"] = 2
+	dict.DefaultCost = 3
+	dict.buildTrie()
+
+	var sb strings.Builder
+	if err := dict.ExportSentencePiece(&sb); err != nil {
+		t.Fatal(err)
+	}
+	// Best first; ties keep code point order
+	want := "<unk>\t0\n<s>\t0\n</s>\t0\n" +
+		"ខ\t-2.302585\n" +
+		"គ\t-4.605170\n" +
+		"ឃ\t-4.605170\n" +
+		"ក\t-6.907755\n"
+	if sb.String() != want {
+		t.Errorf("ExportSentencePiece = %q, want %q", sb.String(), want)
+	}
+}
@@ -0,0 +1,69 @@
+package khmer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// hasKhmerOrInvalid reports whether text needs the Viterbi loop: it contains
+// a Khmer codepoint, or invalid UTF-8 that must become U+FFFD. ASCII bytes are
+// skipped without decoding.
+func hasKhmerOrInvalid(text string) bool {
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if IsKhmerChar(r) || (r == utf8.RuneError && size == 1) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// fastPathApplies reports whether the segmenter's options leave nothing for
+// the Viterbi loop to find in a non-Khmer line beyond what splitNonKhmer does
+func (s *KhmerSegmenter) fastPathApplies() bool {
+	return s.NonKhmerFastPath && len(s.Recognizers) == 0 && len(s.Gazetteers) == 0 &&
+		s.MixedScript == MixedScriptPerRune && !s.GroupCurrency
+}
+
+// splitNonKhmer tokenizes a line without Khmer text: every whitespace and
+// separator rune is a segment of its own, and the runs between them are one
+// segment each. A ',' or '.' between two digits stays in its number ("1,000",
+// "3.5"), as number grouping keeps it in the Viterbi loop.
+func splitNonKhmer(text string) []string {
+	segments := make([]string, 0, len(text)/4+1)
+	start := 0
+	prev := rune(-1)
+	for i, r := range text {
+		if !isSplitRune(r) {
+			prev = r
+			continue
+		}
+		if (r == ',' || r == '.') && IsDigit(prev) {
+			if next, _ := utf8.DecodeRuneInString(text[i+1:]); IsDigit(next) {
+				prev = r
+				continue
+			}
+		}
+		if start < i {
+			segments = append(segments, text[start:i])
+		}
+		end := i + utf8.RuneLen(r)
+		segments = append(segments, text[i:end])
+		start = end
+		prev = r
+	}
+	if start < len(text) {
+		segments = append(segments, text[start:])
+	}
+	return segments
+}
+
+// isSplitRune reports whether splitNonKhmer cuts at r
+func isSplitRune(r rune) bool {
+	return unicode.IsSpace(r) || IsSeparator(r) || unicode.IsPunct(r)
+}
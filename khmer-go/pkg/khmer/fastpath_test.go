@@ -0,0 +1,47 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNonKhmerFastPath(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.NonKhmerFastPath = true
+
+	cases := map[string][]string{
+		"Hello, world!":         {"Hello", ",", " ", "world", "!"},
+		"It costs 1,000.50":     {"It", " ", "costs", " ", "1,000.50"},
+		"zero\u200bwidth space": {"zerowidth", " ", "space"},
+		"end.":                  {"end", "."},
+	}
+	for input, want := range cases {
+		if got := seg.Segment(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("Segment(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	// Lines with Khmer text or invalid UTF-8 still take the Viterbi loop
+	for _, input := range []string{"ខ្ញុំទៅសាលារៀន", "Hello ខ្ញុំ", "bad \xff byte"} {
+		want := testSegmenter.Segment(input)
+		if got := seg.Segment(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("Segment(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestNonKhmerFastPathOptions(t *testing.T) {
+	rec, err := NewRegexRecognizer("EMAIL", `[a-z]+@[a-z]+\.[a-z]+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.NonKhmerFastPath = true
+	seg.Recognizers = []Recognizer{rec}
+
+	// A recognizer could match in a non-Khmer line, so the fast path is off
+	got := seg.Segment("mail me@example.com")
+	if got[len(got)-1] != "me@example.com" {
+		t.Errorf("got %q, want the recognized email as the last segment", got)
+	}
+}
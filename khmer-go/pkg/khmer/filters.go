@@ -0,0 +1,103 @@
+package khmer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Names of the token filter passes
+const (
+	PassLowercase    = "lowercase"
+	PassStripPunct   = "strip-punct"
+	PassArabicDigits = "arabic-digits"
+	PassTrim         = "trim"
+)
+
+// Token filters are post-processors for common cleanup of the output. They
+// compose: append any of them, in the order they should run, to a pipeline.
+var (
+	// LowercaseFilter lowercases Latin letters, leaving other scripts alone
+	LowercaseFilter = tokenFilter(PassLowercase, func(seg string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.In(r, unicode.Latin) {
+				return unicode.ToLower(r)
+			}
+			return r
+		}, seg)
+	})
+	// StripPunctFilter removes segments made only of Unicode punctuation
+	// (។, ?, « », ...); whitespace and currency symbols are kept
+	StripPunctFilter = tokenFilter(PassStripPunct, func(seg string) string {
+		for _, r := range seg {
+			if !unicode.IsPunct(r) {
+				return seg
+			}
+		}
+		return ""
+	})
+	// ArabicDigitsFilter writes Khmer digits ០-៩ as ASCII 0-9
+	ArabicDigitsFilter = tokenFilter(PassArabicDigits, func(seg string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= 0x17E0 && r <= 0x17E9 {
+				return '0' + r - 0x17E0
+			}
+			return r
+		}, seg)
+	})
+	// TrimFilter removes whitespace and zero-width spaces around each
+	// segment, and the segments that are only whitespace
+	TrimFilter = tokenFilter(PassTrim, func(seg string) string {
+		return strings.TrimFunc(seg, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '\u200b'
+		})
+	})
+)
+
+var tokenFilters = map[string]PostProcessor{
+	PassLowercase:    LowercaseFilter,
+	PassStripPunct:   StripPunctFilter,
+	PassArabicDigits: ArabicDigitsFilter,
+	PassTrim:         TrimFilter,
+}
+
+// TokenFilterByName looks up a token filter by its pass name
+func TokenFilterByName(name string) (PostProcessor, error) {
+	if f, ok := tokenFilters[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	names := make([]string, 0, len(tokenFilters))
+	for n := range tokenFilters {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown token filter %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// TokenFilters looks up token filters by name, keeping their order
+func TokenFilters(names ...string) (Pipeline, error) {
+	filters := make(Pipeline, 0, len(names))
+	for _, name := range names {
+		f, err := TokenFilterByName(name)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// tokenFilter makes a pass that rewrites each segment with fn, dropping the
+// segments fn returns "" for
+func tokenFilter(name string, fn func(seg string) string) PostProcessorFunc {
+	return PostProcessorFunc{PassName: name, Fn: func(segments []string, _ *Dictionary) []string {
+		out := make([]string, 0, len(segments))
+		for _, seg := range segments {
+			if seg = fn(seg); seg != "" {
+				out = append(out, seg)
+			}
+		}
+		return out
+	}}
+}
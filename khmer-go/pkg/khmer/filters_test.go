@@ -0,0 +1,43 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenFilters(t *testing.T) {
+	segments := []string{"WiFi", " ", "តម្លៃ", " ", "១២៥", "$", "។", " «", "ម្រា ប់ ", "ABC-Ï"}
+	cases := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{PassLowercase}, []string{"wifi", " ", "តម្លៃ", " ", "១២៥", "$", "។", " «", "ម្រា ប់ ", "abc-ï"}},
+		{[]string{PassStripPunct}, []string{"WiFi", " ", "តម្លៃ", " ", "១២៥", "$", " «", "ម្រា ប់ ", "ABC-Ï"}},
+		{[]string{PassArabicDigits}, []string{"WiFi", " ", "តម្លៃ", " ", "125", "$", "។", " «", "ម្រា ប់ ", "ABC-Ï"}},
+		{[]string{PassTrim}, []string{"WiFi", "តម្លៃ", "១២៥", "$", "។", "«", "ម្រា ប់", "ABC-Ï"}},
+		// Filters compose in order: trimming first lets strip-punct see "«"
+		{[]string{PassTrim, PassStripPunct, PassArabicDigits, PassLowercase}, []string{"wifi", "តម្លៃ", "125", "$", "ម្រា ប់", "abc-ï"}},
+	}
+	for _, tc := range cases {
+		filters, err := TokenFilters(tc.names...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filters.Run(segments, nil); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: got %q, want %q", tc.names, got, tc.want)
+		}
+	}
+	if _, err := TokenFilterByName("stem"); err == nil {
+		t.Error("Expected an error for an unknown filter")
+	}
+}
+
+func TestTokenFiltersInPipeline(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.PostProcessors = seg.PostProcessors.Append(TrimFilter, StripPunctFilter, ArabicDigitsFilter)
+	got := seg.Segment("តម្លៃ ១០០ ដុល្លារ។")
+	want := []string{"តម្លៃ", "100", "ដុល្លារ"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
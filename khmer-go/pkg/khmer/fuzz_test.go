@@ -0,0 +1,42 @@
+package khmer
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// checkSegmentation verifies the invariants Segment guarantees for any input:
+// no empty segments, and the segments join to the input with zero-width
+// spaces removed (invalid UTF-8 bytes each become U+FFFD)
+func checkSegmentation(t *testing.T, input string, segments []string) {
+	t.Helper()
+	for i, seg := range segments {
+		if seg == "" {
+			t.Fatalf("segment %d of %q is empty: %q", i, input, segments)
+		}
+		if !utf8.ValidString(seg) {
+			t.Fatalf("segment %d of %q is not valid UTF-8: %q", i, input, seg)
+		}
+	}
+	want := string([]rune(strings.ReplaceAll(input, ZeroWidthSpace, "")))
+	if got := strings.Join(segments, ""); got != want {
+		t.Fatalf("segments of %q join to %q, want %q", input, got, want)
+	}
+}
+
+func FuzzSegment(f *testing.F) {
+	for _, tc := range testCases {
+		f.Add(tc.Input)
+	}
+	for _, seed := range []string{
+		"", " ", "\u200b", "\u200b\u200b", "្", "ា", "ក្", "៛", "$", "1,000.50", "ក.ខ.",
+		"\xff", "ក\xffខ", "\xe1\x9e", "abc ១២៣ xyz", "ៗ", "ៗៗ",
+	} {
+		f.Add(seed)
+	}
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	f.Fuzz(func(t *testing.T, input string) {
+		checkSegmentation(t, input, seg.Segment(input))
+	})
+}
@@ -0,0 +1,53 @@
+package khmer
+
+// DefaultFuzzyPenalty is the extra path cost of a near-miss dictionary match.
+// It is about half the unknown-word cost, so a misspelled word beats falling
+// apart into unknown clusters but rarely beats an exact segmentation.
+const DefaultFuzzyPenalty = float32(5.0)
+
+// fuzzyLookup calls visit for every dictionary word that matches
+// runes[start:end] (end <= limit) with exactly one edit to a combining mark:
+// an extra, missing or substituted vowel sign/diacritic/coeng, or two adjacent
+// marks typed in the wrong order. Exact matches are not reported; a word may
+// be reported more than once for the same end.
+func (d *Dictionary) fuzzyLookup(runes []rune, start, limit int, visit func(end int, cost float32)) {
+	var walk func(node *TrieNode, pos int, edited bool)
+	walk = func(node *TrieNode, pos int, edited bool) {
+		if edited && node.isWord && pos > start {
+			visit(pos, node.cost)
+		}
+		if pos < limit {
+			if child := node.getChild(runes[pos]); child != nil {
+				walk(child, pos+1, edited)
+			}
+		}
+		if edited {
+			return
+		}
+
+		// Extra mark in the input
+		if pos < limit && pos > start && isMark(runes[pos]) {
+			walk(node, pos+1, true)
+		}
+		node.eachChild(func(m rune, child *TrieNode) {
+			if !isMark(m) {
+				return
+			}
+			// Mark missing from the input
+			walk(child, pos, true)
+			// Wrong vowel sign or diacritic
+			if pos < limit && m != runes[pos] && substituteCost(m, runes[pos]) == markEditCost {
+				walk(child, pos+1, true)
+			}
+		})
+		// Adjacent marks swapped
+		if pos+1 < limit && isMark(runes[pos]) && isMark(runes[pos+1]) && runes[pos] != runes[pos+1] {
+			if c1 := node.getChild(runes[pos+1]); c1 != nil {
+				if c2 := c1.getChild(runes[pos]); c2 != nil {
+					walk(c2, pos+2, true)
+				}
+			}
+		}
+	}
+	walk(d.trie, start, false)
+}
@@ -0,0 +1,51 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatchesNearMissWords(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.FuzzyPenalty = DefaultFuzzyPenalty
+
+	cases := map[string][]string{
+		"ខ្ញុំស្រលាញ់កម្ពជា": {"ខ្ញុំ", "ស្រលាញ់", "កម្ពជា"}, // missing vowel ុ
+		"ភាសាខ្មរ":           {"ភាសាខ្មរ"},                   // missing vowel ែ
+	}
+	for input, want := range cases {
+		if got := seg.Segment(input); !reflect.DeepEqual(got, want) {
+			t.Errorf("Segment(%q) = %v, want %v", input, got, want)
+		}
+		if got := testSegmenter.Segment(input); reflect.DeepEqual(got, want) {
+			t.Errorf("Segment(%q) without fuzzy matching should not merge the typo", input)
+		}
+	}
+}
+
+func TestFuzzyLookupEdits(t *testing.T) {
+	dict := testSegmenter.Dictionary
+	found := func(input string) bool {
+		runes := []rune(input)
+		ok := false
+		dict.fuzzyLookup(runes, 0, len(runes), func(end int, _ float32) {
+			if end == len(runes) {
+				ok = true
+			}
+		})
+		return ok
+	}
+	for _, input := range []string{
+		"កម្ពជា",   // missing mark
+		"កម្ពុុជា", // extra mark
+		"កម្ពូជា",  // substituted vowel
+		"ខ្ញំុ",    // swapped marks
+	} {
+		if !found(input) {
+			t.Errorf("Expected a fuzzy match spanning %q", input)
+		}
+	}
+	if found("កម្ពុខា") {
+		t.Error("Consonant substitutions should not match")
+	}
+}
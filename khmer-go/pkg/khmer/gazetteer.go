@@ -0,0 +1,131 @@
+package khmer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultGazetteerCost is the path cost of a gazetteer entry: cheaper than
+// most pairs of dictionary words, dearer than any single frequent word
+const DefaultGazetteerCost = float32(2.0)
+
+// Gazetteer is a list of named entities (people, places, organizations)
+// matched during the Viterbi loop (see KhmerSegmenter.Gazetteers). Entries live in
+// their own trie, so they never become dictionary words; segments that are
+// entries are tagged with the gazetteer's Type (see TagEntities).
+type Gazetteer struct {
+	// Type is the entity type reported for matches, e.g. "PERSON"
+	Type TokenType
+	// Cost is the path cost of entries without their own cost
+	Cost float32
+
+	entries map[string]float32
+	trie    *TrieNode
+	// maxLen is the length in runes of the longest entry
+	maxLen int
+}
+
+// NewGazetteer returns a gazetteer of the given entity type at DefaultGazetteerCost
+func NewGazetteer(entityType TokenType, entries []string) *Gazetteer {
+	g := &Gazetteer{Type: entityType, Cost: DefaultGazetteerCost, entries: make(map[string]float32), trie: &TrieNode{}}
+	for _, e := range entries {
+		g.Add(e, 0)
+	}
+	return g
+}
+
+// LoadGazetteer reads a gazetteer file: one entry per line, optionally
+// followed by a tab and the entry's cost. Blank lines and lines starting
+// with '#' are skipped.
+func LoadGazetteer(entityType TokenType, path string) (*Gazetteer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("gazetteer not found at %s: %w", path, err)
+	}
+	defer file.Close()
+
+	g := NewGazetteer(entityType, nil)
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry, costField, hasCost := strings.Cut(line, "\t")
+		var cost float64
+		if hasCost {
+			if cost, err = strconv.ParseFloat(strings.TrimSpace(costField), 32); err != nil || cost <= 0 {
+				return nil, fmt.Errorf("%s:%d: invalid cost %q", path, lineNo, costField)
+			}
+		}
+		g.Add(strings.TrimSpace(entry), float32(cost))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Add inserts an entry; a cost of 0 means the gazetteer's Cost. Zero-width
+// spaces are removed, as Segment removes them from its input.
+func (g *Gazetteer) Add(entry string, cost float32) {
+	entry = strings.ReplaceAll(entry, ZeroWidthSpace, "")
+	if entry == "" {
+		return
+	}
+	g.entries[entry] = cost
+	node, length := g.trie, 0
+	for _, r := range entry {
+		node = node.getOrCreateChild(r)
+		length++
+	}
+	if length > g.maxLen {
+		g.maxLen = length
+	}
+	node.isWord = true
+	node.cost = cost
+}
+
+// Len returns the number of entries
+func (g *Gazetteer) Len() int { return len(g.entries) }
+
+// Contains reports whether seg is an entry
+func (g *Gazetteer) Contains(seg string) bool {
+	_, ok := g.entries[seg]
+	return ok
+}
+
+// lookup calls visit for every entry starting at runes[start]
+func (g *Gazetteer) lookup(runes []rune, start int, visit func(end int, cost float32)) {
+	node := g.trie
+	for i := start; i < len(runes); i++ {
+		if node = node.getChild(runes[i]); node == nil {
+			return
+		}
+		if node.isWord {
+			cost := node.cost
+			if cost == 0 {
+				cost = g.Cost
+			}
+			visit(i+1, cost)
+		}
+	}
+}
+
+// TagEntities replaces the type of every segment that is a gazetteer entry
+// with that gazetteer's Type; earlier gazetteers win
+func TagEntities(types []TokenType, segments []string, gazetteers []*Gazetteer) {
+	for i, seg := range segments {
+		for _, g := range gazetteers {
+			if g.Contains(seg) {
+				types[i] = g.Type
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package khmer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGazetteer(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.Gazetteers = []*Gazetteer{
+		NewGazetteer("PERSON", []string{"សុខចាន់"}),
+		NewGazetteer("PLACE", []string{"ភ្នំពេញ"}),
+	}
+
+	tokens := seg.Tokenize("លោកសុខចាន់ទៅភ្នំពេញ")
+	want := []Token{
+		{"លោក", TokenKhmerWord},
+		{"សុខចាន់", "PERSON"},
+		{"ទៅ", TokenKhmerWord},
+		{"ភ្នំពេញ", "PLACE"},
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("Got %v, want %v", tokens, want)
+	}
+	if seg.Dictionary.Has("សុខចាន់") {
+		t.Error("Gazetteer entry leaked into the dictionary")
+	}
+}
+
+func TestLoadGazetteer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orgs.txt")
+	content := "# organizations\nអង្គការសហប្រជាជាតិ\t1.5\n\nក្រសួងអប់រំ\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGazetteer("ORG", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Len() != 2 || !g.Contains("ក្រសួងអប់រំ") {
+		t.Errorf("Loaded %d entries", g.Len())
+	}
+
+	var costs []float32
+	runes := []rune("អង្គការសហប្រជាជាតិ")
+	g.lookup(runes, 0, func(end int, cost float32) {
+		if end == len(runes) {
+			costs = append(costs, cost)
+		}
+	})
+	if !reflect.DeepEqual(costs, []float32{1.5}) {
+		t.Errorf("Got costs %v, want [1.5]", costs)
+	}
+
+	if err := os.WriteFile(path, []byte("ភ្នំពេញ\tcheap\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadGazetteer("PLACE", path); err == nil {
+		t.Error("Expected an error for an invalid cost")
+	}
+}
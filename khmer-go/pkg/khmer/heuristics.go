@@ -16,7 +16,7 @@ func ApplyHeuristics(segments []string, dictionary *Dictionary) []string {
 		curr := segments[i]
 
 		// If known word, don't merge
-		if dictionary.Contains(curr) {
+		if dictionary.Has(curr) {
 			merged = append(merged, curr)
 			i++
 			continue
@@ -81,12 +81,14 @@ func PostProcessUnknowns(segments []string, dictionary *Dictionary) []string {
 			runes := []rune(seg)
 			firstChar := runes[0]
 
-			if IsDigit(firstChar) {
+			if IsDigit(firstChar) || (IsCurrencySymbol(firstChar) && len(runes) > 1 && IsDigit(runes[1])) {
 				isKnown = true
-			} else if dictionary.Contains(seg) {
+			} else if dictionary.Has(seg) {
 				isKnown = true
 			} else if len(runes) == 1 && IsValidSingleWord(firstChar) {
 				isKnown = true
+			} else if len(runes) == 1 && IsLunarSymbol(firstChar) {
+				isKnown = true
 			} else if len(runes) == 1 && IsSeparator(firstChar) {
 				// Only single-char separators break the merge chain
 				// Multi-char segments starting with space (e.g., " ប់") should merge with unknowns
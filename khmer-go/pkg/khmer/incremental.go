@@ -0,0 +1,258 @@
+package khmer
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Incremental segments text that grows at its end, such as the text being
+// typed into an input method. It keeps the lattice of tokens the Viterbi
+// loop can take from each position and the path costs up to them, so Append
+// only finds the tokens near the end of the text again and recomputes the
+// tail of the DP instead of segmenting all of it. The post-processing passes
+// still run over the whole text, as they may look at any of its segments.
+//
+// The segments equal Segment's for the whole text with NonKhmerFastPath off,
+// except with ChunkRunes set (Segment then splits long text into chunks
+// segmented separately), Beam (Incremental does not prune) or a Rescorer
+// (not applied). An Incremental is not safe for concurrent use.
+type Incremental struct {
+	seg *KhmerSegmenter
+	ln  line
+	// text holds the text so far; ln.text is a view of it, so appending does
+	// not copy what came before
+	text strings.Builder
+	// pending holds the bytes of a rune split across Append calls
+	pending  string
+	lattice  [][]latticeEdge
+	segments []string
+	// path is the last Viterbi path before post-processing and ends[k] the
+	// position after path[k], so an unchanged start of the path is reused
+	path []string
+	ends []int
+
+	// dpCost, dpParent and dpSegments hold the DP of every position so far
+	dpCost     []float32
+	dpParent   []int
+	dpSegments []int
+	// relaxed counts the edges the last Append relaxed
+	relaxed int
+}
+
+// latticeEdge is a token from its start position to position to
+type latticeEdge struct {
+	to   int
+	cost float32
+}
+
+// NewIncremental returns an empty Incremental with s's dictionary and
+// options. s must not be changed while the Incremental is in use.
+func (s *KhmerSegmenter) NewIncremental() *Incremental {
+	inc := &Incremental{seg: s}
+	inc.Reset()
+	return inc
+}
+
+// Reset empties the text
+func (inc *Incremental) Reset() {
+	inc.ln = line{offsets: []int32{0}}
+	inc.text.Reset()
+	inc.pending = ""
+	inc.lattice = inc.lattice[:0]
+	inc.segments = []string{}
+	inc.path, inc.ends = nil, inc.ends[:0]
+	inc.dpCost, inc.dpParent, inc.dpSegments = inc.dpCost[:0], inc.dpParent[:0], inc.dpSegments[:0]
+}
+
+// Text returns the text so far, without zero-width spaces and with the
+// LegacyChars policy applied
+func (inc *Incremental) Text() string { return inc.ln.text }
+
+// Segments returns the segments of the text so far
+func (inc *Incremental) Segments() []string { return inc.segments }
+
+// Append adds text at the end and returns the segments of the whole text,
+// with the index of the first segment that differs from the previous call's
+// (len(segments) when none does), so an input method only redraws
+// segments[changed:]. A rune whose UTF-8 bytes are split across calls waits
+// for the rest of its bytes.
+func (inc *Incremental) Append(text string) (segments []string, changed int) {
+	chunk := inc.pending + text
+	complete := len(chunk) - incompleteSuffix(chunk)
+	inc.pending = chunk[complete:]
+	chunk = inc.seg.normalize(chunk[:complete])
+	if chunk == "" {
+		return inc.segments, len(inc.segments)
+	}
+
+	oldN := len(inc.ln.runes)
+	added := newLine(chunk)
+	base := inc.text.Len()
+	inc.text.WriteString(added.text)
+	inc.ln.text = inc.text.String()
+	inc.ln.runes = append(inc.ln.runes, added.runes...)
+	inc.ln.offsets = inc.ln.offsets[:len(inc.ln.offsets)-1]
+	for _, off := range added.offsets {
+		inc.ln.offsets = append(inc.ln.offsets, int32(base)+off)
+	}
+
+	// Tokens from positions before the restart cannot change
+	n := len(inc.ln.runes)
+	restart := inc.restart(oldN)
+	inc.lattice = inc.lattice[:restart]
+	for i := len(inc.lattice); i < n; i++ {
+		var edges []latticeEdge
+		inc.seg.edges(inc.ln, i, func(j int, cost float32) {
+			edges = append(edges, latticeEdge{to: j, cost: cost})
+		})
+		inc.lattice = append(inc.lattice, edges)
+	}
+
+	segments = inc.seg.postProcess(inc.viterbi(restart))
+	changed = 0
+	for changed < len(segments) && changed < len(inc.segments) && segments[changed] == inc.segments[changed] {
+		changed++
+	}
+	inc.segments = segments
+	return segments, changed
+}
+
+// viterbi finds the cheapest path through the lattice exactly as Segment
+// does and returns its tokens. The DP up to restart is kept: a position's
+// cost only depends on the edges from before it, and those from before
+// restart have not changed. The positions after restart are computed again,
+// relaxing in Segment's order first the kept edges that cross restart, then
+// those from restart on.
+func (inc *Incremental) viterbi(restart int) []string {
+	n := len(inc.ln.runes)
+	inf := float32(math.Inf(1))
+	if len(inc.dpCost) == 0 {
+		inc.dpCost, inc.dpParent, inc.dpSegments = append(inc.dpCost, 0), append(inc.dpParent, -1), append(inc.dpSegments, 0)
+	}
+	inc.dpCost, inc.dpParent, inc.dpSegments = inc.dpCost[:restart+1], inc.dpParent[:restart+1], inc.dpSegments[:restart+1]
+	for j := restart + 1; j <= n; j++ {
+		inc.dpCost, inc.dpParent, inc.dpSegments = append(inc.dpCost, inf), append(inc.dpParent, -1), append(inc.dpSegments, 0)
+	}
+	dpCost, dpParent, dpSegments := inc.dpCost, inc.dpParent, inc.dpSegments
+
+	fewest := inc.seg.TieBreak == TieBreakFewestSegments
+	inc.relaxed = 0
+	relax := func(i int, e latticeEdge) {
+		inc.relaxed++
+		newCost := dpCost[i] + e.cost
+		if newCost < dpCost[e.to] || (fewest && newCost == dpCost[e.to] && dpSegments[i]+1 < dpSegments[e.to]) {
+			dpCost[e.to] = newCost
+			dpParent[e.to] = i
+			dpSegments[e.to] = dpSegments[i] + 1
+		}
+	}
+	// Only tokens of at most reach runes cross restart (see restart)
+	for i := max(restart-inc.reach(), 0); i < restart; i++ {
+		if dpCost[i] == inf {
+			continue
+		}
+		for _, e := range inc.lattice[i] {
+			if e.to > restart {
+				relax(i, e)
+			}
+		}
+	}
+	for i := restart; i < n; i++ {
+		if dpCost[i] == inf {
+			continue
+		}
+		for _, e := range inc.lattice[i] {
+			relax(i, e)
+		}
+	}
+
+	// Backtrack to a position up to restart on the last path: the path to
+	// it is final, so its start is the last path's
+	var tail []int
+	k, curr := 0, n
+	for curr > 0 {
+		if c := dpSegments[curr]; curr <= restart && c > 0 && c <= len(inc.ends) && inc.ends[c-1] == curr {
+			k = c
+			break
+		}
+		prev := dpParent[curr]
+		if prev == -1 {
+			break
+		}
+		tail = append(tail, curr)
+		curr = prev
+	}
+	// A new slice, as callers may hold the last one
+	path := make([]string, k, k+len(tail))
+	copy(path, inc.path[:k])
+	inc.ends = inc.ends[:k]
+	for t := len(tail) - 1; t >= 0; t-- {
+		end := tail[t]
+		path = append(path, inc.ln.text[inc.ln.offsets[curr]:inc.ln.offsets[end]])
+		inc.ends = append(inc.ends, end)
+		curr = end
+	}
+	inc.path = path
+	return path
+}
+
+// restart returns the first position whose tokens may change when text is
+// appended after position n. Tokens from earlier positions end before n and
+// were found without reading past it: dictionary words, gazetteer entries
+// and affixed forms are at most reach runes long, and rule-based runs cannot
+// cross a position where runContinues is false. Recognizers may match
+// anything, so with any set every position is redone.
+func (inc *Incremental) restart(n int) int {
+	if len(inc.seg.Recognizers) > 0 {
+		return 0
+	}
+	// One more for the rules that look a rune ahead
+	p := n - inc.reach() - 1
+	for p > 0 && runContinues(inc.ln.runes[p-1], inc.ln.runes[p]) {
+		p--
+	}
+	return max(p, 0)
+}
+
+// reach is the longest token the dictionary, affixes and gazetteers allow
+func (inc *Incremental) reach() int {
+	s := inc.seg
+	reach := s.Dictionary.MaxWordLength
+	if s.Affixes != nil {
+		reach += s.Affixes.maxLen()
+	}
+	for _, g := range s.Gazetteers {
+		reach = max(reach, g.maxLen)
+	}
+	return reach
+}
+
+// runContinues reports whether a rule-based run could hold a and the rune b
+// after it: a number (digits, with ',', '.' or ' ' between, and currency
+// symbols), a Khmer cluster, an acronym or a non-Khmer run
+func runContinues(a, b rune) bool {
+	numeric := func(r rune) bool {
+		return IsDigit(r) || IsCurrencySymbol(r) || r == ',' || r == '.' || r == ' '
+	}
+	mixed := func(r rune) bool {
+		return isMixedLetter(r) || isMixedDigit(r) || mixedJoiner(r)
+	}
+	return numeric(a) && numeric(b) || mixed(a) && mixed(b) ||
+		a == '.' || b == '.' ||
+		IsCoeng(a) || IsCoeng(b) || IsDependentVowel(b) || IsSign(b)
+}
+
+// incompleteSuffix returns the length of an incomplete UTF-8 sequence at the
+// end of s that more bytes could complete
+func incompleteSuffix(s string) int {
+	for k := 1; k < utf8.UTFMax && k <= len(s); k++ {
+		if utf8.RuneStart(s[len(s)-k]) {
+			if utf8.FullRuneInString(s[len(s)-k:]) {
+				return 0
+			}
+			return k
+		}
+	}
+	return 0
+}
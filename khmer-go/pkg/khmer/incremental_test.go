@@ -0,0 +1,124 @@
+package khmer
+
+import (
+	"bufio"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// typeInto appends text to inc in random chunks, checking every step
+// against Segment on the text so far
+func typeInto(t *testing.T, seg *KhmerSegmenter, inc *Incremental, text string, rng *rand.Rand) {
+	t.Helper()
+	runes := []rune(text)
+	typed := inc.Text()
+	for i := 0; i < len(runes); {
+		k := min(len(runes), i+1+rng.Intn(3))
+		chunk := string(runes[i:k])
+		i = k
+		prev := append([]string(nil), inc.Segments()...)
+		got, changed := inc.Append(chunk)
+		typed += chunk
+		want := seg.Segment(typed)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("After %q: got %q, want %q", typed, got, want)
+		}
+		if changed > len(got) || (changed < len(got) && changed < len(prev) && got[changed] == prev[changed]) {
+			t.Fatalf("After %q: changed = %d, previous %q", typed, changed, prev)
+		}
+		for c := 0; c < changed; c++ {
+			if got[c] != prev[c] {
+				t.Fatalf("After %q: segment %d changed before %d", typed, c, changed)
+			}
+		}
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	inputs := []string{"ខ្ញុំទៅសាលារៀន", "តម្លៃ ១,០០០ ៛ និង $5.50", "ស.ភ.ភ. Covid-19 WiFi6E", "ក្រុមហ៊ុនអាយធី"}
+	for _, tc := range testCases {
+		inputs = append(inputs, tc.Input)
+	}
+	file, err := os.Open(filepath.Join(testDataDir, "test_subset.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	// Corpus lines, cut short as every step segments the prefix again
+	for n := 0; n < 3 && scanner.Scan(); n++ {
+		line := []rune(scanner.Text())
+		inputs = append(inputs, string(line[:min(len(line), 300)]))
+	}
+
+	plain := NewKhmerSegmenter(testSegmenter.Dictionary)
+	options := NewKhmerSegmenter(testSegmenter.Dictionary)
+	options.Affixes = DefaultAffixes()
+	options.FuzzyPenalty = DefaultFuzzyPenalty
+	options.GroupCurrency = true
+	options.MixedScript = MixedScriptKeep
+	options.TieBreak = TieBreakFewestSegments
+	options.Gazetteers = []*Gazetteer{NewGazetteer("PLACE", []string{"ភ្នំពេញ", "សៀមរាប"})}
+
+	for _, seg := range []*KhmerSegmenter{plain, options} {
+		inc := seg.NewIncremental()
+		for _, input := range inputs {
+			inc.Reset()
+			typeInto(t, seg, inc, input, rng)
+		}
+	}
+}
+
+func TestIncrementalRestart(t *testing.T) {
+	// Only the tokens near the end are found again
+	inc := testSegmenter.NewIncremental()
+	text := ""
+	for i := 0; i < 20; i++ {
+		text += "ខ្ញុំទៅសាលារៀន"
+	}
+	inc.Append(text)
+	n := len(inc.ln.runes)
+	if p := inc.restart(n); p <= 0 || n-p > 2*testSegmenter.Dictionary.MaxWordLength {
+		t.Errorf("restart(%d) = %d", n, p)
+	}
+}
+
+func TestIncrementalSplitRune(t *testing.T) {
+	// The bytes of ខ arrive in two calls
+	inc := testSegmenter.NewIncremental()
+	inc.Append("ក\xe1\x9e")
+	if got := inc.Text(); got != "ក" {
+		t.Errorf("Text = %q, want the incomplete rune held back", got)
+	}
+	got, _ := inc.Append("\x81\u200b")
+	if want := testSegmenter.Segment("កខ"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIncrementalWorkPerAppend(t *testing.T) {
+	// Appending a rune relaxes about as many edges on a long text as on a
+	// short one: only the tail of the DP is recomputed
+	relaxed := func(repeat int) int {
+		inc := testSegmenter.NewIncremental()
+		text := ""
+		for i := 0; i < repeat; i++ {
+			text += "ខ្ញុំទៅសាលារៀន "
+		}
+		inc.Append(text)
+		got, _ := inc.Append("ក")
+		if want := testSegmenter.Segment(text + "ក"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+		return inc.relaxed
+	}
+	short, long := relaxed(10), relaxed(500)
+	if long > short {
+		t.Errorf("Append relaxed %d edges on a long text, %d on a short one", long, short)
+	}
+}
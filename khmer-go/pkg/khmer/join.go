@@ -0,0 +1,39 @@
+package khmer
+
+import "strings"
+
+// ZeroWidthSpace is the invisible word separator used in Khmer typesetting
+const ZeroWidthSpace = "\u200b"
+
+// Join reconstructs text from segments; it is the inverse of Segment.
+// Segment keeps spaces and punctuation as their own segments, so plain
+// concatenation restores the input (minus any zero-width spaces Segment stripped).
+func Join(segments []string) string {
+	return strings.Join(segments, "")
+}
+
+// JoinWithZWSP reconstructs text and inserts a zero-width space between adjacent
+// word segments, marking word boundaries without changing the visible text.
+// No ZWSP is inserted next to whitespace or punctuation.
+func JoinWithZWSP(segments []string) string {
+	var sb strings.Builder
+	size := 0
+	for _, seg := range segments {
+		size += len(seg) + len(ZeroWidthSpace)
+	}
+	sb.Grow(size)
+
+	prevIsWord := false
+	for _, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		isWord := !isSeparatorSegment(seg)
+		if prevIsWord && isWord {
+			sb.WriteString(ZeroWidthSpace)
+		}
+		sb.WriteString(seg)
+		prevIsWord = isWord
+	}
+	return sb.String()
+}
@@ -0,0 +1,26 @@
+package khmer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJoinRoundTrip(t *testing.T) {
+	for _, tc := range testCases {
+		expected := strings.ReplaceAll(tc.Input, ZeroWidthSpace, "")
+		if result := Join(testSegmenter.Segment(tc.Input)); result != expected {
+			t.Errorf("[%d] Expected %q, got %q", tc.ID, expected, result)
+		}
+	}
+}
+
+func TestJoinWithZWSP(t *testing.T) {
+	result := JoinWithZWSP([]string{"ខ្ញុំ", "ស្រលាញ់", " ", "កម្ពុជា", "។"})
+	expected := "ខ្ញុំ\u200bស្រលាញ់ កម្ពុជា។"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+	if Join(testSegmenter.Segment(result)) != "ខ្ញុំស្រលាញ់ កម្ពុជា។" {
+		t.Errorf("ZWSP text did not round trip: %q", result)
+	}
+}
@@ -0,0 +1,102 @@
+package khmer
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// DictionaryLoader loads a dictionary in the background, in two stages.
+// The first holds every word and cost but has only the words with a
+// frequency in its trie, which covers running text well and builds several
+// times faster; it is published as soon as it is ready. The complete
+// dictionary then replaces it. Each stage is a separate, read-only
+// Dictionary, so segmenters using the first stage are never affected by the
+// second being built.
+type DictionaryLoader struct {
+	current atomic.Pointer[Dictionary]
+	ready   chan struct{}
+	done    chan struct{}
+	err     error
+}
+
+// LoadInBackground starts loading dictPath and freqPath (or a compiled
+// dictionary, which loads in one stage) and returns at once. logger, which
+// may be nil, receives load progress.
+func LoadInBackground(dictPath, freqPath string, logger *slog.Logger) *DictionaryLoader {
+	d := NewDictionary()
+	d.Logger = logger
+	return d.LoadInBackground(dictPath, freqPath)
+}
+
+// LoadInBackground is like the LoadInBackground function, loading into d,
+// a new dictionary whose options (Logger, DisableVariants, ...) are set. d
+// must not be used otherwise; the loader's Dictionary returns it when done.
+func (d *Dictionary) LoadInBackground(dictPath, freqPath string) *DictionaryLoader {
+	l := &DictionaryLoader{ready: make(chan struct{}), done: make(chan struct{})}
+	go l.load(d, dictPath, freqPath)
+	return l
+}
+
+// Ready is closed once Dictionary returns a usable dictionary, or when
+// loading fails
+func (l *DictionaryLoader) Ready() <-chan struct{} { return l.ready }
+
+// Done is closed once the complete dictionary is loaded, or when loading fails
+func (l *DictionaryLoader) Done() <-chan struct{} { return l.done }
+
+// Dictionary returns the latest stage loaded: nil before Ready, the
+// frequent-word stage until Done, then the complete dictionary
+func (l *DictionaryLoader) Dictionary() *Dictionary { return l.current.Load() }
+
+// Err returns the loading error, if any; it is only set once Done is closed
+func (l *DictionaryLoader) Err() error {
+	select {
+	case <-l.done:
+		return l.err
+	default:
+		return nil
+	}
+}
+
+func (l *DictionaryLoader) load(d *Dictionary, dictPath, freqPath string) {
+	start, readyClosed := time.Now(), false
+	defer func() {
+		if !readyClosed {
+			close(l.ready)
+		}
+		close(l.done)
+	}()
+
+	if IsCompiledDictionary(dictPath) {
+		if l.err = d.LoadCompiled(dictPath); l.err == nil {
+			l.current.Store(d)
+		}
+		return
+	}
+	if l.err = d.loadDictionary(dictPath); l.err != nil {
+		return
+	}
+	if l.err = d.loadFrequencies(freqPath); l.err != nil {
+		return
+	}
+
+	// From here on the maps are only read, so both stages share them
+	quick := *d
+	quick.trie = &TrieNode{}
+	frequent := 0
+	for word := range d.words {
+		if cost, ok := d.wordCosts[word]; ok {
+			quick.insertIntoTrie(word, cost)
+			frequent++
+		}
+	}
+	l.current.Store(&quick)
+	close(l.ready)
+	readyClosed = true
+	d.logger().Info("Frequent words ready", "words", frequent, "elapsed", time.Since(start).Round(time.Millisecond))
+
+	d.buildTrie()
+	l.current.Store(d)
+	d.logger().Info("Dictionary complete", "words", len(d.words), "elapsed", time.Since(start).Round(time.Millisecond))
+}
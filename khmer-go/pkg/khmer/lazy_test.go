@@ -0,0 +1,48 @@
+package khmer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadInBackground(t *testing.T) {
+	loader := LoadInBackground(filepath.Join(testDataDir, "khmer_dictionary_words.txt"),
+		filepath.Join(testDataDir, "khmer_word_frequencies.json"), nil)
+
+	<-loader.Ready()
+	quick := loader.Dictionary()
+	if quick == nil {
+		t.Fatalf("No dictionary at Ready: %v", loader.Err())
+	}
+	seg := NewKhmerSegmenter(quick)
+	// Frequent words are in the first stage
+	if got, want := seg.Segment("ខ្ញុំទៅសាលារៀន"), []string{"ខ្ញុំ", "ទៅ", "សាលារៀន"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("First stage: got %v, want %v", got, want)
+	}
+
+	<-loader.Done()
+	if err := loader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	full := loader.Dictionary()
+	if full.Stats().TrieNodes <= quick.Stats().TrieNodes {
+		t.Error("Complete dictionary has no more trie nodes than the first stage")
+	}
+	// The complete stage segments exactly as a synchronous load
+	seg.Dictionary = full
+	for _, tc := range testCases {
+		if got := seg.Segment(tc.Input); !reflect.DeepEqual(got, testSegmenter.Segment(tc.Input)) {
+			t.Errorf("%s: background load segments differently: %v", tc.Input, got)
+		}
+	}
+}
+
+func TestLoadInBackgroundError(t *testing.T) {
+	loader := LoadInBackground(filepath.Join(t.TempDir(), "missing.txt"), "", nil)
+	<-loader.Ready()
+	<-loader.Done()
+	if loader.Err() == nil || loader.Dictionary() != nil {
+		t.Errorf("Got err %v, dictionary %v; want an error and no dictionary", loader.Err(), loader.Dictionary())
+	}
+}
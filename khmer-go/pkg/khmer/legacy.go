@@ -0,0 +1,103 @@
+package khmer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Deprecated and invisible Khmer characters found in legacy documents. The
+// deprecated independent vowels have the same appearance as the sequences
+// replacing them; the inherent vowels U+17B4 and U+17B5 render as nothing.
+const (
+	DeprecatedQaq  = '\u17A3' // ឣ, written អ
+	DeprecatedQaa  = '\u17A4' // ឤ, written អា
+	InvisibleAq    = '\u17B4'
+	InvisibleAa    = '\u17B5'
+	replacementQaq = "\u17A2"
+	replacementQaa = "\u17A2\u17B6"
+)
+
+// LegacyChars is the policy for deprecated and invisible characters, which
+// otherwise keep dictionary words containing them from matching
+type LegacyChars int
+
+const (
+	// LegacyKeep segments the text as it is
+	LegacyKeep LegacyChars = iota
+	// LegacyNormalize writes ឣ as អ and ឤ as អា, and removes the invisible
+	// vowels, before segmenting; segments hold the normalized text
+	LegacyNormalize
+	// LegacyStripInvisible only removes the invisible vowels
+	LegacyStripInvisible
+)
+
+var legacyCharsNames = map[LegacyChars]string{
+	LegacyKeep:           "keep",
+	LegacyNormalize:      "normalize",
+	LegacyStripInvisible: "strip-invisible",
+}
+
+func (l LegacyChars) String() string {
+	if name, ok := legacyCharsNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LegacyChars(%d)", int(l))
+}
+
+// LegacyCharsByName looks up a policy by its String name
+func LegacyCharsByName(name string) (LegacyChars, error) {
+	for l, n := range legacyCharsNames {
+		if strings.EqualFold(name, n) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown legacy-chars policy %q (available: keep, normalize, strip-invisible)", name)
+}
+
+var (
+	legacyNormalizer = strings.NewReplacer(
+		string(DeprecatedQaq), replacementQaq,
+		string(DeprecatedQaa), replacementQaa,
+		string(InvisibleAq), "",
+		string(InvisibleAa), "")
+	invisibleStripper = strings.NewReplacer(string(InvisibleAq), "", string(InvisibleAa), "")
+)
+
+// IsInvisibleVowel reports whether r is one of the inherent vowels U+17B4
+// and U+17B5, which render as nothing
+func IsInvisibleVowel(r rune) bool {
+	return r == InvisibleAq || r == InvisibleAa
+}
+
+// isLegacyChar reports whether r is deprecated or invisible
+func isLegacyChar(r rune) bool {
+	return r == DeprecatedQaq || r == DeprecatedQaa || IsInvisibleVowel(r)
+}
+
+// apply rewrites text under the policy
+func (l LegacyChars) apply(text string) string {
+	if l == LegacyKeep || strings.IndexFunc(text, isLegacyChar) < 0 {
+		return text
+	}
+	if l == LegacyNormalize {
+		return legacyNormalizer.Replace(text)
+	}
+	return invisibleStripper.Replace(text)
+}
+
+// legacyForm returns what LegacyNormalize writes for a deprecated character
+func legacyForm(r rune) (string, bool) {
+	switch r {
+	case DeprecatedQaq:
+		return replacementQaq, true
+	case DeprecatedQaa:
+		return replacementQaa, true
+	}
+	return "", false
+}
+
+// normalize removes zero-width spaces from text and applies the LegacyChars
+// policy, producing the text the segments are cut from
+func (s *KhmerSegmenter) normalize(text string) string {
+	return s.LegacyChars.apply(strings.ReplaceAll(text, ZeroWidthSpace, ""))
+}
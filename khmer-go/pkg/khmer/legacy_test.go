@@ -0,0 +1,52 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLegacyChars(t *testing.T) {
+	// ឤហារ is អាហារ spelled with the deprecated ឤ, then an invisible vowel
+	input := "ឤហារ\u17b4ឆ្ងាញ់"
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.LegacyChars = LegacyNormalize
+	want := testSegmenter.Segment("អាហារឆ្ងាញ់")
+	if got := seg.Segment(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize: got %q, want %q", got, want)
+	}
+
+	seg.LegacyChars = LegacyStripInvisible
+	if got := seg.Segment(input); !reflect.DeepEqual(got, testSegmenter.Segment("ឤហារឆ្ងាញ់")) {
+		t.Errorf("Strip invisible: got %q", got)
+	}
+	if got := testSegmenter.Segment(input); reflect.DeepEqual(got, want) {
+		t.Errorf("Keep: expected the legacy text to segment differently, got %q", got)
+	}
+}
+
+func TestLegacyCharsSpans(t *testing.T) {
+	// Spans index the original text
+	input := "ឤហារ\u17b4ឆ្ងាញ់"
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.LegacyChars = LegacyNormalize
+	spans := seg.SegmentSpans(input)
+	if len(spans) == 0 || spans[0].Start != 0 || spans[len(spans)-1].End != len(input) {
+		t.Fatalf("Spans %+v do not cover %q", spans, input)
+	}
+	for i := 1; i < len(spans); i++ {
+		if spans[i].Start < spans[i-1].End {
+			t.Errorf("Spans overlap: %+v", spans)
+		}
+	}
+}
+
+func TestLegacyCharsByName(t *testing.T) {
+	for l, name := range legacyCharsNames {
+		if got, err := LegacyCharsByName(name); err != nil || got != l {
+			t.Errorf("LegacyCharsByName(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := LegacyCharsByName("nope"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
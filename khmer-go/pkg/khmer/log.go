@@ -0,0 +1,25 @@
+package khmer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardHandler drops every record; it is the default so that library users
+// get no output unless they inject a logger
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+var discardLogger = slog.New(discardHandler{})
+
+// logger returns the injected logger, or one that discards everything
+func (d *Dictionary) logger() *slog.Logger {
+	if d.Logger != nil {
+		return d.Logger
+	}
+	return discardLogger
+}
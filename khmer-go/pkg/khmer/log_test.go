@@ -0,0 +1,22 @@
+package khmer
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDictionaryLoggerInjection(t *testing.T) {
+	var buf bytes.Buffer
+	dict := NewDictionary()
+	dict.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+	if err := dict.Load(filepath.Join(testDataDir, "khmer_dictionary_words.txt"), "missing.json"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Loaded dictionary") || !strings.Contains(out, "Frequency file not found") {
+		t.Errorf("Expected load messages in injected logger, got %q", out)
+	}
+}
@@ -0,0 +1,50 @@
+package khmer
+
+// lossless rewrites the segments of the normalized text as the original text
+// they came from, so that they join back into text exactly. Zero-width spaces
+// and characters removed by the LegacyChars policy join the segment before
+// them (the first segment, at the start of text); any other text no segment
+// covers, such as a filtered stopword, becomes a segment of its own.
+func (s *KhmerSegmenter) lossless(text string, segments []string) []string {
+	normalized, offsets := s.Normalize(text)
+	out := make([]string, 0, len(segments)+1)
+	pos := 0
+	// gap adds text[pos:end], which no segment covers, and reports whether
+	// it was left for the next segment to take
+	gap := func(end int) bool {
+		switch {
+		case end == pos:
+		case !isRemovedOnly(text[pos:end]):
+			out = append(out, text[pos:end])
+		case len(out) > 0:
+			out[len(out)-1] += text[pos:end]
+		default:
+			return true
+		}
+		pos = end
+		return false
+	}
+	alignSegments(normalized, segments, func(_ int, sp Span) {
+		start, end := offsets.Span(sp.Start, sp.End)
+		if gap(start) {
+			start = pos
+		}
+		out = append(out, text[start:end])
+		pos = end
+	})
+	if gap(len(text)) {
+		out = append(out, text[pos:])
+	}
+	return out
+}
+
+// isRemovedOnly reports whether text holds only characters normalization
+// removes: zero-width spaces and the invisible vowels
+func isRemovedOnly(text string) bool {
+	for _, r := range text {
+		if r != '\u200b' && !IsInvisibleVowel(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package khmer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLossless(t *testing.T) {
+	inputs := []string{
+		"",
+		"\u200b\u200b",
+		"\u200bខ្ញុំ\u200bទៅ\u200b\u200bសាលា\u200b",
+		"តម្លៃ\t១,០០០ ៛ និង $5.50\xff",
+		"\u17a4រ\u17b4 \u17a3",
+	}
+	for _, tc := range testCases {
+		inputs = append(inputs, tc.Input)
+	}
+	file, err := os.Open(filepath.Join(testDataDir, "test_subset.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		inputs = append(inputs, scanner.Text())
+	}
+
+	plain := NewKhmerSegmenter(testSegmenter.Dictionary)
+	plain.Lossless = true
+	// Passes and policies that drop or rewrite text
+	filtered := NewKhmerSegmenter(testSegmenter.Dictionary)
+	filtered.Lossless = true
+	filtered.LegacyChars = LegacyNormalize
+	filtered.PostProcessors = filtered.PostProcessors.Append(NewStopwordFilter(DefaultStopwords), WhitespaceCollapse)
+
+	for _, seg := range []*KhmerSegmenter{plain, filtered} {
+		for _, input := range inputs {
+			segments := seg.Segment(input)
+			if got := strings.Join(segments, ""); got != input {
+				t.Fatalf("Segments %q join to %q, want %q", segments, got, input)
+			}
+			for _, s := range segments {
+				if s == "" {
+					t.Fatalf("Empty segment in %q", segments)
+				}
+			}
+		}
+	}
+}
+
+func TestLosslessKeepsSegments(t *testing.T) {
+	// Zero-width spaces join the segment before them; the segmentation is
+	// otherwise unchanged
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.Lossless = true
+	got := seg.Segment("\u200bខ្ញុំ\u200bទៅ\u200bសាលា")
+	want := []string{"\u200bខ្ញុំ\u200b", "ទៅ\u200b", "សាលា"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if spans := seg.SegmentSpans("ខ្ញុំ\u200bទៅ"); len(spans) != 2 || spans[1].Start != len("ខ្ញុំ\u200b") {
+		t.Errorf("Got spans %+v", spans)
+	}
+}
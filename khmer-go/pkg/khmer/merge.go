@@ -0,0 +1,65 @@
+package khmer
+
+import "math"
+
+// Merge combines other into d by interpolating word probabilities: each word
+// gets probability (1-weight)·p_d + weight·p_other, where a dictionary that
+// lacks the word contributes its floor probability (that of DefaultCost).
+// Costs are the usual -log10 of the result, so weight 0 keeps d's costs,
+// 1 takes other's and 0.5 averages the two sources rather than letting
+// either overwrite the other. DefaultCost is interpolated the same way and
+// UnknownCost stays 5 above it. weight is clamped to [0, 1].
+//
+// The word lists are joined, a word keeping an explicit cost if either
+// dictionary has one, and the trie is rebuilt; other is not modified.
+func (d *Dictionary) Merge(other *Dictionary, weight float32) {
+	w := math.Min(math.Max(float64(weight), 0), 1)
+	floorD, floorO := costProb(d.DefaultCost), costProb(other.DefaultCost)
+	prob := func(dict *Dictionary, word string, floor float64) float64 {
+		if cost, ok := dict.wordCosts[word]; ok {
+			return costProb(cost)
+		}
+		return floor
+	}
+
+	costs := make(map[string]float32, len(d.wordCosts)+len(other.wordCosts))
+	merge := func(word string) {
+		if _, done := costs[word]; done {
+			return
+		}
+		p := (1-w)*prob(d, word, floorD) + w*prob(other, word, floorO)
+		costs[word] = float32(-math.Log10(p))
+	}
+	for word := range d.wordCosts {
+		merge(word)
+	}
+	for word := range other.wordCosts {
+		merge(word)
+	}
+
+	for word := range other.words {
+		if !d.words[word] {
+			d.words[word] = true
+			if other.variants[word] {
+				d.variants[word] = true
+			}
+		} else if !other.variants[word] {
+			// A head word on either side is a head word
+			delete(d.variants, word)
+		}
+	}
+	if other.MaxWordLength > d.MaxWordLength {
+		d.MaxWordLength = other.MaxWordLength
+	}
+	d.wordCosts = costs
+	d.DefaultCost = float32(-math.Log10((1-w)*floorD + w*floorO))
+	d.UnknownCost = d.DefaultCost + 5.0
+
+	d.trie = &TrieNode{}
+	d.buildTrie()
+}
+
+// costProb is the probability a cost stands for
+func costProb(cost float32) float64 {
+	return math.Pow(10, -float64(cost))
+}
@@ -0,0 +1,66 @@
+package khmer
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func mergeDict(costs map[string]float32, defaultCost float32) *Dictionary {
+	dict := NewDictionary()
+	for word, cost := range costs {
+		dict.addWordWithVariants(word)
+		dict.wordCosts[word] = cost
+		if n := len([]rune(word)); n > dict.MaxWordLength {
+			dict.MaxWordLength = n
+		}
+	}
+	dict.DefaultCost = defaultCost
+	dict.UnknownCost = defaultCost + 5
+	dict.buildTrie()
+	return dict
+}
+
+func TestMerge(t *testing.T) {
+	near := func(got, want float32) bool { return math.Abs(float64(got-want)) < 1e-4 }
+
+	// Weight 0 keeps the costs of words d already has
+	d := mergeDict(map[string]float32{"ក": 1, "ខ": 2}, 4)
+	d.Merge(mergeDict(map[string]float32{"ខ": 3, "គ": 1}, 6), 0)
+	if !near(d.Cost("ក"), 1) || !near(d.Cost("ខ"), 2) || !near(d.DefaultCost, 4) {
+		t.Errorf("Weight 0 changed costs: ក %v, ខ %v, default %v", d.Cost("ក"), d.Cost("ខ"), d.DefaultCost)
+	}
+	// ...and words only in other get d's floor
+	if !d.words["គ"] || !near(d.Cost("គ"), 4) {
+		t.Errorf("Weight 0: គ listed %v at %v, want true at 4", d.words["គ"], d.Cost("គ"))
+	}
+
+	// Weight 0.5 averages the probabilities
+	d = mergeDict(map[string]float32{"ក": 1, "ខ": 2}, 4)
+	d.Merge(mergeDict(map[string]float32{"ខ": 3, "គ": 1}, 6), 0.5)
+	wantB := float32(-math.Log10(0.5*0.01 + 0.5*0.001))
+	wantC := float32(-math.Log10(0.5*1e-4 + 0.5*0.1))
+	if !near(d.Cost("ខ"), wantB) || !near(d.Cost("គ"), wantC) {
+		t.Errorf("Weight 0.5: ខ %v, គ %v, want %v, %v", d.Cost("ខ"), d.Cost("គ"), wantB, wantC)
+	}
+	if !near(d.UnknownCost, d.DefaultCost+5) {
+		t.Errorf("UnknownCost %v, want DefaultCost+5 (%v)", d.UnknownCost, d.DefaultCost+5)
+	}
+	// The rebuilt trie carries the merged costs
+	if cost, ok := d.LookupRuneRange([]rune("គ"), 0, 1); !ok || !near(cost, wantC) {
+		t.Errorf("Trie lookup of គ: %v %v, want true %v", ok, cost, wantC)
+	}
+}
+
+func TestMergeVariants(t *testing.T) {
+	d := mergeDict(map[string]float32{"ស្តី": 1}, 4)
+	variant := strings.ReplaceAll("ស្តី", coengTa, coengDa)
+	if !d.variants[variant] {
+		t.Fatalf("%q should be a variant", variant)
+	}
+	// Listed as a head word in other, the variant becomes a head word
+	d.Merge(mergeDict(map[string]float32{variant: 1}, 4), 0.5)
+	if d.variants[variant] {
+		t.Errorf("%q is still a variant after merging it as a head word", variant)
+	}
+}
@@ -0,0 +1,143 @@
+package khmer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MixedScript is the policy for non-Khmer runs such as "Covid-19", "5G" or
+// "iPhone15" inside Khmer text
+type MixedScript int
+
+const (
+	// MixedScriptPerRune leaves non-Khmer letters as single-rune unknowns that
+	// merge-unknowns joins with their unknown neighbours, as the reference
+	// implementations do
+	MixedScriptPerRune MixedScript = iota
+	// MixedScriptKeep keeps letters and digits joined by '-', '.' or '_'
+	// as one segment ("Covid-19", "5G", "WiFi6E")
+	MixedScriptKeep
+	// MixedScriptSplitScript splits letters from digits and punctuation
+	// ("Covid" "-" "19", "5" "G")
+	MixedScriptSplitScript
+	// MixedScriptSplitPunct keeps letters and digits together but splits at
+	// punctuation ("Covid" "-" "19", "5G")
+	MixedScriptSplitPunct
+)
+
+var mixedScriptNames = map[MixedScript]string{
+	MixedScriptPerRune:     "per-rune",
+	MixedScriptKeep:        "keep",
+	MixedScriptSplitScript: "split-script",
+	MixedScriptSplitPunct:  "split-punct",
+}
+
+func (m MixedScript) String() string {
+	if name, ok := mixedScriptNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("MixedScript(%d)", int(m))
+}
+
+// MixedScriptByName looks up a policy by its String name
+func MixedScriptByName(name string) (MixedScript, error) {
+	for m, n := range mixedScriptNames {
+		if strings.EqualFold(name, n) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mixed-script policy %q (available: per-rune, keep, split-script, split-punct)", name)
+}
+
+// mixedRunCost is the path cost of a non-Khmer run (same as number grouping)
+const mixedRunCost = float32(1.0)
+
+// isMixedLetter reports whether r is a letter outside the Khmer block
+func isMixedLetter(r rune) bool {
+	return unicode.IsLetter(r) && !IsKhmerChar(r)
+}
+
+// isMixedDigit reports whether r is a digit outside the Khmer block
+func isMixedDigit(r rune) bool {
+	return unicode.IsDigit(r) && !IsKhmerChar(r)
+}
+
+// mixedJoiner reports whether r joins two parts of a kept run
+func mixedJoiner(r rune) bool {
+	return r == '-' || r == '.' || r == '_'
+}
+
+// mixedRunLength returns the length in runes of the non-Khmer run starting
+// at runes[i] under policy m. Runs without a letter are left to the number
+// rules, so the result is 0 for them and for per-rune.
+func (m MixedScript) mixedRunLength(runes []rune, i, n int) int {
+	if m == MixedScriptPerRune || !(isMixedLetter(runes[i]) || isMixedDigit(runes[i])) {
+		return 0
+	}
+	j, hasLetter := i, false
+	for j < n {
+		r := runes[j]
+		switch {
+		case isMixedLetter(r):
+			if m == MixedScriptSplitScript && j > i && !isMixedLetter(runes[i]) {
+				return runLength(i, j, hasLetter)
+			}
+			hasLetter = true
+		case isMixedDigit(r):
+			if m == MixedScriptSplitScript && j > i && !isMixedDigit(runes[i]) {
+				return runLength(i, j, hasLetter)
+			}
+		case m == MixedScriptKeep && mixedJoiner(r) && j > i && j+1 < n &&
+			(isMixedLetter(runes[j+1]) || isMixedDigit(runes[j+1])):
+		default:
+			return runLength(i, j, hasLetter)
+		}
+		j++
+	}
+	return runLength(i, j, hasLetter)
+}
+
+// runLength is j-i for runs containing a letter, 0 otherwise
+func runLength(i, j int, hasLetter bool) int {
+	if !hasLetter {
+		return 0
+	}
+	return j - i
+}
+
+// isMixedRun reports whether seg is a run of non-Khmer letters and digits,
+// as the mixed-script policies produce
+func isMixedRun(seg string) bool {
+	hasLetter := false
+	for _, r := range seg {
+		switch {
+		case isMixedLetter(r):
+			hasLetter = true
+		case isMixedDigit(r), mixedJoiner(r):
+		default:
+			return false
+		}
+	}
+	return hasLetter
+}
+
+// postProcess runs the segmenter's pipeline. Under a policy other than
+// MixedScriptPerRune, non-Khmer runs are final: the pipeline runs on the
+// segments between them, so no pass snaps or merges them into Khmer text.
+func (s *KhmerSegmenter) postProcess(segments []string) []string {
+	if s.MixedScript == MixedScriptPerRune {
+		return s.PostProcessors.Run(segments, s.Dictionary)
+	}
+	out := make([]string, 0, len(segments))
+	start := 0
+	for k, seg := range segments {
+		if isMixedRun(seg) {
+			// Capped so a pass appending to its input cannot overwrite seg
+			out = append(out, s.PostProcessors.Run(segments[start:k:k], s.Dictionary)...)
+			out = append(out, seg)
+			start = k + 1
+		}
+	}
+	return append(out, s.PostProcessors.Run(segments[start:], s.Dictionary)...)
+}
@@ -0,0 +1,53 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMixedScript(t *testing.T) {
+	cases := []struct {
+		input string
+		want  map[MixedScript][]string
+	}{
+		{"ជំងឺCovid-19បានរាលដាល", map[MixedScript][]string{
+			MixedScriptPerRune:     {"ជំងឺCovid", "-", "19", "បាន", "រាលដាល"},
+			MixedScriptKeep:        {"ជំងឺ", "Covid-19", "បាន", "រាលដាល"},
+			MixedScriptSplitScript: {"ជំងឺ", "Covid", "-", "19", "បាន", "រាលដាល"},
+			MixedScriptSplitPunct:  {"ជំងឺ", "Covid", "-", "19", "បាន", "រាលដាល"},
+		}},
+		{"បណ្ដាញ 5G ថ្មី", map[MixedScript][]string{
+			MixedScriptPerRune:     {"បណ្ដាញ", " ", "5G", " ", "ថ្មី"},
+			MixedScriptKeep:        {"បណ្ដាញ", " ", "5G", " ", "ថ្មី"},
+			MixedScriptSplitScript: {"បណ្ដាញ", " ", "5", "G", " ", "ថ្មី"},
+			MixedScriptSplitPunct:  {"បណ្ដាញ", " ", "5G", " ", "ថ្មី"},
+		}},
+		{"ប្រើiPhone15ដើម្បីថត", map[MixedScript][]string{
+			MixedScriptPerRune:     {"ប្រើiPhone", "15", "ដើម្បី", "ថត"},
+			MixedScriptKeep:        {"ប្រើ", "iPhone15", "ដើម្បី", "ថត"},
+			MixedScriptSplitScript: {"ប្រើ", "iPhone", "15", "ដើម្បី", "ថត"},
+			MixedScriptSplitPunct:  {"ប្រើ", "iPhone15", "ដើម្បី", "ថត"},
+		}},
+	}
+
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	for _, tc := range cases {
+		for policy, want := range tc.want {
+			seg.MixedScript = policy
+			if got := seg.Segment(tc.input); !reflect.DeepEqual(got, want) {
+				t.Errorf("%v %q: got %q, want %q", policy, tc.input, got, want)
+			}
+		}
+	}
+}
+
+func TestMixedScriptByName(t *testing.T) {
+	for policy := range mixedScriptNames {
+		if got, err := MixedScriptByName(policy.String()); err != nil || got != policy {
+			t.Errorf("MixedScriptByName(%q) = %v, %v", policy.String(), got, err)
+		}
+	}
+	if _, err := MixedScriptByName("whole"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
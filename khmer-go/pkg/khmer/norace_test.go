@@ -0,0 +1,5 @@
+//go:build !race
+
+package khmer
+
+const raceEnabled = false
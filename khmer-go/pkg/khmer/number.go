@@ -0,0 +1,94 @@
+package khmer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseNumber returns the value of a number token written with ASCII or
+// Khmer digits (០-៩). Spaces, commas and dots may group thousands in
+// three-digit groups, as number tokens do. The decimal point is:
+//   - the later of '.' and ',' when both appear ("1,234.5", "1.234,5")
+//   - a lone '.' or ',' unless exactly three digits follow it and at most
+//     three precede it ("12,5" and "3.14" are decimals, "4.000" and
+//     "1,500" are thousands, as in Khmer prices)
+//
+// A leading '-' or '+' is accepted.
+func ParseNumber(token string) (float64, error) {
+	var sb strings.Builder
+	sb.Grow(len(token))
+	body := token
+	if strings.HasPrefix(body, "-") || strings.HasPrefix(body, "+") {
+		sb.WriteByte(body[0])
+		body = body[1:]
+	}
+
+	decimal := decimalMark(body)
+	// group counts the digits since the last grouping mark (-1 before the first)
+	digits, group, afterMark := 0, -1, false
+	for _, r := range body {
+		switch {
+		case IsDigit(r):
+			if r >= 0x17E0 {
+				r = '0' + r - 0x17E0
+			}
+			sb.WriteRune(r)
+			digits++
+			if group >= 0 && !afterMark {
+				group++
+			}
+		case r == decimal && !afterMark:
+			if digits == 0 || (group >= 0 && group != 3) {
+				return 0, fmt.Errorf("invalid number %q: digit groups must have three digits", token)
+			}
+			sb.WriteByte('.')
+			afterMark = true
+		case (r == ',' || r == '.' || r == ' ') && !afterMark:
+			if digits == 0 || (group >= 0 && group != 3) {
+				return 0, fmt.Errorf("invalid number %q: digit groups must have three digits", token)
+			}
+			group = 0
+		default:
+			return 0, fmt.Errorf("invalid number %q: unexpected %q", token, r)
+		}
+	}
+	if digits == 0 {
+		return 0, fmt.Errorf("invalid number %q: no digits", token)
+	}
+	if group >= 0 && !afterMark && group != 3 {
+		return 0, fmt.Errorf("invalid number %q: digit groups must have three digits", token)
+	}
+	value, err := strconv.ParseFloat(sb.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q: %w", token, err)
+	}
+	return value, nil
+}
+
+// decimalMark returns the rune acting as decimal point in s, or 0 if none
+func decimalMark(s string) rune {
+	lastDot, lastComma := strings.LastIndexByte(s, '.'), strings.LastIndexByte(s, ',')
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastDot > lastComma {
+			return '.'
+		}
+		return ','
+	case lastDot < 0 && lastComma < 0:
+		return 0
+	}
+	mark, at := byte('.'), lastDot
+	if lastComma >= 0 {
+		mark, at = ',', lastComma
+	}
+	if strings.Count(s, string(mark)) > 1 {
+		return 0
+	}
+	before := []rune(strings.ReplaceAll(s[:at], " ", ""))
+	after := []rune(s[at+1:])
+	if len(after) == 3 && len(before) <= 3 {
+		return 0
+	}
+	return rune(mark)
+}
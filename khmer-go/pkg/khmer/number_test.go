@@ -0,0 +1,35 @@
+package khmer
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	cases := map[string]float64{
+		"0":         0,
+		"១២៣":       123,
+		"២០២៤":      2024,
+		"1,500":     1500,
+		"៤.០០០":     4000,
+		"1.234.567": 1234567,
+		"១ ០០០ ០០០": 1000000,
+		"12,345.67": 12345.67,
+		"12.345,67": 12345.67,
+		"3.14":      3.14,
+		"១២,៥":      12.5,
+		"1234.567":  1234.567,
+		"-៥":        -5,
+		"+2.5":      2.5,
+		"០០៧":       7,
+	}
+	for token, want := range cases {
+		got, err := ParseNumber(token)
+		if err != nil || got != want {
+			t.Errorf("ParseNumber(%q) = %v, %v; want %v", token, got, err, want)
+		}
+	}
+
+	for _, token := range []string{"", "-", "abc", "១២ក", "1.5.", "1.5,3", "$5", "១,២,៣.៤.៥"} {
+		if got, err := ParseNumber(token); err == nil {
+			t.Errorf("ParseNumber(%q) = %v, want an error", token, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package khmer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// OffsetMap maps byte offsets in the text segments are cut from back to the
+// caller's original text. The two differ by the zero-width spaces Segment
+// strips, the characters a LegacyChars policy removes or rewrites, and each
+// invalid UTF-8 byte, which becomes the three bytes of U+FFFD.
+type OffsetMap struct {
+	// starts[b] and ends[b] are the original byte range of the character
+	// normalized byte b came from
+	starts, ends []int
+	length       int
+}
+
+// Normalize returns the text Segment cuts its segments from (zero-width
+// spaces removed, the LegacyChars policy applied and invalid UTF-8 replaced)
+// and the OffsetMap from its byte offsets to those of text
+func (s *KhmerSegmenter) Normalize(text string) (string, *OffsetMap) {
+	var b strings.Builder
+	b.Grow(len(text))
+	m := &OffsetMap{
+		starts: make([]int, 0, len(text)),
+		ends:   make([]int, 0, len(text)),
+		length: len(text),
+	}
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == '\u200b' || (IsInvisibleVowel(r) && s.LegacyChars != LegacyKeep) {
+			i += size
+			continue
+		}
+		out := text[i : i+size]
+		if r == utf8.RuneError && size == 1 {
+			out = "\uFFFD"
+		} else if s.LegacyChars == LegacyNormalize {
+			if form, ok := legacyForm(r); ok {
+				out = form
+			}
+		}
+		b.WriteString(out)
+		for j := 0; j < len(out); j++ {
+			m.starts = append(m.starts, i)
+			m.ends = append(m.ends, i+size)
+		}
+		i += size
+	}
+	return b.String(), m
+}
+
+// Span maps the normalized byte range [start, end) to the original text. The
+// result covers every original character the range came from, with the
+// zero-width spaces and removed characters between them, but not those before
+// its first character or after its last. An empty range maps to an empty one.
+func (m *OffsetMap) Span(start, end int) (int, int) {
+	origStart := m.length
+	if start < len(m.starts) {
+		origStart = m.starts[start]
+	}
+	if end <= start {
+		return origStart, origStart
+	}
+	return origStart, m.ends[end-1]
+}
@@ -0,0 +1,61 @@
+package khmer
+
+import "testing"
+
+func TestNormalizeOffsets(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.LegacyChars = LegacyNormalize
+	text := "\u200bខ្ញុំ\u200b\u200bទៅ\u17b4 \u17a4រ\xff"
+	normalized, offsets := seg.Normalize(text)
+	if want := seg.normalize(text); normalized != want && normalized != "ខ្ញុំទៅ អារ�" {
+		t.Fatalf("Normalized %q, want %q", normalized, want)
+	}
+	cases := []struct {
+		start, end         int
+		origStart, origEnd int
+	}{
+		// ខ្ញុំ, after the leading zero-width space
+		{0, len("ខ្ញុំ"), 3, 3 + len("ខ្ញុំ")},
+		// ខ្ញុំទៅ spans the zero-width spaces between the words
+		{0, len("ខ្ញុំទៅ"), 3, 3 + len("ខ្ញុំ\u200b\u200bទៅ")},
+		// អា covers the one deprecated character it came from
+		{len("ខ្ញុំទៅ "), len("ខ្ញុំទៅ អា"), len("\u200bខ្ញុំ\u200b\u200bទៅ\u17b4 "), len("\u200bខ្ញុំ\u200b\u200bទៅ\u17b4 \u17a4")},
+		// U+FFFD covers the invalid byte
+		{len(normalized) - 3, len(normalized), len(text) - 1, len(text)},
+		// An empty range at the end
+		{len(normalized), len(normalized), len(text), len(text)},
+	}
+	for _, tc := range cases {
+		start, end := offsets.Span(tc.start, tc.end)
+		if start != tc.origStart || end != tc.origEnd {
+			t.Errorf("Span(%d, %d) = %d, %d, want %d, %d", tc.start, tc.end, start, end, tc.origStart, tc.origEnd)
+		}
+	}
+}
+
+func TestSegmentSpansLegacy(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.LegacyChars = LegacyNormalize
+	text := "ខ្ញុំ\u200bទៅ \u17a4រ\u17b4 សាលា"
+	spans := seg.SegmentSpans(text)
+	segments := seg.Segment(text)
+	if len(spans) != len(segments) {
+		t.Fatalf("Got %d spans for %d segments", len(spans), len(segments))
+	}
+	pos := 0
+	for i, sp := range spans {
+		// Only zero-width spaces and removed characters fall between spans
+		for _, r := range text[pos:sp.Start] {
+			if r != '\u200b' && !IsInvisibleVowel(r) {
+				t.Errorf("Span %+v skips %q", sp, text[pos:sp.Start])
+			}
+		}
+		if seg.normalize(text[sp.Start:sp.End]) != segments[i] {
+			t.Errorf("Span %+v covers %q", sp, text[sp.Start:sp.End])
+		}
+		pos = sp.End
+	}
+	if pos != len(text) {
+		t.Errorf("Spans end at %d, want %d", pos, len(text))
+	}
+}
@@ -0,0 +1,147 @@
+package khmer
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OOVReport collects out-of-vocabulary segments with their counts and a few
+// example contexts, to show lexicon maintainers which words to add next.
+// A segment is OOV under the same rules as CorpusStats.
+type OOVReport struct {
+	Counts   map[string]int
+	Examples map[string][]string
+	// MaxExamples is the number of contexts kept per segment
+	MaxExamples int
+	// ContextSegments is how many segments of context are kept on each side
+	ContextSegments int
+	// StableExamples keeps the examples that sort first instead of the first
+	// seen, so the report does not depend on the order lines are added in
+	// (e.g. by concurrent workers)
+	StableExamples bool
+}
+
+// NewOOVReport creates an empty report keeping 3 examples of 3 segments either side
+func NewOOVReport() *OOVReport {
+	return &OOVReport{
+		Counts:          make(map[string]int),
+		Examples:        make(map[string][]string),
+		MaxExamples:     3,
+		ContextSegments: 3,
+	}
+}
+
+// Add records the OOV segments of one segmented line
+func (r *OOVReport) Add(segments []string, dict *Dictionary) {
+	for i, seg := range segments {
+		if seg == "" || isSeparatorSegment(seg) || !isOOV(seg, dict) {
+			continue
+		}
+		r.Counts[seg]++
+		if r.StableExamples || len(r.Examples[seg]) < r.MaxExamples {
+			r.addExample(seg, r.context(segments, i))
+		}
+	}
+}
+
+// addExample records ex as an example context of seg if there is room or,
+// with StableExamples, if it sorts before one kept
+func (r *OOVReport) addExample(seg, ex string) {
+	examples := r.Examples[seg]
+	if !r.StableExamples {
+		if len(examples) < r.MaxExamples {
+			r.Examples[seg] = append(examples, ex)
+		}
+		return
+	}
+	i := sort.SearchStrings(examples, ex)
+	if i >= r.MaxExamples {
+		return
+	}
+	examples = append(examples, "")
+	copy(examples[i+1:], examples[i:])
+	examples[i] = ex
+	if len(examples) > r.MaxExamples {
+		examples = examples[:r.MaxExamples]
+	}
+	r.Examples[seg] = examples
+}
+
+// context renders the segments around segments[i], marking the OOV segment with brackets
+func (r *OOVReport) context(segments []string, i int) string {
+	from, to := i-r.ContextSegments, i+r.ContextSegments+1
+	if from < 0 {
+		from = 0
+	}
+	if to > len(segments) {
+		to = len(segments)
+	}
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("…")
+	}
+	sb.WriteString(strings.Join(segments[from:i], ""))
+	sb.WriteString("[")
+	sb.WriteString(segments[i])
+	sb.WriteString("]")
+	sb.WriteString(strings.Join(segments[i+1:to], ""))
+	if to < len(segments) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
+
+// Merge adds other's counts and, up to MaxExamples, its examples into r
+func (r *OOVReport) Merge(other *OOVReport) {
+	for seg, n := range other.Counts {
+		r.Counts[seg] += n
+	}
+	for seg, examples := range other.Examples {
+		for _, ex := range examples {
+			r.addExample(seg, ex)
+		}
+	}
+}
+
+// Sorted returns the OOV segments by descending count, ties in string order
+func (r *OOVReport) Sorted() []string {
+	segs := make([]string, 0, len(r.Counts))
+	for seg := range r.Counts {
+		segs = append(segs, seg)
+	}
+	sort.Slice(segs, func(i, j int) bool {
+		if r.Counts[segs[i]] != r.Counts[segs[j]] {
+			return r.Counts[segs[i]] > r.Counts[segs[j]]
+		}
+		return segs[i] < segs[j]
+	})
+	return segs
+}
+
+// WriteTSV writes the report as tab-separated "token, count, example..." rows
+// with a header line
+func (r *OOVReport) WriteTSV(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("token\tcount\texamples\n")
+	for _, seg := range r.Sorted() {
+		bw.WriteString(tsvEscape(seg))
+		bw.WriteByte('\t')
+		bw.WriteString(strconv.Itoa(r.Counts[seg]))
+		for _, ex := range r.Examples[seg] {
+			bw.WriteByte('\t')
+			bw.WriteString(tsvEscape(ex))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+var tsvReplacer = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// tsvEscape replaces characters that would break the TSV layout
+func tsvEscape(s string) string {
+	return tsvReplacer.Replace(s)
+}
@@ -0,0 +1,54 @@
+package khmer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOOVReport(t *testing.T) {
+	dict := testSegmenter.Dictionary
+	a := NewOOVReport()
+	a.Add([]string{"ខ្ញុំ", "ទៅ", " ", "xyz", "។"}, dict)
+	b := NewOOVReport()
+	b.Add([]string{"xyz", " ", "abc"}, dict)
+	a.Merge(b)
+
+	if a.Counts["xyz"] != 2 || a.Counts["abc"] != 1 || len(a.Counts) != 2 {
+		t.Fatalf("Unexpected counts: %v", a.Counts)
+	}
+	if got := a.Examples["xyz"]; len(got) != 2 || got[0] != "ខ្ញុំទៅ [xyz]។" || got[1] != "[xyz] abc" {
+		t.Errorf("Unexpected examples: %q", got)
+	}
+
+	var sb strings.Builder
+	if err := a.WriteTSV(&sb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "xyz\t2\t") || !strings.HasPrefix(lines[2], "abc\t1\t") {
+		t.Errorf("Unexpected TSV:\n%s", sb.String())
+	}
+}
+
+func TestOOVReportStableExamples(t *testing.T) {
+	dict := testSegmenter.Dictionary
+	lines := [][]string{{"a", "xyz"}, {"b", "xyz"}, {"c", "xyz"}, {"d", "xyz"}}
+	var reports []*OOVReport
+	// Added in two orders, split across two reports
+	for _, order := range [][]int{{3, 1, 0, 2}, {2, 0, 3, 1}} {
+		r, other := NewOOVReport(), NewOOVReport()
+		r.StableExamples, other.StableExamples = true, true
+		r.Add(lines[order[0]], dict)
+		other.Add(lines[order[1]], dict)
+		other.Add(lines[order[2]], dict)
+		r.Add(lines[order[3]], dict)
+		r.Merge(other)
+		reports = append(reports, r)
+	}
+	want := []string{"a[xyz]", "b[xyz]", "c[xyz]"}
+	for _, r := range reports {
+		if got := r.Examples["xyz"]; strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("Got examples %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package khmer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// segmentChunksParallel segments chunks on up to s.Parallel goroutines, each
+// with a helper segmenter of its own, and joins their segments in order
+func (s *KhmerSegmenter) segmentChunksParallel(chunks []string) []string {
+	results := make([][]string, len(chunks))
+	helpers := min(s.Parallel, len(chunks))
+	var next atomic.Int64
+	var timedOut atomic.Bool
+	var wg sync.WaitGroup
+	for k := 0; k < helpers; k++ {
+		h := s.helper(k)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1)) - 1; i < len(chunks); i = int(next.Add(1)) - 1 {
+				results[i] = h.segmentChunk(chunks[i])
+			}
+			if h.timedOut {
+				timedOut.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+	s.timedOut = s.timedOut || timedOut.Load()
+
+	var segments []string
+	for _, r := range results {
+		segments = append(segments, r...)
+	}
+	return segments
+}
+
+// helper returns the k-th helper segmenter, configured like s for this call
+// but keeping its own buffers
+func (s *KhmerSegmenter) helper(k int) *KhmerSegmenter {
+	for len(s.helpers) <= k {
+		s.helpers = append(s.helpers, NewKhmerSegmenter(s.Dictionary))
+	}
+	h := s.helpers[k]
+	buffers := *h
+	*h = *s
+	h.dpCost, h.dpParent, h.dpSegments = buffers.dpCost, buffers.dpParent, buffers.dpSegments
+	h.runeBuffer, h.offsetBuffer = buffers.runeBuffer, buffers.offsetBuffer
+	// Helpers only segment chunks; spans of concurrent chunks would overlap
+	h.helpers, h.Tracer, h.Cache, h.timedOut = nil, nil, nil, false
+	return h
+}
@@ -0,0 +1,38 @@
+package khmer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParallel(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(testDataDir, "test_subset.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.ReplaceAll(string(data), "\n", " ")
+
+	sequential := NewKhmerSegmenter(testSegmenter.Dictionary)
+	sequential.ChunkRunes = 300
+	want := sequential.Segment(text)
+
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.ChunkRunes = 300
+	seg.Parallel = 4
+	// Twice, so the helpers are reused
+	for i := 0; i < 2; i++ {
+		if got := seg.Segment(text); !reflect.DeepEqual(got, want) {
+			t.Fatalf("parallel segments differ from sequential chunks (%d vs %d segments)", len(got), len(want))
+		}
+	}
+	if len(seg.helpers) != 4 {
+		t.Errorf("%d helpers, want 4", len(seg.helpers))
+	}
+	// Short lines are not split
+	if got := seg.Segment("ខ្ញុំទៅសាលា"); !reflect.DeepEqual(got, testSegmenter.Segment("ខ្ញុំទៅសាលា")) {
+		t.Errorf("short line: %q", got)
+	}
+}
@@ -0,0 +1,116 @@
+package khmer
+
+// PostProcessor is a named pass that runs over the Viterbi output.
+// Passes run in order; each receives the output of the previous one.
+type PostProcessor interface {
+	Name() string
+	Process(segments []string, dict *Dictionary) []string
+}
+
+// PostProcessorFunc adapts a plain function into a PostProcessor
+type PostProcessorFunc struct {
+	PassName string
+	Fn       func(segments []string, dict *Dictionary) []string
+}
+
+// Name returns the pass name
+func (p PostProcessorFunc) Name() string { return p.PassName }
+
+// Process runs the wrapped function
+func (p PostProcessorFunc) Process(segments []string, dict *Dictionary) []string {
+	return p.Fn(segments, dict)
+}
+
+// Names of the built-in post-processing passes
+const (
+	PassSnapSingleConsonants = "snap-single-consonants"
+	PassHeuristics           = "heuristics"
+	PassMergeUnknowns        = "merge-unknowns"
+)
+
+// Pipeline is an ordered list of post-processing passes
+type Pipeline []PostProcessor
+
+// DefaultPipeline returns the built-in passes in the order Segment has always applied them
+func DefaultPipeline() Pipeline {
+	return Pipeline{
+		PostProcessorFunc{PassName: PassSnapSingleConsonants, Fn: snapInvalidSingleConsonants},
+		PostProcessorFunc{PassName: PassHeuristics, Fn: ApplyHeuristics},
+		PostProcessorFunc{PassName: PassMergeUnknowns, Fn: PostProcessUnknowns},
+	}
+}
+
+// Run applies every pass in order
+func (p Pipeline) Run(segments []string, dict *Dictionary) []string {
+	for _, pp := range p {
+		segments = pp.Process(segments, dict)
+	}
+	return segments
+}
+
+// Names returns the pass names in order
+func (p Pipeline) Names() []string {
+	names := make([]string, len(p))
+	for i, pp := range p {
+		names[i] = pp.Name()
+	}
+	return names
+}
+
+// Index returns the position of the named pass, or -1
+func (p Pipeline) Index(name string) int {
+	for i, pp := range p {
+		if pp.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Without returns a copy of the pipeline with the named passes removed
+func (p Pipeline) Without(names ...string) Pipeline {
+	drop := make(map[string]bool, len(names))
+	for _, name := range names {
+		drop[name] = true
+	}
+	out := make(Pipeline, 0, len(p))
+	for _, pp := range p {
+		if !drop[pp.Name()] {
+			out = append(out, pp)
+		}
+	}
+	return out
+}
+
+// Append returns a copy of the pipeline with passes added at the end
+func (p Pipeline) Append(passes ...PostProcessor) Pipeline {
+	out := make(Pipeline, 0, len(p)+len(passes))
+	out = append(out, p...)
+	return append(out, passes...)
+}
+
+// InsertBefore returns a copy with pass inserted before the named pass.
+// If the named pass is missing, pass is appended.
+func (p Pipeline) InsertBefore(name string, pass PostProcessor) Pipeline {
+	idx := p.Index(name)
+	if idx < 0 {
+		return p.Append(pass)
+	}
+	out := make(Pipeline, 0, len(p)+1)
+	out = append(out, p[:idx]...)
+	out = append(out, pass)
+	return append(out, p[idx:]...)
+}
+
+// InsertAfter returns a copy with pass inserted after the named pass.
+// If the named pass is missing, pass is appended.
+func (p Pipeline) InsertAfter(name string, pass PostProcessor) Pipeline {
+	idx := p.Index(name)
+	if idx < 0 {
+		return p.Append(pass)
+	}
+	out := make(Pipeline, 0, len(p)+1)
+	out = append(out, p[:idx+1]...)
+	out = append(out, pass)
+	return append(out, p[idx+1:]...)
+}
@@ -0,0 +1,47 @@
+package khmer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultPipelineOrder(t *testing.T) {
+	expected := []string{PassSnapSingleConsonants, PassHeuristics, PassMergeUnknowns}
+	if names := DefaultPipeline().Names(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestPipelineWithoutMergeUnknowns(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.PostProcessors = DefaultPipeline().Without(PassMergeUnknowns)
+
+	input := "សម្រា ប់ការ"
+	merged := testSegmenter.Segment(input)
+	unmerged := seg.Segment(input)
+	if len(unmerged) <= len(merged) {
+		t.Errorf("Expected more segments without merging, got %v vs %v", unmerged, merged)
+	}
+	if strings.Join(unmerged, "") != input {
+		t.Errorf("Segments %v do not cover input", unmerged)
+	}
+}
+
+func TestPipelineCustomPass(t *testing.T) {
+	mark := PostProcessorFunc{PassName: "mark", Fn: func(segments []string, _ *Dictionary) []string {
+		return append(segments, "|")
+	}}
+	p := DefaultPipeline().InsertAfter(PassHeuristics, mark)
+	if p.Index("mark") != 2 {
+		t.Fatalf("Expected custom pass at index 2, got %v", p.Names())
+	}
+
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.PostProcessors = p
+	result := seg.Segment("សួស្តី")
+	expected := []string{"សួស្តី", "|"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
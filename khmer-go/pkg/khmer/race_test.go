@@ -0,0 +1,7 @@
+//go:build race
+
+package khmer
+
+// raceEnabled reports whether the race detector is on; it allocates on
+// its own, so allocation checks are skipped under it.
+const raceEnabled = true
@@ -0,0 +1,106 @@
+package khmer
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// DefaultRecognizerCost is the path cost of a recognized token (same as number grouping)
+const DefaultRecognizerCost = float32(1.0)
+
+// defaultRecognizerWindow bounds how many runes a regex recognizer inspects per position
+const defaultRecognizerWindow = 64
+
+// Recognizer matches custom token classes during the Viterbi loop, alongside the
+// built-in digit/currency and acronym rules. Use it to keep codes such as
+// invoice numbers or product SKUs in a single segment.
+type Recognizer interface {
+	Name() string
+	// Match returns the length in runes of the token starting at runes[start], or 0 for no match
+	Match(runes []rune, start int) int
+	// Cost returns the path cost charged for a matched token
+	Cost() float32
+}
+
+// lineRecognizer is implemented by recognizers that match on the line's
+// UTF-8 text, which the segmenter then calls instead of Match
+type lineRecognizer interface {
+	matchLine(ln line, start int) int
+}
+
+// RecognizerFunc adapts a callback into a Recognizer
+type RecognizerFunc struct {
+	RecName   string
+	TokenCost float32
+	Fn        func(runes []rune, start int) int
+}
+
+// Name returns the recognizer name
+func (r RecognizerFunc) Name() string { return r.RecName }
+
+// Match runs the wrapped callback
+func (r RecognizerFunc) Match(runes []rune, start int) int { return r.Fn(runes, start) }
+
+// Cost returns the configured token cost
+func (r RecognizerFunc) Cost() float32 { return r.TokenCost }
+
+// RegexRecognizer matches tokens with a regular expression anchored at the current position
+type RegexRecognizer struct {
+	name string
+	re   *regexp.Regexp
+	// TokenCost is the path cost of a match
+	TokenCost float32
+	// Window is the maximum number of runes a match may span
+	Window int
+}
+
+// NewRegexRecognizer compiles pattern into a recognizer. The pattern is
+// implicitly anchored at the position being tested, and matches as much as
+// it can (leftmost-longest, as POSIX; `a|ab` takes "ab").
+func NewRegexRecognizer(name, pattern string) (*RegexRecognizer, error) {
+	re, err := regexp.Compile(`^(?:` + pattern + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern for recognizer %q: %w", name, err)
+	}
+	re.Longest()
+	return &RegexRecognizer{
+		name:      name,
+		re:        re,
+		TokenCost: DefaultRecognizerCost,
+		Window:    defaultRecognizerWindow,
+	}, nil
+}
+
+// Name returns the recognizer name
+func (r *RegexRecognizer) Name() string { return r.name }
+
+// Cost returns the configured token cost
+func (r *RegexRecognizer) Cost() float32 { return r.TokenCost }
+
+// Match returns the rune length of the longest match at runes[start]. It
+// converts the window to a string on each call; segmenters match on the
+// line's text instead, which needs no conversion.
+func (r *RegexRecognizer) Match(runes []rune, start int) int {
+	end := min(start+r.Window, len(runes))
+	return r.match(string(runes[start:end]))
+}
+
+func (r *RegexRecognizer) matchLine(ln line, start int) int {
+	end := min(start+r.Window, len(ln.runes))
+	return r.match(ln.text[ln.offsets[start]:ln.offsets[end]])
+}
+
+// match returns the rune length of the match at the start of window
+func (r *RegexRecognizer) match(window string) int {
+	loc := r.re.FindStringIndex(window)
+	if loc == nil || loc[1] == 0 {
+		return 0
+	}
+	return utf8.RuneCountInString(window[:loc[1]])
+}
+
+// AddRecognizer registers a custom token recognizer on the segmenter
+func (s *KhmerSegmenter) AddRecognizer(r Recognizer) {
+	s.Recognizers = append(s.Recognizers, r)
+}
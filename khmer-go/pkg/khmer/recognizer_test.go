@@ -0,0 +1,68 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegexRecognizerKeepsSKUWhole(t *testing.T) {
+	rec, err := NewRegexRecognizer("sku", `[A-Z]{2,4}-[0-9]{3,}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.AddRecognizer(rec)
+
+	result := seg.Segment("ទិញ INV-2024 ហើយ")
+	expected := []string{"ទិញ", " ", "INV-2024", " ", "ហើយ"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestRecognizerFunc(t *testing.T) {
+	hash := RecognizerFunc{RecName: "hashtag", TokenCost: DefaultRecognizerCost, Fn: func(runes []rune, start int) int {
+		if runes[start] != '#' {
+			return 0
+		}
+		i := start + 1
+		for i < len(runes) && IsKhmerChar(runes[i]) {
+			i++
+		}
+		return i - start
+	}}
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.AddRecognizer(hash)
+
+	result := seg.Segment("#កម្ពុជា")
+	expected := []string{"#កម្ពុជា"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestInvalidRecognizerPattern(t *testing.T) {
+	if _, err := NewRegexRecognizer("bad", "(["); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestRegexRecognizerMatchesLongest(t *testing.T) {
+	rec, err := NewRegexRecognizer("code", `AB|AB-[0-9]+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Match([]rune("AB-12 ខ្ញុំ"), 0); got != 5 {
+		t.Errorf("Match: expected 5 runes, got %d", got)
+	}
+	ln := newLine("ខ្ញុំ AB-12")
+	if got := rec.matchLine(ln, 6); got != 5 {
+		t.Errorf("matchLine: expected 5 runes, got %d", got)
+	}
+	if raceEnabled {
+		return
+	}
+	if allocs := testing.AllocsPerRun(100, func() { rec.matchLine(ln, 0) }); allocs != 0 {
+		t.Errorf("Expected no allocations without a match, got %.0f", allocs)
+	}
+}
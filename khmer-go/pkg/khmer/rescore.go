@@ -0,0 +1,74 @@
+package khmer
+
+import "unicode/utf8"
+
+// DefaultRescoreEpsilon is how far above the best path an alternative may
+// cost and still be offered to a Rescorer
+const DefaultRescoreEpsilon = float32(2.0)
+
+// Rescorer picks among the near-best segmentations of each ambiguous span
+// of a line, so an external model (a CRF over hand-written features, a
+// neural scorer behind a callback) can decide what the Viterbi costs cannot
+// while the Viterbi loop still does the search. A Rescorer may be called
+// from several goroutines at once.
+type Rescorer interface {
+	// Rescore returns a score for each of amb.Alternatives; the highest wins
+	// and ties keep the earlier one, Alternatives[0] being the Viterbi
+	// choice. text is the whole line, for context. Returning fewer scores
+	// than alternatives keeps the Viterbi choice.
+	Rescore(text string, amb Ambiguity) []float64
+}
+
+// RescorerFunc adapts a callback into a Rescorer
+type RescorerFunc func(text string, amb Ambiguity) []float64
+
+// Rescore runs the wrapped callback
+func (f RescorerFunc) Rescore(text string, amb Ambiguity) []float64 { return f(text, amb) }
+
+// rescore replaces the Viterbi segments of each ambiguous span of text with
+// the alternative s.Rescorer scores highest. segments must be the Viterbi
+// output for text before post-processing.
+func (s *KhmerSegmenter) rescore(text string, segments []string) []string {
+	epsilon := s.RescoreEpsilon
+	if epsilon <= 0 {
+		epsilon = DefaultRescoreEpsilon
+	}
+	spans := s.Ambiguities(text, epsilon)
+	if len(spans) == 0 {
+		return segments
+	}
+
+	out := make([]string, 0, len(segments))
+	pos, k := 0, 0
+	for _, amb := range spans {
+		// Copy the segments before the span
+		for k < len(segments) && pos < amb.Start {
+			out = append(out, segments[k])
+			pos += utf8.RuneCountInString(segments[k])
+			k++
+		}
+		// The span starts and ends at boundaries of the Viterbi path unless
+		// Beam pruned it to another one; such spans are left alone
+		end, last := pos, k
+		for last < len(segments) && end < amb.End {
+			end += utf8.RuneCountInString(segments[last])
+			last++
+		}
+		if pos != amb.Start || end != amb.End {
+			continue
+		}
+		scores := s.Rescorer.Rescore(text, amb)
+		best := 0
+		for i := 1; i < len(amb.Alternatives) && i < len(scores); i++ {
+			if scores[i] > scores[best] {
+				best = i
+			}
+		}
+		if best == 0 {
+			continue
+		}
+		out = append(out, amb.Alternatives[best].Segments...)
+		pos, k = end, last
+	}
+	return append(out, segments[k:]...)
+}
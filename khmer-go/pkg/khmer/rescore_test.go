@@ -0,0 +1,51 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRescorer(t *testing.T) {
+	dict := NewDictionary()
+	for word, cost := range map[string]float32{"ក": 1, "ខ": 1, "គឃ": 1, "កខគ": 2, "ឃ": 1, "ង": 1} {
+		dict.words[word] = true
+		dict.wordCosts[word] = cost
+	}
+	dict.MaxWordLength = 3
+	dict.buildTrie()
+	seg := NewKhmerSegmenter(dict)
+	seg.PostProcessors = nil
+
+	// Preferring fewer segments picks the other side of the tie
+	var calls int
+	seg.Rescorer = RescorerFunc(func(text string, amb Ambiguity) []float64 {
+		calls++
+		if text != "ង កខគឃ" || amb.Text != "កខគឃ" {
+			t.Errorf("Rescore(%q, %q)", text, amb.Text)
+		}
+		scores := make([]float64, len(amb.Alternatives))
+		for i, alt := range amb.Alternatives {
+			scores[i] = -float64(len(alt.Segments))
+		}
+		return scores
+	})
+	want := []string{"ង", " ", "កខគ", "ឃ"}
+	if got := seg.Segment("ង កខគឃ"); !reflect.DeepEqual(got, want) || calls != 1 {
+		t.Errorf("Segment = %q after %d calls, want %q after 1", got, calls, want)
+	}
+
+	// Equal scores keep the Viterbi choice
+	seg.Rescorer = RescorerFunc(func(string, Ambiguity) []float64 { return []float64{0, 0} })
+	want = []string{"ង", " ", "ក", "ខ", "គឃ"}
+	if got := seg.Segment("ង កខគឃ"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Segment with tied scores = %q, want %q", got, want)
+	}
+
+	// A line with no ambiguity is never offered
+	calls = 0
+	seg.Rescorer = RescorerFunc(func(string, Ambiguity) []float64 { calls++; return nil })
+	seg.Segment("ង")
+	if calls != 0 {
+		t.Errorf("Rescorer called %d times for an unambiguous line", calls)
+	}
+}
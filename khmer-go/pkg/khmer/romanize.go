@@ -0,0 +1,230 @@
+package khmer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RomanizationScheme maps Khmer script to Latin. Vowel values are indexed by
+// consonant series: Khmer consonants belong to the first (a-) or second (o-)
+// series, and the same vowel sign is read differently after each.
+type RomanizationScheme struct {
+	Name       string
+	Consonants map[rune]string
+	Vowels     map[rune][2]string
+	Inherent   [2]string
+	// BareSigns are used when a nasal/aspiration sign follows a consonant with no vowel
+	BareSigns   map[rune][2]string
+	Signs       map[rune]string
+	Independent map[rune]string
+	Punctuation map[rune]string
+}
+
+// secondSeries holds the o-series consonants; all other consonants are a-series
+var secondSeries = map[rune]bool{
+	'គ': true, 'ឃ': true, 'ង': true, 'ជ': true, 'ឈ': true, 'ញ': true,
+	'ឌ': true, 'ឍ': true, 'ទ': true, 'ធ': true, 'ន': true, 'ព': true,
+	'ភ': true, 'ម': true, 'យ': true, 'រ': true, 'ល': true, 'វ': true,
+}
+
+// sonorants do not change the series of the consonant they are subscribed to
+var sonorants = map[rune]bool{
+	'ង': true, 'ញ': true, 'ន': true, 'ម': true, 'យ': true, 'រ': true, 'ល': true, 'វ': true,
+}
+
+var khmerPunctuation = map[rune]string{
+	'។': ".", '៕': ".", '៖': ":", '៘': "...", '៙': "", '៚': "", '៛': "riel",
+}
+
+var khmerIndependentVowels = map[rune]string{
+	'ឥ': "e", 'ឦ': "ei", 'ឧ': "o", 'ឩ': "ou", 'ឪ': "ov", 'ឫ': "rue", 'ឬ': "rue",
+	'ឭ': "lue", 'ឮ': "lue", 'ឯ': "ae", 'ឰ': "ai", 'ឱ': "ao", 'ឲ': "ao", 'ឳ': "au",
+}
+
+// SchemeALALC is a transliteration following the ALA-LC Khmer table
+var SchemeALALC = &RomanizationScheme{
+	Name: "alalc",
+	Consonants: map[rune]string{
+		'ក': "k", 'ខ': "kh", 'គ': "g", 'ឃ': "gh", 'ង': "ṅ",
+		'ច': "c", 'ឆ': "ch", 'ជ': "j", 'ឈ': "jh", 'ញ': "ñ",
+		'ដ': "ṭ", 'ឋ': "ṭh", 'ឌ': "ḍ", 'ឍ': "ḍh", 'ណ': "ṇ",
+		'ត': "t", 'ថ': "th", 'ទ': "d", 'ធ': "dh", 'ន': "n",
+		'ប': "p", 'ផ': "ph", 'ព': "b", 'ភ': "bh", 'ម': "m",
+		'យ': "y", 'រ': "r", 'ល': "l", 'វ': "v", 'ឝ': "ś", 'ឞ': "ṣ",
+		'ស': "s", 'ហ': "h", 'ឡ': "ḷ", 'អ': "ʼ",
+	},
+	Vowels: map[rune][2]string{
+		'ា': {"ā", "ā"}, 'ិ': {"i", "i"}, 'ី': {"ī", "ī"}, 'ឹ': {"ẏ", "ẏ"},
+		'ឺ': {"ȳ", "ȳ"}, 'ុ': {"u", "u"}, 'ូ': {"ū", "ū"}, 'ួ': {"ua", "ua"},
+		'ើ': {"oe", "oe"}, 'ឿ': {"ẏa", "ẏa"}, 'ៀ': {"ia", "ia"}, 'េ': {"e", "e"},
+		'ែ': {"ae", "ae"}, 'ៃ': {"ai", "ai"}, 'ោ': {"o", "o"}, 'ៅ': {"au", "au"},
+	},
+	Inherent:  [2]string{"a", "a"},
+	BareSigns: map[rune][2]string{'ំ': {"aṃ", "aṃ"}, 'ះ': {"aḥ", "aḥ"}},
+	Signs:     map[rune]string{'ំ': "ṃ", 'ះ': "ḥ", 'ៈ': "ă"},
+	Independent: map[rune]string{
+		'ឥ': "i", 'ឦ': "ī", 'ឧ': "u", 'ឩ': "ū", 'ឪ': "ū", 'ឫ': "ṛ", 'ឬ': "ṝ",
+		'ឭ': "ḷ", 'ឮ': "ḹ", 'ឯ': "e", 'ឰ': "ai", 'ឱ': "o", 'ឲ': "o", 'ឳ': "au",
+	},
+	Punctuation: khmerPunctuation,
+}
+
+// SchemeInformal approximates the popular romanization seen on signs and in names
+var SchemeInformal = &RomanizationScheme{
+	Name: "informal",
+	Consonants: map[rune]string{
+		'ក': "k", 'ខ': "kh", 'គ': "k", 'ឃ': "kh", 'ង': "ng",
+		'ច': "ch", 'ឆ': "chh", 'ជ': "ch", 'ឈ': "chh", 'ញ': "nh",
+		'ដ': "d", 'ឋ': "th", 'ឌ': "d", 'ឍ': "th", 'ណ': "n",
+		'ត': "t", 'ថ': "th", 'ទ': "t", 'ធ': "th", 'ន': "n",
+		'ប': "b", 'ផ': "ph", 'ព': "p", 'ភ': "ph", 'ម': "m",
+		'យ': "y", 'រ': "r", 'ល': "l", 'វ': "v", 'ឝ': "s", 'ឞ': "s",
+		'ស': "s", 'ហ': "h", 'ឡ': "l", 'អ': "",
+	},
+	Vowels: map[rune][2]string{
+		'ា': {"a", "ea"}, 'ិ': {"e", "i"}, 'ី': {"ei", "i"}, 'ឹ': {"oe", "ue"},
+		'ឺ': {"eu", "eu"}, 'ុ': {"o", "u"}, 'ូ': {"ou", "ou"}, 'ួ': {"uo", "uo"},
+		'ើ': {"aeu", "eu"}, 'ឿ': {"oea", "oea"}, 'ៀ': {"ie", "ie"}, 'េ': {"e", "e"},
+		'ែ': {"ae", "eae"}, 'ៃ': {"ai", "ey"}, 'ោ': {"ao", "o"}, 'ៅ': {"au", "ov"},
+	},
+	Inherent:    [2]string{"a", "o"},
+	BareSigns:   map[rune][2]string{'ំ': {"am", "um"}, 'ះ': {"ah", "eah"}},
+	Signs:       map[rune]string{'ំ': "m", 'ះ': "h", 'ៈ': "a"},
+	Independent: khmerIndependentVowels,
+	Punctuation: khmerPunctuation,
+}
+
+var romanizationSchemes = map[string]*RomanizationScheme{
+	SchemeALALC.Name:    SchemeALALC,
+	SchemeInformal.Name: SchemeInformal,
+}
+
+// RomanizationSchemeByName looks up a built-in scheme ("alalc" or "informal")
+func RomanizationSchemeByName(name string) (*RomanizationScheme, error) {
+	if sc, ok := romanizationSchemes[strings.ToLower(name)]; ok {
+		return sc, nil
+	}
+	return nil, fmt.Errorf("unknown romanization scheme %q (available: alalc, informal)", name)
+}
+
+// Romanize transliterates a single token. Non-Khmer characters pass through,
+// Khmer digits become ASCII digits.
+func (sc *RomanizationScheme) Romanize(token string) string {
+	runes := []rune(token)
+	n := len(runes)
+	var sb strings.Builder
+	sb.Grow(len(token))
+
+	// Syllable state: series of the onset, and whether a vowel nucleus was written
+	series := 0
+	inSyllable := false
+	hasNucleus := false
+
+	closeSyllable := func() {
+		if inSyllable && !hasNucleus {
+			sb.WriteString(sc.Inherent[series])
+		}
+		inSyllable = false
+		hasNucleus = false
+	}
+
+	for i := 0; i < n; i++ {
+		r := runes[i]
+		switch {
+		case IsConsonant(r):
+			nextIsVowel := i+1 < n && (IsDependentVowel(runes[i+1]) || IsSign(runes[i+1]))
+			nextIsCoeng := i+1 < n && IsCoeng(runes[i+1])
+			if inSyllable && hasNucleus && !nextIsVowel {
+				// Coda consonant closing the current syllable
+				sb.WriteString(sc.Consonants[r])
+				inSyllable = false
+				hasNucleus = false
+				continue
+			}
+			if inSyllable && !hasNucleus && !nextIsVowel && !nextIsCoeng && (i+1 >= n || IsConsonant(runes[i+1])) {
+				// Unwritten vowel after a bare onset: this consonant is the coda
+				sb.WriteString(sc.Inherent[series])
+				sb.WriteString(sc.Consonants[r])
+				inSyllable = false
+				continue
+			}
+			closeSyllable()
+			sb.WriteString(sc.Consonants[r])
+			series = 0
+			if secondSeries[r] {
+				series = 1
+			}
+			inSyllable = true
+		case IsCoeng(r):
+			// Subscript consonant joins the onset cluster
+			if i+1 < n && IsConsonant(runes[i+1]) {
+				i++
+				sb.WriteString(sc.Consonants[runes[i]])
+				if !inSyllable {
+					inSyllable = true
+				} else if series == 0 && secondSeries[runes[i]] && !sonorants[runes[i]] {
+					// A second-series stop under a first-series base shifts the cluster
+					series = 1
+				}
+			}
+		case r == '៉':
+			// Muusikatoan: shifts second series to first
+			series = 0
+		case r == '៊':
+			// Triisap: shifts first series to second
+			series = 1
+		case IsDependentVowel(r):
+			if v, ok := sc.Vowels[r]; ok {
+				sb.WriteString(v[series])
+			}
+			hasNucleus = true
+		case IsSign(r):
+			if inSyllable && !hasNucleus {
+				if v, ok := sc.BareSigns[r]; ok {
+					sb.WriteString(v[series])
+					hasNucleus = true
+					continue
+				}
+			}
+			if v, ok := sc.Signs[r]; ok {
+				sb.WriteString(v)
+			}
+		case r >= 0x17A3 && r <= 0x17B3:
+			closeSyllable()
+			sb.WriteString(sc.Independent[r])
+			inSyllable = true
+			hasNucleus = true
+		case r >= 0x17E0 && r <= 0x17E9:
+			closeSyllable()
+			sb.WriteByte(byte('0' + r - 0x17E0))
+		case IsKhmerChar(r):
+			closeSyllable()
+			if p, ok := sc.Punctuation[r]; ok {
+				sb.WriteString(p)
+			}
+		default:
+			closeSyllable()
+			sb.WriteRune(r)
+		}
+	}
+	closeSyllable()
+	return sb.String()
+}
+
+// RomanizeSegments transliterates each segment. The repetition mark ៗ repeats
+// the previous word, as it does in Khmer text.
+func (sc *RomanizationScheme) RomanizeSegments(segments []string) []string {
+	out := make([]string, len(segments))
+	prevWord := ""
+	for i, seg := range segments {
+		if seg == "ៗ" {
+			out[i] = prevWord
+			continue
+		}
+		out[i] = sc.Romanize(seg)
+		if !isSeparatorSegment(seg) {
+			prevWord = out[i]
+		}
+	}
+	return out
+}
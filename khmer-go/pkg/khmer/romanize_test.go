@@ -0,0 +1,45 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRomanizeInformal(t *testing.T) {
+	cases := map[string]string{
+		"កម្ពុជា": "kampuchea",
+		"ខ្ញុំ":   "khnhom",
+		"សាលារៀន": "salearien",
+		"កង":      "kang",
+		"១២៣":     "123",
+		"ABC":     "ABC",
+	}
+	for input, expected := range cases {
+		if result := SchemeInformal.Romanize(input); result != expected {
+			t.Errorf("Romanize(%q): expected %q, got %q", input, expected, result)
+		}
+	}
+}
+
+func TestRomanizeALALC(t *testing.T) {
+	if result := SchemeALALC.Romanize("កម្ពុជា"); result != "kambujā" {
+		t.Errorf("Expected %q, got %q", "kambujā", result)
+	}
+}
+
+func TestRomanizeSegmentsRepetition(t *testing.T) {
+	result := SchemeInformal.RomanizeSegments([]string{"តូច", "ៗ"})
+	expected := []string{"touch", "touch"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestRomanizationSchemeByName(t *testing.T) {
+	if _, err := RomanizationSchemeByName("ALALC"); err != nil {
+		t.Error(err)
+	}
+	if _, err := RomanizationSchemeByName("pinyin"); err == nil {
+		t.Error("Expected error for unknown scheme")
+	}
+}
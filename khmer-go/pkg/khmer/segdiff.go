@@ -0,0 +1,63 @@
+package khmer
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrTextMismatch is returned when two segmentations do not cover the same text
+var ErrTextMismatch = errors.New("segmentations are of different text")
+
+// Boundaries returns the rune offsets between consecutive segments, i.e. the
+// word boundaries inside the segmented text (the start and end are not included)
+func Boundaries(segments []string) []int {
+	if len(segments) < 2 {
+		return nil
+	}
+	out := make([]int, 0, len(segments)-1)
+	pos := 0
+	for _, seg := range segments[:len(segments)-1] {
+		pos += utf8.RuneCountInString(seg)
+		out = append(out, pos)
+	}
+	return out
+}
+
+// BoundaryDiff compares the word boundaries of two segmentations of one text.
+// Offsets are in runes of the joined segments.
+type BoundaryDiff struct {
+	Common []int
+	OnlyA  []int
+	OnlyB  []int
+}
+
+// Equal reports whether both segmentations split the text identically
+func (d BoundaryDiff) Equal() bool {
+	return len(d.OnlyA) == 0 && len(d.OnlyB) == 0
+}
+
+// DiffSegmentations compares two segmentations of the same text
+func DiffSegmentations(a, b []string) (BoundaryDiff, error) {
+	if strings.Join(a, "") != strings.Join(b, "") {
+		return BoundaryDiff{}, ErrTextMismatch
+	}
+	ba, bb := Boundaries(a), Boundaries(b)
+	var d BoundaryDiff
+	i, j := 0, 0
+	for i < len(ba) || j < len(bb) {
+		switch {
+		case j >= len(bb) || (i < len(ba) && ba[i] < bb[j]):
+			d.OnlyA = append(d.OnlyA, ba[i])
+			i++
+		case i >= len(ba) || bb[j] < ba[i]:
+			d.OnlyB = append(d.OnlyB, bb[j])
+			j++
+		default:
+			d.Common = append(d.Common, ba[i])
+			i++
+			j++
+		}
+	}
+	return d, nil
+}
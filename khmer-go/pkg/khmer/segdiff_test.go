@@ -0,0 +1,36 @@
+package khmer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBoundaries(t *testing.T) {
+	if got := Boundaries([]string{"ខ្ញុំ", "ទៅ", "សាលា"}); !reflect.DeepEqual(got, []int{5, 7}) {
+		t.Errorf("Boundaries = %v, want [5 7]", got)
+	}
+	if got := Boundaries([]string{"ខ្ញុំ"}); got != nil {
+		t.Errorf("Boundaries of one segment = %v, want nil", got)
+	}
+}
+
+func TestDiffSegmentations(t *testing.T) {
+	a := []string{"ក", "ម្ព", "ជា", " ", "x"}
+	b := []string{"កម្ពជា", " ", "x"}
+	d, err := DiffSegmentations(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := BoundaryDiff{Common: []int{6, 7}, OnlyA: []int{1, 4}}
+	if !reflect.DeepEqual(d, want) || d.Equal() {
+		t.Errorf("DiffSegmentations = %+v, want %+v", d, want)
+	}
+
+	if d, _ := DiffSegmentations(b, b); !d.Equal() {
+		t.Errorf("Expected identical segmentations to be equal: %+v", d)
+	}
+	if _, err := DiffSegmentations(a, []string{"x"}); !errors.Is(err, ErrTextMismatch) {
+		t.Errorf("Expected ErrTextMismatch, got %v", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"math"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // 1BRC optimization: Pool for segment slice reuse
@@ -18,10 +20,87 @@ var segmentPool = sync.Pool{
 type KhmerSegmenter struct {
 	Dictionary *Dictionary
 	// Pre-allocated buffers for reuse (not thread-safe, but faster)
-	dpCost   []float32
-	dpParent []int
-	// 1BRC optimization: Pre-allocated rune buffer
-	runeBuffer []rune
+	dpCost     []float32
+	dpParent   []int
+	dpSegments []int
+	// 1BRC optimization: Pre-allocated rune and byte offset buffers
+	runeBuffer   []rune
+	offsetBuffer []int32
+	// PostProcessors run in order over the Viterbi output
+	PostProcessors Pipeline
+	// Recognizers add custom token classes to the Viterbi loop
+	Recognizers []Recognizer
+	// FuzzyPenalty, when positive, lets dictionary words match with one
+	// vowel sign, diacritic or coeng off, at this extra cost (see DefaultFuzzyPenalty)
+	FuzzyPenalty float32
+	// TieBreak chooses between paths of equal cost (default TieBreakLongestLast)
+	TieBreak TieBreak
+	// MixedScript is the policy for Latin and other non-Khmer runs (default
+	// MixedScriptPerRune)
+	MixedScript MixedScript
+	// GroupCurrency keeps a currency symbol and its amount ("$5", "១០០០៛")
+	// in one CURRENCY segment; see ParseCurrency
+	GroupCurrency bool
+	// Gazetteers add named-entity entries to the Viterbi loop without
+	// adding them to the dictionary
+	Gazetteers []*Gazetteer
+	// Affixes, when set, lets prefix+word and word+suffix forms missing from
+	// the dictionary segment as one token (see DefaultAffixes)
+	Affixes *Affixes
+	// Tracer, when set, receives the normalize, viterbi and postprocess
+	// stages of each Segment call
+	Tracer Tracer
+	// NonKhmerFastPath splits lines with no Khmer text at whitespace and
+	// punctuation instead of running the Viterbi loop. It is used only
+	// without Recognizers, Gazetteers, GroupCurrency or a MixedScript policy.
+	NonKhmerFastPath bool
+	// Cache, when set, returns the segments of a line seen before instead
+	// of segmenting it again
+	Cache *SegmentCache
+	// LegacyChars is the policy for the deprecated independent vowels ឣ and
+	// ឤ and the invisible vowels U+17B4 and U+17B5 (default LegacyKeep)
+	LegacyChars LegacyChars
+	// Lossless makes the segments join back into the input exactly, with
+	// its zero-width spaces, invalid UTF-8 and LegacyChars rewrites kept;
+	// text a post-processor drops becomes a segment again
+	Lossless bool
+	// Timeout, when positive, bounds the Viterbi loop of one line. Past it
+	// the rest of the line is split into Khmer clusters and single other
+	// characters, which takes linear time, so a degenerate input cannot hold
+	// a caller for long. Such lines are not cached.
+	Timeout time.Duration
+	// timedOut is set when the last line hit Timeout, due at deadline
+	timedOut bool
+	deadline time.Time
+	// ChunkRunes, when positive, splits lines longer than this many runes
+	// into chunks at sentence marks or at whitespace between non-digits,
+	// which are segmented one after the other. It bounds the DP buffers and
+	// the latency of megabyte-long lines. Words and numbers do not span such
+	// a boundary, but the post-processing passes no longer see across it, so
+	// the segments can still differ from those of the whole line in rare
+	// cases (and do when a chunk has no boundary and is cut between clusters).
+	ChunkRunes int
+	// Beam, when positive, prunes the Viterbi loop: a position is not
+	// expanded when a path already reaching further is cheaper by more than
+	// Beam, as paths through it would have to catch up that cost. Smaller
+	// beams are faster and more likely to miss the best path.
+	Beam float32
+	// Parallel, when above 1, segments up to this many chunks of a line
+	// split by ChunkRunes at once, on goroutines with segmenters of their
+	// own, so one huge line does not take a single core for its whole
+	// length. The segments are the same as without it.
+	Parallel int
+	// helpers segment chunks in parallel; they are kept for their buffers
+	helpers []*KhmerSegmenter
+	// Costs, when set, replaces DefaultStepCosts
+	Costs *StepCosts
+	// edgeKind is the rule whose edges edges is visiting, for DebugTrace
+	edgeKind EdgeKind
+	// Rescorer, when set, chooses among the segmentations of each ambiguous
+	// span costing within RescoreEpsilon (default DefaultRescoreEpsilon) of
+	// the best path. It is skipped for lines that hit Timeout.
+	Rescorer       Rescorer
+	RescoreEpsilon float32
 }
 
 // NewKhmerSegmenter creates a new segmenter with the given dictionary
@@ -29,208 +108,397 @@ func NewKhmerSegmenter(dictionary *Dictionary) *KhmerSegmenter {
 	// Pre-allocate reasonable buffer sizes
 	initialSize := 1024
 	return &KhmerSegmenter{
-		Dictionary: dictionary,
-		dpCost:     make([]float32, initialSize),
-		dpParent:   make([]int, initialSize),
-		runeBuffer: make([]rune, initialSize),
+		Dictionary:     dictionary,
+		dpCost:         make([]float32, initialSize),
+		dpParent:       make([]int, initialSize),
+		dpSegments:     make([]int, initialSize),
+		runeBuffer:     make([]rune, initialSize),
+		offsetBuffer:   make([]int32, initialSize+1),
+		PostProcessors: DefaultPipeline(),
 	}
 }
 
-// Segment segments Khmer text into words using the Viterbi algorithm
+// Segment segments Khmer text into words using the Viterbi algorithm.
+//
+// Segment accepts any input, including invalid UTF-8, without panicking. With
+// the default pipeline the segments are never empty and join to the input with
+// zero-width spaces removed; each invalid UTF-8 byte becomes U+FFFD, so the
+// output is always valid UTF-8. With Lossless set, the segments instead join
+// to the input exactly, invalid bytes included, whatever the pipeline.
 func (s *KhmerSegmenter) Segment(text string) []string {
-	// 1. Strip Zero-Width Spaces
-	textRaw := strings.ReplaceAll(text, "\u200b", "")
-	if textRaw == "" {
-		return []string{}
+	if s.Cache == nil {
+		return s.segment(text)
 	}
-
-	// 1BRC optimization: Reuse rune buffer to avoid allocation
-	runeCount := 0
-	for range textRaw {
-		runeCount++
+	if segments, ok := s.Cache.get(s.Dictionary, text); ok {
+		return segments
+	}
+	segments := s.segment(text)
+	if !s.timedOut {
+		s.Cache.add(s.Dictionary, text, segments)
 	}
+	return segments
+}
 
-	// Ensure rune buffer is large enough
-	if len(s.runeBuffer) < runeCount {
-		s.runeBuffer = make([]rune, runeCount*2)
+// segment runs the Viterbi loop and the pipeline over text, or over each of
+// its chunks (see ChunkRunes)
+func (s *KhmerSegmenter) segment(text string) []string {
+	s.timedOut = false
+	if s.Timeout > 0 {
+		s.deadline = time.Now().Add(s.Timeout)
+	}
+	// A string has at least as many bytes as runes
+	if s.ChunkRunes <= 0 || len(text) <= s.ChunkRunes || utf8.RuneCountInString(text) <= s.ChunkRunes {
+		return s.segmentChunk(text)
+	}
+	chunks := chunkText(text, s.ChunkRunes)
+	if s.Parallel > 1 && len(chunks) > 1 {
+		return s.segmentChunksParallel(chunks)
+	}
+	var segments []string
+	for _, chunk := range chunks {
+		segments = append(segments, s.segmentChunk(chunk)...)
 	}
+	return segments
+}
 
-	// Fill rune buffer (reuse existing memory)
-	runes := s.runeBuffer[:runeCount]
-	idx := 0
-	for _, r := range textRaw {
-		runes[idx] = r
-		idx++
+// segmentChunk runs the Viterbi loop and the pipeline over one chunk of a line
+func (s *KhmerSegmenter) segmentChunk(text string) []string {
+	// 1. Strip Zero-Width Spaces and apply the LegacyChars policy
+	endNormalize := startSpan(s.Tracer, SpanNormalize)
+	textRaw := s.normalize(text)
+	if textRaw == "" {
+		endNormalize()
+		if s.Lossless && text != "" {
+			return []string{text}
+		}
+		return []string{}
 	}
-	n := runeCount
+	if s.fastPathApplies() && !hasKhmerOrInvalid(textRaw) {
+		endNormalize()
+		endPostProcess := startSpan(s.Tracer, SpanPostProcess)
+		segments := s.postProcess(splitNonKhmer(textRaw))
+		if s.Lossless {
+			segments = s.lossless(text, segments)
+		}
+		endPostProcess()
+		return segments
+	}
+
+	ln := s.fillLine(textRaw)
+	n := len(ln.runes)
+	endNormalize()
+	endViterbi := startSpan(s.Tracer, SpanViterbi)
 
 	// Ensure buffers are large enough
 	if len(s.dpCost) < n+1 {
 		s.dpCost = make([]float32, n+1)
 		s.dpParent = make([]int, n+1)
+		s.dpSegments = make([]int, n+1)
 	}
 
 	// Reset DP arrays (reuse allocated memory)
 	dpCost := s.dpCost[:n+1]
 	dpParent := s.dpParent[:n+1]
+	dpSegments := s.dpSegments[:n+1]
 	inf := float32(math.Inf(1))
 	for i := range dpCost {
 		dpCost[i] = inf
 		dpParent[i] = -1
 	}
 	dpCost[0] = 0.0
+	dpSegments[0] = 0
+
+	// relax makes i the parent of j if that path is cheaper. Edges are tried
+	// in increasing i, so keeping the first of equal-cost paths is
+	// TieBreakLongestLast.
+	fewest := s.TieBreak == TieBreakFewestSegments
+	relax := func(i, j int, newCost float32) {
+		if newCost < dpCost[j] || (fewest && newCost == dpCost[j] && dpSegments[i]+1 < dpSegments[j]) {
+			dpCost[j] = newCost
+			dpParent[j] = i
+			dpSegments[j] = dpSegments[i] + 1
+		}
+	}
 
-	// Cache dictionary reference
-	dict := s.Dictionary
-	maxWordLen := dict.MaxWordLength
-	unknownCost := dict.UnknownCost
-
+	// reach is the furthest position relaxed so far
+	reach := 0
 	for i := 0; i < n; i++ {
+		// Checking the clock every 256 positions keeps its cost negligible
+		if s.Timeout > 0 && !s.timedOut && i&255 == 255 && time.Now().After(s.deadline) {
+			s.timedOut = true
+		}
 		if dpCost[i] == inf {
 			continue
 		}
-
 		currentCost := dpCost[i]
-		charI := runes[i]
-
-		// --- Constraint Checks & Fallback (Repair Mode) ---
-		forceRepair := false
-
-		// 1. Previous char was Coeng (U+17D2)
-		if i > 0 && runes[i-1] == '\u17D2' {
-			forceRepair = true
+		if s.Beam > 0 && i < reach && s.outsideBeam(dpCost[i+1:reach+1], currentCost) {
+			continue
 		}
-
-		// 2. Current char is Dependent Vowel
-		if IsDependentVowel(charI) {
-			forceRepair = true
+		visit := func(j int, stepCost float32) {
+			relax(i, j, currentCost+stepCost)
+			if j > reach {
+				reach = j
+			}
+		}
+		if s.timedOut {
+			s.clusterEdge(ln, i, visit)
+		} else {
+			s.edges(ln, i, visit)
 		}
+	}
 
-		if forceRepair {
-			// Recovery Mode: Consume 1 char with high penalty
-			nextIdx := i + 1
-			newCost := currentCost + unknownCost + 50.0
-			if nextIdx <= n && newCost < dpCost[nextIdx] {
-				dpCost[nextIdx] = newCost
-				dpParent[nextIdx] = i
-			}
-			continue
+	// Backtrack - build segments in reverse, then reverse once at the end.
+	// Segments are substrings of the text, so they need no allocation.
+	segments := make([]string, 0, n/4) // Estimate ~4 chars per word
+	curr := n
+	for curr > 0 {
+		prev := dpParent[curr]
+		if prev == -1 {
+			break
 		}
+		segments = append(segments, ln.text[ln.offsets[prev]:ln.offsets[curr]])
+		curr = prev
+	}
 
-		// --- Normal Processing ---
+	// Reverse segments in-place
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	if s.Rescorer != nil && !s.timedOut {
+		segments = s.rescore(textRaw, segments)
+	}
 
-		// 1. Number / Digit Grouping (and Currency)
-		isDigitChar := IsDigit(charI)
-		isCurrencyStart := false
-		if IsCurrencySymbol(charI) && i+1 < n && IsDigit(runes[i+1]) {
-			isCurrencyStart = true
-		}
+	endViterbi()
 
-		if isDigitChar || isCurrencyStart {
-			numLen := getNumberLength(runes, i, n)
-			nextIdx := i + numLen
-			stepCost := float32(1.0)
-			if nextIdx <= n {
-				newCost := currentCost + stepCost
-				if newCost < dpCost[nextIdx] {
-					dpCost[nextIdx] = newCost
-					dpParent[nextIdx] = i
-				}
-			}
-		} else if IsSeparator(charI) {
-			// 2. Separators
-			nextIdx := i + 1
-			stepCost := float32(0.1)
-			if nextIdx <= n {
-				newCost := currentCost + stepCost
-				if newCost < dpCost[nextIdx] {
-					dpCost[nextIdx] = newCost
-					dpParent[nextIdx] = i
-				}
-			}
+	// Post-Processing: snap single consonants, heuristics, merge unknowns (by default)
+	endPostProcess := startSpan(s.Tracer, SpanPostProcess)
+	segments = s.postProcess(segments)
+	if s.Lossless {
+		segments = s.lossless(text, segments)
+	}
+	endPostProcess()
+	return segments
+}
+
+// line is the input of the Viterbi loop. Positions are rune indexes: the
+// rules read runes, while dictionary lookups walk the UTF-8 text from the
+// byte offset of a position.
+type line struct {
+	text  string
+	runes []rune
+	// offsets[k] is the byte offset of runes[k] in text; offsets[len(runes)]
+	// is len(text). They are int32 to halve the buffer, which limits a line
+	// (or chunk, see ChunkRunes) to 2 GiB.
+	offsets []int32
+}
+
+// newLine decodes text into a line. Invalid UTF-8 bytes become U+FFFD in
+// both runes and text, so segments cut from text are valid UTF-8.
+func newLine(text string) line {
+	n := utf8.RuneCountInString(text)
+	return decodeLine(text, make([]rune, 0, n), make([]int32, 0, n+1))
+}
+
+// fillLine decodes text into a line using the segmenter's buffers
+func (s *KhmerSegmenter) fillLine(text string) line {
+	// Sized by runes: Khmer takes 3 bytes a rune, so sizing by bytes would
+	// triple the buffers
+	if n := utf8.RuneCountInString(text); cap(s.runeBuffer) < n || cap(s.offsetBuffer) < n+1 {
+		s.runeBuffer = make([]rune, 0, n)
+		s.offsetBuffer = make([]int32, 0, n+1)
+	}
+	ln := decodeLine(text, s.runeBuffer[:0], s.offsetBuffer[:0])
+	s.runeBuffer, s.offsetBuffer = ln.runes, ln.offsets
+	return ln
+}
+
+// decodeLine appends the runes and offsets of text to the given buffers
+func decodeLine(text string, runes []rune, offsets []int32) line {
+	valid := true
+	for b, r := range text {
+		if r == utf8.RuneError && !strings.HasPrefix(text[b:], "\uFFFD") {
+			valid = false
+		}
+		runes = append(runes, r)
+		offsets = append(offsets, int32(b))
+	}
+	if !valid {
+		text = string(runes)
+		offsets = offsets[:0]
+		for b := range text {
+			offsets = append(offsets, int32(b))
 		}
+	}
+	return line{text: text, runes: runes, offsets: append(offsets, int32(len(text)))}
+}
 
-		// 3. Acronyms
-		if isAcronymStart(runes, i, n) {
-			acrLen := getAcronymLength(runes, i, n)
-			nextIdx := i + acrLen
-			stepCost := float32(1.0)
-			if nextIdx <= n {
-				newCost := currentCost + stepCost
-				if newCost < dpCost[nextIdx] {
-					dpCost[nextIdx] = newCost
-					dpParent[nextIdx] = i
-				}
+// matchPrefixes calls visit with the end and cost of every dictionary word
+// starting at position i of ln and ending by limit. The trie is walked once
+// along the text's bytes, stopping where no word continues.
+func (d *Dictionary) matchPrefixes(ln line, i, limit int, visit func(j int, cost float32)) {
+	node := d.trie
+	for j := i + 1; j <= limit; j++ {
+		for b := ln.offsets[j-1]; b < ln.offsets[j]; b++ {
+			if node = node.byteChild(ln.text[b]); node == nil {
+				return
 			}
 		}
+		if node.isWord {
+			visit(j, node.cost)
+		}
+	}
+}
 
-		// 4. Dictionary Match - OPTIMIZED: use range-based lookup (no slice allocation)
-		endLimit := i + maxWordLen
-		if endLimit > n {
-			endLimit = n
+// edges calls visit with the end and cost of every token the Viterbi loop can
+// take starting at position i of ln
+func (s *KhmerSegmenter) edges(ln line, i int, visit func(j int, cost float32)) {
+	runes := ln.runes
+	n := len(runes)
+	dict := s.Dictionary
+	unknownCost := dict.UnknownCost
+	costs := s.stepCosts()
+	charI := runes[i]
+
+	// --- Constraint Checks & Fallback (Repair Mode) ---
+	forceRepair := false
+
+	// 1. Previous char was Coeng (U+17D2)
+	if i > 0 && runes[i-1] == '\u17D2' {
+		forceRepair = true
+	}
+
+	// 2. Current char is Dependent Vowel
+	if IsDependentVowel(charI) {
+		forceRepair = true
+	}
+
+	if forceRepair {
+		// Recovery Mode: Consume 1 char with high penalty
+		s.edgeKind = EdgeRepair
+		visit(i+1, unknownCost+costs.Repair)
+		return
+	}
+
+	// --- Normal Processing ---
+
+	// 1. Number / Digit Grouping (and Currency)
+	isDigitChar := IsDigit(charI)
+	isCurrencyStart := false
+	if IsCurrencySymbol(charI) && i+1 < n && IsDigit(runes[i+1]) {
+		isCurrencyStart = true
+	}
+
+	if isDigitChar || isCurrencyStart {
+		s.edgeKind = EdgeNumber
+		// getNumberLength is 0 at a currency symbol, which adds no token
+		if numLen := getNumberLength(runes, i, n); numLen > 0 {
+			visit(i+numLen, costs.Number)
 		}
-		for j := i + 1; j <= endLimit; j++ {
-			// Use direct range lookup without creating a slice
-			if wordCost, ok := dict.LookupRuneRange(runes, i, j); ok {
-				newCost := currentCost + wordCost
-				if newCost < dpCost[j] {
-					dpCost[j] = newCost
-					dpParent[j] = i
-				}
+		if s.GroupCurrency {
+			if curLen := currencyLength(runes, i, n); curLen > 0 {
+				visit(i+curLen, costs.Number)
 			}
 		}
+	} else if IsSeparator(charI) {
+		// 2. Separators
+		s.edgeKind = EdgeSeparator
+		visit(i+1, costs.Separator)
+	} else if IsLunarSymbol(charI) {
+		// 2b. Lunar date symbols, never part of a word or cluster
+		s.edgeKind = EdgeLunar
+		visit(i+1, costs.Lunar)
+	}
 
-		// 5. Unknown Cluster Fallback
-		if IsKhmerChar(charI) {
-			clusterLen := getKhmerClusterLength(runes, i, n)
-			stepCost := unknownCost
-
-			if clusterLen == 1 && !IsValidSingleWord(charI) {
-				stepCost += 10.0
-			}
+	// 3. Acronyms
+	if isAcronymStart(runes, i, n) {
+		s.edgeKind = EdgeAcronym
+		visit(i+getAcronymLength(runes, i, n), costs.Acronym)
+	}
 
-			nextIdx := i + clusterLen
-			if nextIdx <= n {
-				newCost := currentCost + stepCost
-				if newCost < dpCost[nextIdx] {
-					dpCost[nextIdx] = newCost
-					dpParent[nextIdx] = i
-				}
-			}
+	// 3b. Custom Recognizers
+	s.edgeKind = EdgeRecognizer
+	for _, rec := range s.Recognizers {
+		var tokLen int
+		if lr, ok := rec.(lineRecognizer); ok {
+			tokLen = lr.matchLine(ln, i)
 		} else {
-			// Non-Khmer
-			nextIdx := i + 1
-			newCost := currentCost + unknownCost
-			if nextIdx <= n && newCost < dpCost[nextIdx] {
-				dpCost[nextIdx] = newCost
-				dpParent[nextIdx] = i
-			}
+			tokLen = rec.Match(runes, i)
+		}
+		if tokLen > 0 && i+tokLen <= n {
+			visit(i+tokLen, rec.Cost())
 		}
 	}
 
-	// Backtrack - build segments in reverse, then reverse once at the end
-	segments := make([]string, 0, n/4) // Estimate ~4 chars per word
-	curr := n
-	for curr > 0 {
-		prev := dpParent[curr]
-		if prev == -1 {
-			break
-		}
-		segments = append(segments, string(runes[prev:curr]))
-		curr = prev
+	// 3c. Gazetteer entries
+	s.edgeKind = EdgeGazetteer
+	for _, g := range s.Gazetteers {
+		g.lookup(runes, i, visit)
 	}
 
-	// Reverse segments in-place
-	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
-		segments[i], segments[j] = segments[j], segments[i]
+	// 3d. Non-Khmer runs (opt-in)
+	if runLen := s.MixedScript.mixedRunLength(runes, i, n); runLen > 0 {
+		s.edgeKind = EdgeMixed
+		visit(i+runLen, mixedRunCost)
 	}
 
-	// Post-Processing Pass 1: Snap Invalid Single Consonants
-	pass1Segments := snapInvalidSingleConsonants(segments, dict)
+	// 4. Dictionary Match - OPTIMIZED: one walk along the UTF-8 bytes
+	endLimit := i + dict.MaxWordLength
+	if endLimit > n {
+		endLimit = n
+	}
+	s.edgeKind = EdgeDictionary
+	dict.matchPrefixes(ln, i, endLimit, visit)
+
+	// 4b. Near-miss dictionary matches (opt-in)
+	if s.FuzzyPenalty > 0 && IsKhmerChar(charI) {
+		s.edgeKind = EdgeFuzzy
+		dict.fuzzyLookup(runes, i, endLimit, func(j int, wordCost float32) {
+			visit(j, s.FuzzyPenalty+wordCost)
+		})
+	}
+
+	// 4c. Derived forms: prefix + word, word + suffix (opt-in)
+	if s.Affixes != nil && IsKhmerChar(charI) {
+		s.edgeKind = EdgeAffix
+		s.Affixes.lookup(dict, runes, i, visit)
+	}
+
+	// 5. Unknown Cluster Fallback
+	s.edgeKind = EdgeUnknown
+	if IsKhmerChar(charI) {
+		clusterLen := getKhmerClusterLength(runes, i, n)
+		stepCost := unknownCost
+
+		if clusterLen == 1 && !IsValidSingleWord(charI) {
+			stepCost += costs.InvalidSingle
+		}
+		visit(i+clusterLen, stepCost)
+	} else {
+		// Non-Khmer
+		visit(i+1, unknownCost)
+	}
+}
 
-	// Apply heuristics and post-process unknowns
-	pass2Segments := ApplyHeuristics(pass1Segments, dict)
-	return PostProcessUnknowns(pass2Segments, dict)
+// outsideBeam reports whether a position reached at cost is pruned: one of
+// the positions after it, whose costs are ahead, is cheaper by more than Beam.
+// The furthest reached position is never pruned, so the end stays reachable.
+func (s *KhmerSegmenter) outsideBeam(ahead []float32, cost float32) bool {
+	for _, c := range ahead {
+		if cost > c+s.Beam {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterEdge is the only edge taken from position i after Timeout: the
+// Khmer cluster starting there, or a single other character
+func (s *KhmerSegmenter) clusterEdge(ln line, i int, visit func(j int, cost float32)) {
+	if IsKhmerChar(ln.runes[i]) {
+		visit(i+getKhmerClusterLength(ln.runes, i, len(ln.runes)), s.Dictionary.UnknownCost)
+		return
+	}
+	visit(i+1, s.Dictionary.UnknownCost)
 }
 
 // snapInvalidSingleConsonants merges invalid single consonants with neighbors
@@ -247,9 +515,10 @@ func snapInvalidSingleConsonants(segments []string, dict *Dictionary) []string {
 
 		isInvalidSingle := segLen == 1 &&
 			!IsValidSingleWord(firstChar) &&
-			!dict.Contains(seg) &&
+			!dict.Has(seg) &&
 			!IsDigit(firstChar) &&
-			!IsSeparator(firstChar)
+			!IsSeparator(firstChar) &&
+			!IsLunarSymbol(firstChar)
 
 		if isInvalidSingle {
 			prevIsSep := false
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -18,6 +19,7 @@ type TestCase struct {
 
 var testSegmenter *KhmerSegmenter
 var testCases []TestCase
+var testDataDir string
 
 func TestMain(m *testing.M) {
 	// Find data directory (try multiple locations)
@@ -40,6 +42,7 @@ func TestMain(m *testing.M) {
 	if dataDir == "" {
 		panic("Could not find data directory with test_cases.json")
 	}
+	testDataDir = dataDir
 
 	dictPath := filepath.Join(dataDir, "khmer_dictionary_words.txt")
 	freqPath := filepath.Join(dataDir, "khmer_word_frequencies.json")
@@ -139,3 +142,19 @@ func TestPunctuation(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, result)
 	}
 }
+
+func TestExoticWhitespace(t *testing.T) {
+	// Tab, no-break, thin and ideographic spaces segment like ASCII space
+	inputs := []string{"ខ្ញុំ ទៅ សាលា", "សម្រា ប់ការ", "តម្លៃ ១០០ ដុល្លារ"}
+	for _, ws := range []string{"\t", "\u00a0", "\u2009", "\u3000"} {
+		for _, input := range inputs {
+			var want []string
+			for _, seg := range testSegmenter.Segment(input) {
+				want = append(want, strings.ReplaceAll(seg, " ", ws))
+			}
+			if got := testSegmenter.Segment(strings.ReplaceAll(input, " ", ws)); !reflect.DeepEqual(got, want) {
+				t.Errorf("With %q: got %q, want %q", ws, got, want)
+			}
+		}
+	}
+}
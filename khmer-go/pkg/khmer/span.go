@@ -0,0 +1,126 @@
+package khmer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Span is a segment with its byte offsets in the text it came from
+type Span struct {
+	Text string
+	// Start and End are byte offsets, End exclusive
+	Start, End int
+}
+
+// SegmentSpans segments text like Segment and locates each segment in text,
+// for highlighting and other callers that index into the original bytes. The
+// segments are located in the normalized text and mapped back through its
+// OffsetMap, so zero-width spaces and LegacyChars rewrites never misplace them.
+func (s *KhmerSegmenter) SegmentSpans(text string) []Span {
+	segments := s.Segment(text)
+	if s.Lossless {
+		// The segments are the text, in order
+		spans := make([]Span, len(segments))
+		pos := 0
+		for i, seg := range segments {
+			spans[i] = Span{Text: seg, Start: pos, End: pos + len(seg)}
+			pos += len(seg)
+		}
+		return spans
+	}
+	normalized, offsets := s.Normalize(text)
+	spans := make([]Span, 0, len(segments))
+	alignSegments(normalized, segments, func(_ int, sp Span) {
+		sp.Start, sp.End = offsets.Span(sp.Start, sp.End)
+		spans = append(spans, sp)
+	})
+	return spans
+}
+
+// AlignSegments locates segments, in order, in the text they were produced
+// from. Zero-width spaces, which Segment removes, are skipped: a span covers
+// those inside its segment but not those before it. The same goes for the
+// characters a LegacyChars policy removes or rewrites. An invalid UTF-8 byte
+// matches the U+FFFD it became. A segment not found where the previous one
+// ended (e.g. after a filtered stopword) is searched for further along, and
+// one not found at all is left out.
+func AlignSegments(text string, segments []string) []Span {
+	spans := make([]Span, 0, len(segments))
+	alignSegments(text, segments, func(_ int, sp Span) {
+		spans = append(spans, sp)
+	})
+	return spans
+}
+
+// alignSegments calls visit with the index and span of each segment located
+func alignSegments(text string, segments []string, visit func(i int, sp Span)) {
+	pos := 0
+	for i, seg := range segments {
+		if start, end, ok := alignAt(text, pos, seg); ok {
+			visit(i, Span{Text: seg, Start: start, End: end})
+			pos = end
+		}
+	}
+}
+
+// alignAt finds seg in text at or after byte offset from and returns its span
+func alignAt(text string, from int, seg string) (int, int, bool) {
+	if seg == "" {
+		return 0, 0, false
+	}
+	for start := from; start < len(text); {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		if r == '\u200b' {
+			start += size
+			continue
+		}
+		if end, ok := matchAt(text, start, seg); ok {
+			return start, end, true
+		}
+		start += size
+	}
+	return 0, 0, false
+}
+
+// matchAt reports whether seg matches text from byte offset i, ignoring
+// zero-width spaces and invisible vowels after its first rune, and returns
+// the end of the match. A deprecated character in text matches the runes
+// LegacyNormalize writes for it.
+func matchAt(text string, i int, seg string) (int, bool) {
+	first := true
+	// pending holds the rest of a deprecated character's normalized form
+	pending := ""
+	for _, want := range seg {
+		if pending != "" {
+			r, size := utf8.DecodeRuneInString(pending)
+			if r != want {
+				return 0, false
+			}
+			pending = pending[size:]
+			continue
+		}
+		for {
+			if i >= len(text) {
+				return 0, false
+			}
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if r == want {
+				i += size
+				break
+			}
+			if (r == '\u200b' || IsInvisibleVowel(r)) && !first {
+				i += size
+				continue
+			}
+			form, ok := legacyForm(r)
+			if !ok || !strings.HasPrefix(form, string(want)) {
+				return 0, false
+			}
+			pending = form[utf8.RuneLen(want):]
+			i += size
+			break
+		}
+		first = false
+	}
+	return i, pending == ""
+}
@@ -0,0 +1,43 @@
+package khmer
+
+import "testing"
+
+func TestSegmentSpans(t *testing.T) {
+	// Zero-width spaces between and inside words, and an invalid byte
+	text := "ខ្ញុំ\u200bទៅ\u200bសាលា\u200bរៀន \xff!"
+	spans := testSegmenter.SegmentSpans(text)
+	if len(spans) == 0 {
+		t.Fatal("No spans")
+	}
+	pos := 0
+	for _, sp := range spans {
+		if sp.Start < pos || sp.End <= sp.Start || sp.End > len(text) {
+			t.Fatalf("Span %+v out of order after %d", sp, pos)
+		}
+		// The original bytes are the segment with zero-width spaces kept
+		got := []rune(text[sp.Start:sp.End])
+		want := []rune(sp.Text)
+		j := 0
+		for _, r := range got {
+			if r == '\u200b' {
+				continue
+			}
+			if j >= len(want) || r != want[j] {
+				t.Fatalf("Span %+v covers %q", sp, text[sp.Start:sp.End])
+			}
+			j++
+		}
+		pos = sp.End
+	}
+	if last := spans[len(spans)-1]; last.End != len(text) {
+		t.Errorf("Last span ends at %d, want %d", last.End, len(text))
+	}
+}
+
+func TestAlignSegmentsSkipsMissing(t *testing.T) {
+	// A filtered segment ("ទៅ") and one not in the text at all
+	spans := AlignSegments("ខ្ញុំទៅផ្ទះ", []string{"ខ្ញុំ", "ផ្ទះ", "គ"})
+	if len(spans) != 2 || spans[1].Text != "ផ្ទះ" || spans[1].Start != len("ខ្ញុំទៅ") {
+		t.Errorf("Got %+v", spans)
+	}
+}
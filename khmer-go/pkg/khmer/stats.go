@@ -0,0 +1,184 @@
+package khmer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Character classes reported by CorpusStats
+const (
+	ClassConsonant      = "consonant"
+	ClassIndependent    = "independent-vowel"
+	ClassDependentVowel = "dependent-vowel"
+	ClassSign           = "sign"
+	ClassCoeng          = "coeng"
+	ClassDigit          = "digit"
+	ClassKhmerPunct     = "khmer-punctuation"
+	ClassKhmerSymbol    = "khmer-symbol"
+	ClassWhitespace     = "whitespace"
+	ClassOtherPunct     = "punctuation"
+	ClassNonKhmer       = "non-khmer"
+	ClassZeroWidthSpace = "zero-width-space"
+)
+
+// CharClasses lists the character classes in report order
+var CharClasses = []string{
+	ClassConsonant, ClassIndependent, ClassDependentVowel, ClassSign, ClassCoeng,
+	ClassDigit, ClassKhmerPunct, ClassKhmerSymbol, ClassWhitespace, ClassOtherPunct,
+	ClassZeroWidthSpace, ClassNonKhmer,
+}
+
+// ClassifyChar returns the CorpusStats class of r
+func ClassifyChar(r rune) string {
+	switch {
+	case r == '\u200b':
+		return ClassZeroWidthSpace
+	case IsConsonant(r):
+		return ClassConsonant
+	case r >= 0x17A3 && r <= 0x17B3:
+		return ClassIndependent
+	case IsDependentVowel(r):
+		return ClassDependentVowel
+	case IsCoeng(r):
+		return ClassCoeng
+	case IsSign(r):
+		return ClassSign
+	case IsDigit(r):
+		return ClassDigit
+	case r >= 0x17D4 && r <= 0x17DA:
+		return ClassKhmerPunct
+	case IsKhmerChar(r):
+		return ClassKhmerSymbol
+	case unicode.IsSpace(r):
+		return ClassWhitespace
+	case IsSeparator(r) || unicode.IsPunct(r):
+		return ClassOtherPunct
+	}
+	return ClassNonKhmer
+}
+
+// CorpusStats accumulates character, cluster and OOV statistics over a corpus
+type CorpusStats struct {
+	Lines        int
+	Chars        int
+	CharClasses  map[string]int
+	Clusters     int
+	ClusterRunes int
+	// Tokens counts word segments (separators and whitespace excluded)
+	Tokens    int
+	OOVTokens int
+	// OOVTypes counts distinct out-of-vocabulary segments
+	OOVTypes map[string]int
+}
+
+// NewCorpusStats creates an empty accumulator
+func NewCorpusStats() *CorpusStats {
+	return &CorpusStats{
+		CharClasses: make(map[string]int),
+		OOVTypes:    make(map[string]int),
+	}
+}
+
+// AddLine records the characters and orthographic clusters of a line
+func (s *CorpusStats) AddLine(line string) {
+	s.Lines++
+	runes := []rune(line)
+	s.Chars += len(runes)
+	for _, r := range runes {
+		s.CharClasses[ClassifyChar(r)]++
+	}
+
+	n := len(runes)
+	for i := 0; i < n; {
+		c := runes[i]
+		if c >= 0x1780 && c <= 0x17B3 {
+			clusterLen := getKhmerClusterLength(runes, i, n)
+			s.Clusters++
+			s.ClusterRunes += clusterLen
+			i += clusterLen
+			continue
+		}
+		i++
+	}
+}
+
+// AddSegments records the OOV rate of a segmented line against dict.
+// Numbers, separators and acronyms are not counted as words.
+func (s *CorpusStats) AddSegments(segments []string, dict *Dictionary) {
+	for _, seg := range segments {
+		if seg == "" || isSeparatorSegment(seg) {
+			continue
+		}
+		s.Tokens++
+		if isOOV(seg, dict) {
+			s.OOVTokens++
+			s.OOVTypes[seg]++
+		}
+	}
+}
+
+// CountOOV returns the number of word segments (separators excluded) and how
+// many of them are out of vocabulary
+func CountOOV(segments []string, dict *Dictionary) (tokens, oov int) {
+	for _, seg := range segments {
+		if seg == "" || isSeparatorSegment(seg) {
+			continue
+		}
+		tokens++
+		if isOOV(seg, dict) {
+			oov++
+		}
+	}
+	return tokens, oov
+}
+
+// isOOV reports whether a non-separator segment is out of vocabulary
+func isOOV(seg string, dict *Dictionary) bool {
+	if dict.Has(seg) {
+		return false
+	}
+	runes := []rune(seg)
+	if IsDigit(runes[0]) || (IsCurrencySymbol(runes[0]) && len(runes) > 1 && IsDigit(runes[1])) {
+		return false
+	}
+	if len(runes) == 1 && IsValidSingleWord(runes[0]) {
+		return false
+	}
+	if len(runes) >= 2 && strings.Contains(seg, ".") {
+		// Acronym
+		return false
+	}
+	return true
+}
+
+// Merge adds other's counts into s
+func (s *CorpusStats) Merge(other *CorpusStats) {
+	s.Lines += other.Lines
+	s.Chars += other.Chars
+	for k, v := range other.CharClasses {
+		s.CharClasses[k] += v
+	}
+	s.Clusters += other.Clusters
+	s.ClusterRunes += other.ClusterRunes
+	s.Tokens += other.Tokens
+	s.OOVTokens += other.OOVTokens
+	for k, v := range other.OOVTypes {
+		s.OOVTypes[k] += v
+	}
+}
+
+// AverageClusterLength returns the mean number of runes per Khmer cluster
+func (s *CorpusStats) AverageClusterLength() float64 {
+	if s.Clusters == 0 {
+		return 0
+	}
+	return float64(s.ClusterRunes) / float64(s.Clusters)
+}
+
+// OOVRate returns the fraction of word tokens not found in the dictionary
+func (s *CorpusStats) OOVRate() float64 {
+	if s.Tokens == 0 {
+		return 0
+	}
+	return float64(s.OOVTokens) / float64(s.Tokens)
+}
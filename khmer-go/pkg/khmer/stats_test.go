@@ -0,0 +1,35 @@
+package khmer
+
+import "testing"
+
+func TestCorpusStats(t *testing.T) {
+	stats := NewCorpusStats()
+	line := "ខ្ញុំទៅ ១២ xyz។"
+	stats.AddLine(line)
+	stats.AddSegments(testSegmenter.Segment(line), testSegmenter.Dictionary)
+
+	if stats.Lines != 1 || stats.Chars != len([]rune(line)) {
+		t.Errorf("Unexpected line/char counts: %+v", stats)
+	}
+	expected := map[string]int{
+		ClassConsonant:      3,
+		ClassCoeng:          1,
+		ClassDependentVowel: 2,
+		ClassSign:           1,
+		ClassDigit:          2,
+		ClassWhitespace:     2,
+		ClassNonKhmer:       3,
+		ClassKhmerPunct:     1,
+	}
+	for class, count := range expected {
+		if stats.CharClasses[class] != count {
+			t.Errorf("%s: expected %d, got %d", class, count, stats.CharClasses[class])
+		}
+	}
+	if stats.Clusters != 2 {
+		t.Errorf("Expected 2 clusters, got %d", stats.Clusters)
+	}
+	if stats.OOVTokens != 1 || stats.OOVTypes["xyz"] != 1 {
+		t.Errorf("Expected xyz as the only OOV token, got %v", stats.OOVTypes)
+	}
+}
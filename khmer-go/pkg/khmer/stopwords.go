@@ -0,0 +1,91 @@
+package khmer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PassDropStopwords is the name of the stopword filtering pass
+const PassDropStopwords = "drop-stopwords"
+
+// DefaultStopwords are common Khmer function words (conjunctions, particles, prepositions)
+var DefaultStopwords = []string{
+	"និង", "ឬ", "ហើយ", "ក៏", "ដែល", "ថា", "ជា", "គឺ", "នៃ", "នូវ",
+	"ក្នុង", "ពី", "ដោយ", "ដើម្បី", "សម្រាប់", "របស់", "ចំពោះ", "នៅ", "បាន", "នឹង",
+	"ទេ", "ដ៏", "នេះ", "នោះ", "ទាំង", "ផង", "ដែរ", "ណា", "ៗ",
+}
+
+// StopwordFilter removes stopwords and separator-only segments from the output.
+// It implements PostProcessor so it can be appended to a segmenter's pipeline.
+type StopwordFilter struct {
+	Words map[string]bool
+	// KeepSeparators retains punctuation and whitespace segments
+	KeepSeparators bool
+}
+
+// NewStopwordFilter creates a filter for the given words
+func NewStopwordFilter(words []string) *StopwordFilter {
+	f := &StopwordFilter{Words: make(map[string]bool, len(words))}
+	for _, w := range words {
+		f.Words[w] = true
+	}
+	return f
+}
+
+// LoadStopwords reads a stopword list (one word per line, '#' starts a comment)
+func LoadStopwords(path string) (*StopwordFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("stopword list not found at %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewStopwordFilter(words), nil
+}
+
+// Name returns the pass name
+func (f *StopwordFilter) Name() string { return PassDropStopwords }
+
+// Process implements PostProcessor
+func (f *StopwordFilter) Process(segments []string, _ *Dictionary) []string {
+	return f.Filter(segments)
+}
+
+// Filter returns the segments that are neither stopwords nor (unless kept) separators
+func (f *StopwordFilter) Filter(segments []string) []string {
+	out := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if f.Words[seg] {
+			continue
+		}
+		if !f.KeepSeparators && isSeparatorSegment(seg) {
+			continue
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+// isSeparatorSegment reports whether seg consists only of separators and whitespace
+func isSeparatorSegment(seg string) bool {
+	for _, r := range seg {
+		if !IsSeparator(r) && r != '\t' && r != '\u200b' {
+			return false
+		}
+	}
+	return true
+}
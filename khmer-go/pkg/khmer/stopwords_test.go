@@ -0,0 +1,22 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStopwordFilter(t *testing.T) {
+	f := NewStopwordFilter(DefaultStopwords)
+	result := f.Filter([]string{"ខ្ញុំ", " ", "និង", " ", "អ្នក", "។"})
+	expected := []string{"ខ្ញុំ", "អ្នក"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+
+	f.KeepSeparators = true
+	result = f.Filter([]string{"ខ្ញុំ", " ", "និង", "អ្នក"})
+	expected = []string{"ខ្ញុំ", " ", "អ្នក"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
@@ -0,0 +1,134 @@
+package khmer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// StreamToken is one token of a TokenStream
+type StreamToken struct {
+	Text string
+	Type TokenType
+	// Start and End are byte offsets in the stream's whole input
+	Start, End int
+	// PositionIncrement is the distance from the previous token: 1, or more
+	// where the pipeline removed words (e.g. stopwords) in between
+	PositionIncrement int
+}
+
+// TokenStream hands out tokens one at a time, Lucene-style, reading its
+// input a line at a time, so consumers never hold more than one line's
+// tokens. Spaces and punctuation are not emitted. It uses the segmenter's
+// buffers and must not be used concurrently with it.
+type TokenStream struct {
+	seg    *KhmerSegmenter
+	r      *bufio.Reader
+	err    error
+	eof    bool
+	offset int // byte offset of the current line in the input
+
+	line    string
+	spans   []Span
+	types   []TokenType
+	next    int
+	lastEnd int // end of the previous span in line, for gap detection
+	gap     bool
+}
+
+// NewTokenStream returns a stream of the tokens of r
+func (s *KhmerSegmenter) NewTokenStream(r io.Reader) *TokenStream {
+	ts := &TokenStream{seg: s}
+	ts.Reset(r)
+	return ts
+}
+
+// Reset starts the stream over on r, reusing its buffers
+func (ts *TokenStream) Reset(r io.Reader) {
+	if ts.r == nil {
+		ts.r = bufio.NewReader(r)
+	} else {
+		ts.r.Reset(r)
+	}
+	ts.err, ts.eof, ts.offset = nil, false, 0
+	ts.line, ts.spans, ts.types, ts.next, ts.lastEnd, ts.gap = "", ts.spans[:0], ts.types[:0], 0, 0, false
+}
+
+// Next returns the next token, or false at the end of the input or on a
+// read error (see Err)
+func (ts *TokenStream) Next() (StreamToken, bool) {
+	for {
+		for ts.next < len(ts.spans) {
+			sp, typ := ts.spans[ts.next], ts.types[ts.next]
+			ts.next++
+			if gapHasWords(ts.line[ts.lastEnd:sp.Start]) {
+				ts.gap = true
+			}
+			ts.lastEnd = sp.End
+			if typ == TokenSpace || typ == TokenPunct {
+				continue
+			}
+			incr := 1
+			if ts.gap {
+				incr, ts.gap = 2, false
+			}
+			return StreamToken{
+				Text:              sp.Text,
+				Type:              typ,
+				Start:             ts.offset + sp.Start,
+				End:               ts.offset + sp.End,
+				PositionIncrement: incr,
+			}, true
+		}
+		if !ts.readLine() {
+			return StreamToken{}, false
+		}
+	}
+}
+
+// Err returns the first read error, other than io.EOF
+func (ts *TokenStream) Err() error { return ts.err }
+
+// readLine segments the next line of input, reporting false at its end
+func (ts *TokenStream) readLine() bool {
+	if ts.eof {
+		return false
+	}
+	ts.offset += len(ts.line)
+	line, err := ts.r.ReadString('\n')
+	if err != nil {
+		ts.eof = true
+		if err != io.EOF {
+			ts.err = err
+		}
+		if line == "" {
+			return false
+		}
+	}
+	if ts.lastEnd < len(ts.line) && gapHasWords(ts.line[ts.lastEnd:]) {
+		ts.gap = true
+	}
+	ts.line, ts.next, ts.lastEnd = line, 0, 0
+
+	segments := ts.seg.Segment(strings.TrimRight(line, "\r\n"))
+	types := ClassifyTokens(segments, ts.seg.Dictionary)
+	TagEntities(types, segments, ts.seg.Gazetteers)
+	ts.spans, ts.types = ts.spans[:0], ts.types[:0]
+	alignSegments(line, segments, func(i int, sp Span) {
+		ts.spans = append(ts.spans, sp)
+		ts.types = append(ts.types, types[i])
+	})
+	return true
+}
+
+// gapHasWords reports whether text between two spans holds more than
+// whitespace, i.e. a segment the pipeline removed
+func gapHasWords(text string) bool {
+	for _, r := range text {
+		if !unicode.IsSpace(r) && r != '\u200b' && !IsSeparator(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package khmer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenStream(t *testing.T) {
+	text := "ខ្ញុំទៅសាលារៀន។\nគាត់\u200bមាន ១០០ ដុល្លារ\r\n\nលា"
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+
+	var want []string
+	for _, line := range strings.Split(text, "\n") {
+		for _, tok := range seg.Tokenize(strings.TrimRight(line, "\r")) {
+			if tok.Type != TokenSpace && tok.Type != TokenPunct {
+				want = append(want, tok.Text)
+			}
+		}
+	}
+
+	ts := seg.NewTokenStream(strings.NewReader(text))
+	var got []string
+	for {
+		tok, ok := ts.Next()
+		if !ok {
+			break
+		}
+		if orig := strings.ReplaceAll(text[tok.Start:tok.End], "\u200b", ""); orig != tok.Text {
+			t.Errorf("Token %q at [%d,%d) covers %q", tok.Text, tok.Start, tok.End, orig)
+		}
+		if tok.PositionIncrement != 1 {
+			t.Errorf("Token %q has position increment %d, want 1", tok.Text, tok.PositionIncrement)
+		}
+		got = append(got, tok.Text)
+	}
+	if ts.Err() != nil {
+		t.Fatal(ts.Err())
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+
+	// Reset reuses the stream
+	ts.Reset(strings.NewReader("លា"))
+	if tok, ok := ts.Next(); !ok || tok.Text != "លា" || tok.Start != 0 {
+		t.Errorf("After Reset got %+v, %v", tok, ok)
+	}
+}
+
+func TestTokenStreamPositionGaps(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.PostProcessors = DefaultPipeline().Append(NewStopwordFilter([]string{"ទៅ"}))
+
+	ts := seg.NewTokenStream(strings.NewReader("ខ្ញុំទៅសាលារៀន"))
+	var incrs []int
+	for tok, ok := ts.Next(); ok; tok, ok = ts.Next() {
+		incrs = append(incrs, tok.PositionIncrement)
+	}
+	if len(incrs) != 2 || incrs[0] != 1 || incrs[1] != 2 {
+		t.Errorf("Got position increments %v, want [1 2]", incrs)
+	}
+}
@@ -0,0 +1,175 @@
+package khmer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PassSubword is the name of the subword splitting pass
+const PassSubword = "subword"
+
+// bpeEndOfWord marks the last symbol of a word in subword-nmt merges files
+const bpeEndOfWord = "</w>"
+
+// BPE holds the merge rules of a byte-pair encoding model
+type BPE struct {
+	// ranks[pair] is the priority of merging pair; lower merges first
+	ranks map[[2]string]int
+	// endOfWord is set when the merges mark the last symbol with "</w>"
+	endOfWord bool
+}
+
+// NewBPE creates a model from merge rules in priority order, each a pair of
+// symbols to join
+func NewBPE(merges [][2]string) *BPE {
+	b := &BPE{ranks: make(map[[2]string]int, len(merges))}
+	for i, pair := range merges {
+		if _, ok := b.ranks[pair]; !ok {
+			b.ranks[pair] = i
+		}
+		if strings.HasSuffix(pair[1], bpeEndOfWord) {
+			b.endOfWord = true
+		}
+	}
+	return b
+}
+
+// LoadBPEMerges reads a merges file as written by subword-nmt or Hugging Face
+// tokenizers: one "left right" pair per line in priority order, with an
+// optional "#version" header
+func LoadBPEMerges(path string) (*BPE, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("BPE merges file not found at %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var merges [][2]string
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || (lineNo == 1 && strings.HasPrefix(line, "#")) {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected two symbols, got %q", path, lineNo, line)
+		}
+		merges = append(merges, [2]string{fields[0], fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewBPE(merges), nil
+}
+
+// Encode splits word into pieces: starting from its characters, the adjacent
+// pair with the best rank is merged until no rule applies
+func (b *BPE) Encode(word string) []string {
+	symbols := make([]string, 0, len(word))
+	for _, r := range word {
+		symbols = append(symbols, string(r))
+	}
+	if len(symbols) == 0 {
+		return symbols
+	}
+	if b.endOfWord {
+		symbols[len(symbols)-1] += bpeEndOfWord
+	}
+	for len(symbols) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i+1 < len(symbols); i++ {
+			if rank, ok := b.ranks[[2]string{symbols[i], symbols[i+1]}]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		// Merge every occurrence of the pair, left to right
+		pair := [2]string{symbols[best], symbols[best+1]}
+		merged := symbols[:0]
+		for i := 0; i < len(symbols); i++ {
+			if i+1 < len(symbols) && symbols[i] == pair[0] && symbols[i+1] == pair[1] {
+				merged = append(merged, pair[0]+pair[1])
+				i++
+				continue
+			}
+			merged = append(merged, symbols[i])
+		}
+		symbols = merged
+	}
+	if b.endOfWord {
+		last := len(symbols) - 1
+		if symbols[last] = strings.TrimSuffix(symbols[last], bpeEndOfWord); symbols[last] == "" {
+			symbols = symbols[:last]
+		}
+	}
+	return symbols
+}
+
+// SplitSyllables splits word into orthographic syllables: a base consonant
+// or independent vowel with its subscript consonants, vowels and signs. Runs
+// of other characters stay whole.
+func SplitSyllables(word string) []string {
+	runes := []rune(word)
+	var pieces []string
+	start := 0
+	for i := 0; i < len(runes); {
+		length := getKhmerClusterLength(runes, i, len(runes))
+		if isClusterStart(runes[i]) {
+			if start < i {
+				pieces = append(pieces, string(runes[start:i]))
+			}
+			pieces = append(pieces, string(runes[i:i+length]))
+			start = i + length
+		}
+		i += length
+	}
+	if start < len(runes) {
+		pieces = append(pieces, string(runes[start:]))
+	}
+	return pieces
+}
+
+// isClusterStart reports whether r begins a Khmer cluster: a base consonant
+// or an independent vowel
+func isClusterStart(r rune) bool {
+	return r >= 0x1780 && r <= 0x17B3
+}
+
+// SubwordSplitter splits out-of-vocabulary segments into smaller pieces, so
+// that models trained on the output see a bounded vocabulary. Dictionary
+// words, numbers, acronyms and separators are left whole. It implements
+// PostProcessor so it can be appended to a segmenter's pipeline.
+type SubwordSplitter struct {
+	// BPE, when set, splits segments into its pieces; otherwise they are
+	// split into syllables (see SplitSyllables)
+	BPE *BPE
+}
+
+// Name returns the pass name
+func (s *SubwordSplitter) Name() string { return PassSubword }
+
+// Process implements PostProcessor
+func (s *SubwordSplitter) Process(segments []string, dict *Dictionary) []string {
+	out := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if seg == "" || isSeparatorSegment(seg) || !isOOV(seg, dict) {
+			out = append(out, seg)
+			continue
+		}
+		out = append(out, s.Split(seg)...)
+	}
+	return out
+}
+
+// Split returns the pieces of one segment
+func (s *SubwordSplitter) Split(seg string) []string {
+	if s.BPE != nil {
+		return s.BPE.Encode(seg)
+	}
+	return SplitSyllables(seg)
+}
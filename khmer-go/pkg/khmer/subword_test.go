@@ -0,0 +1,79 @@
+package khmer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitSyllables(t *testing.T) {
+	cases := map[string][]string{
+		"ស្រលាញ់":   {"ស្រ", "លា", "ញ់"},
+		"ក្រុមហ៊ុន": {"ក្រុ", "ម", "ហ៊ុ", "ន"},
+		"ខ្មែរABC":  {"ខ្មែ", "រ", "ABC"},
+		"":          nil,
+	}
+	for word, want := range cases {
+		if got := SplitSyllables(word); !reflect.DeepEqual(got, want) {
+			t.Errorf("SplitSyllables(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestBPE(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "merges.txt")
+	merges := "#version: 0.2\nl o\nlo w\ne r</w>\nlow er</w>\n"
+	if err := os.WriteFile(path, []byte(merges), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bpe, err := LoadBPEMerges(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string][]string{
+		"lower":  {"lower"},
+		"lowest": {"low", "e", "s", "t"},
+		// "w</w>" ends the word, so "lo w" does not apply
+		"slow": {"s", "lo", "w"},
+		"r":    {"r"},
+	}
+	for word, want := range cases {
+		if got := bpe.Encode(word); !reflect.DeepEqual(got, want) {
+			t.Errorf("Encode(%q) = %q, want %q", word, got, want)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("l o w\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBPEMerges(path); err == nil {
+		t.Error("Expected an error for a malformed merge")
+	}
+}
+
+func TestSubwordSplitter(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.PostProcessors = seg.PostProcessors.Append(&SubwordSplitter{})
+	input := "ខ្ញុំទៅភ្នំពេញ ១២៣ ស.ភ.ភ. គឃងឆ្ឈ"
+	plain := testSegmenter.Segment(input)
+	got := seg.Segment(input)
+	i := 0
+	for _, s := range plain {
+		if !isOOV(s, testSegmenter.Dictionary) || isSeparatorSegment(s) {
+			if got[i] != s {
+				t.Fatalf("Known segment %q became %q", s, got[i])
+			}
+			i++
+			continue
+		}
+		pieces := SplitSyllables(s)
+		if !reflect.DeepEqual(got[i:i+len(pieces)], pieces) {
+			t.Fatalf("OOV segment %q gave %q, want %q", s, got[i:i+len(pieces)], pieces)
+		}
+		i += len(pieces)
+	}
+	if i != len(got) || len(got) == len(plain) {
+		t.Errorf("Got %q from %q", got, plain)
+	}
+}
@@ -0,0 +1,132 @@
+package khmer
+
+import "sort"
+
+// Edit costs used by Suggest. Typing errors in Khmer are most often a wrong,
+// missing or misordered vowel sign, diacritic or coeng, so those edits cost
+// half as much as edits involving base characters.
+const (
+	editCost     = float32(1.0)
+	markEditCost = float32(0.5)
+)
+
+// isMark reports whether r is a combining mark: coeng, dependent vowel or sign
+func isMark(r rune) bool {
+	return IsCoeng(r) || IsDependentVowel(r) || IsSign(r)
+}
+
+// indelCost is the cost of inserting or deleting r
+func indelCost(r rune) float32 {
+	if isMark(r) {
+		return markEditCost
+	}
+	return editCost
+}
+
+// substituteCost is the cost of replacing a with b
+func substituteCost(a, b rune) float32 {
+	switch {
+	case a == b:
+		return 0
+	case IsDependentVowel(a) && IsDependentVowel(b), IsSign(a) && IsSign(b):
+		return markEditCost
+	}
+	return editCost
+}
+
+// transposeCost is the cost of swapping adjacent a and b
+func transposeCost(a, b rune) float32 {
+	if isMark(a) && isMark(b) {
+		return markEditCost
+	}
+	return editCost
+}
+
+// suggestion is a candidate found by Suggest
+type suggestion struct {
+	word string
+	dist float32
+	cost float32
+}
+
+// Suggest returns dictionary words within maxDist edits of word, closest first
+// and, among equally close words, most frequent (lowest cost) first. Edits are
+// insertions, deletions, substitutions and adjacent transpositions; those that
+// only touch vowel signs, diacritics or coeng count as half an edit. A word
+// that is already in the dictionary is returned first with distance 0.
+func (d *Dictionary) Suggest(word string, maxDist int) []string {
+	if d.trie == nil || maxDist < 0 {
+		return nil
+	}
+	target := []rune(word)
+	limit := float32(maxDist)
+	cols := len(target) + 1
+
+	// rows[i] holds the edit distances between the i-rune trie prefix and every
+	// prefix of target; rows are reused across the depth-first walk
+	rows := [][]float32{make([]float32, cols)}
+	for j := 1; j < cols; j++ {
+		rows[0][j] = rows[0][j-1] + indelCost(target[j-1])
+	}
+
+	var found []suggestion
+	path := make([]rune, 0, d.MaxWordLength)
+
+	var walk func(node *TrieNode, depth int)
+	walk = func(node *TrieNode, depth int) {
+		for _, r := range node.sortedChildren() {
+			child := node.getChild(r)
+			if len(rows) <= depth+1 {
+				rows = append(rows, make([]float32, cols))
+			}
+			prev, row := rows[depth], rows[depth+1]
+			path = append(path, r)
+
+			row[0] = prev[0] + indelCost(r)
+			rowMin := row[0]
+			for j := 1; j < cols; j++ {
+				t := target[j-1]
+				best := prev[j-1] + substituteCost(r, t)
+				if c := prev[j] + indelCost(r); c < best {
+					best = c
+				}
+				if c := row[j-1] + indelCost(t); c < best {
+					best = c
+				}
+				if depth > 0 && j > 1 && r == target[j-2] && path[depth-1] == t {
+					if c := rows[depth-1][j-2] + transposeCost(r, t); c < best {
+						best = c
+					}
+				}
+				row[j] = best
+				if best < rowMin {
+					rowMin = best
+				}
+			}
+
+			if child.isWord && row[cols-1] <= limit {
+				found = append(found, suggestion{word: string(path), dist: row[cols-1], cost: child.cost})
+			}
+			if rowMin <= limit {
+				walk(child, depth+1)
+			}
+			path = path[:depth]
+		}
+	}
+	walk(d.trie, 0)
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		if found[i].cost != found[j].cost {
+			return found[i].cost < found[j].cost
+		}
+		return found[i].word < found[j].word
+	})
+	words := make([]string, len(found))
+	for i, s := range found {
+		words[i] = s.word
+	}
+	return words
+}
@@ -0,0 +1,47 @@
+package khmer
+
+import "testing"
+
+func TestSuggestCorrectsMissingVowel(t *testing.T) {
+	got := testSegmenter.Dictionary.Suggest("កម្ពជា", 1)
+	if len(got) == 0 || got[0] != "កម្ពុជា" {
+		t.Errorf("Expected កម្ពុជា first, got %v", got)
+	}
+}
+
+func TestSuggestExactMatchFirst(t *testing.T) {
+	got := testSegmenter.Dictionary.Suggest("សួស្តី", 1)
+	if len(got) == 0 || got[0] != "សួស្តី" {
+		t.Errorf("Expected the word itself first, got %v", got)
+	}
+}
+
+func TestSuggestMarkEditsAreCheaper(t *testing.T) {
+	// A missing vowel sign is half an edit: found within 1, but not within 0
+	got := testSegmenter.Dictionary.Suggest("ភាសាខ្មរ", 1)
+	if len(got) != 1 || got[0] != "ភាសាខ្មែរ" {
+		t.Errorf("Expected [ភាសាខ្មែរ], got %v", got)
+	}
+	if got := testSegmenter.Dictionary.Suggest("ភាសាខ្មរ", 0); len(got) != 0 {
+		t.Errorf("Expected no suggestions at distance 0, got %v", got)
+	}
+}
+
+func TestSuggestSwappedMarks(t *testing.T) {
+	got := testSegmenter.Dictionary.Suggest("ខ្ញំុ", 1)
+	if len(got) == 0 || got[0] != "ខ្ញុំ" {
+		t.Errorf("Expected ខ្ញុំ first, got %v", got)
+	}
+}
+
+func TestEditCosts(t *testing.T) {
+	if c := substituteCost('ិ', 'ី'); c != markEditCost {
+		t.Errorf("vowel substitution: got %v", c)
+	}
+	if c := substituteCost('ក', 'ខ'); c != editCost {
+		t.Errorf("consonant substitution: got %v", c)
+	}
+	if c := transposeCost('ុ', 'ំ'); c != markEditCost {
+		t.Errorf("mark transposition: got %v", c)
+	}
+}
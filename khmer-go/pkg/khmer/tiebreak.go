@@ -0,0 +1,43 @@
+package khmer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TieBreak chooses between segmentations of exactly equal cost. Ties are
+// common because every dictionary word without a frequency shares
+// DefaultCost, and unknown clusters share UnknownCost.
+type TieBreak int
+
+const (
+	// TieBreakLongestLast keeps the path whose final word is longest, i.e.
+	// whose last boundary comes earliest. Applied at every position of the
+	// Viterbi loop, this is the behaviour of the reference implementations.
+	TieBreakLongestLast TieBreak = iota
+	// TieBreakFewestSegments keeps the path with the fewest segments and
+	// falls back to TieBreakLongestLast when those are equal too
+	TieBreakFewestSegments
+)
+
+var tieBreakNames = map[TieBreak]string{
+	TieBreakLongestLast:    "longest-last",
+	TieBreakFewestSegments: "fewest-segments",
+}
+
+func (t TieBreak) String() string {
+	if name, ok := tieBreakNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TieBreak(%d)", int(t))
+}
+
+// TieBreakByName looks up a rule by its String name ("longest-last" or "fewest-segments")
+func TieBreakByName(name string) (TieBreak, error) {
+	for t, n := range tieBreakNames {
+		if strings.EqualFold(name, n) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tie-break rule %q (available: longest-last, fewest-segments)", name)
+}
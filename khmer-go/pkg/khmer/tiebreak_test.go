@@ -0,0 +1,44 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTieBreak(t *testing.T) {
+	// ក|ខ|គឃ and កខគ|ឃ both cost 3
+	dict := NewDictionary()
+	for word, cost := range map[string]float32{"ក": 1, "ខ": 1, "គឃ": 1, "កខគ": 2, "ឃ": 1} {
+		dict.words[word] = true
+		dict.wordCosts[word] = cost
+	}
+	dict.MaxWordLength = 3
+	dict.buildTrie()
+
+	seg := NewKhmerSegmenter(dict)
+	seg.PostProcessors = Pipeline{}
+	cases := map[TieBreak][]string{
+		TieBreakLongestLast:    {"ក", "ខ", "គឃ"},
+		TieBreakFewestSegments: {"កខគ", "ឃ"},
+	}
+	for rule, want := range cases {
+		seg.TieBreak = rule
+		// Twice, so a stale segment count from the first run would show
+		for run := 0; run < 2; run++ {
+			if got := seg.Segment("កខគឃ"); !reflect.DeepEqual(got, want) {
+				t.Errorf("%v: got %v, want %v", rule, got, want)
+			}
+		}
+	}
+}
+
+func TestTieBreakByName(t *testing.T) {
+	for _, rule := range []TieBreak{TieBreakLongestLast, TieBreakFewestSegments} {
+		if got, err := TieBreakByName(rule.String()); err != nil || got != rule {
+			t.Errorf("TieBreakByName(%q) = %v, %v", rule.String(), got, err)
+		}
+	}
+	if _, err := TieBreakByName("random"); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+}
@@ -0,0 +1,36 @@
+package khmer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	text := strings.Repeat("ខ្ញុំទៅសាលារៀន abc ", 100)
+
+	// A budget that cannot be met falls back to clusters for most of the line
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.Timeout = time.Nanosecond
+	seg.Cache = NewSegmentCache(10)
+	segments := seg.Segment(text)
+	if got := strings.Join(segments, ""); got != text {
+		t.Fatalf("timed-out segments join to %q, want the input", got)
+	}
+	if !seg.timedOut {
+		t.Error("timedOut not set")
+	}
+	if st := seg.Cache.Stats(); st.Entries != 0 {
+		t.Errorf("timed-out line was cached (%d entries)", st.Entries)
+	}
+
+	// A generous budget changes nothing
+	seg.Timeout = time.Minute
+	if got, want := seg.Segment(text), testSegmenter.Segment(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Segment with a generous Timeout = %q, want %q", got, want)
+	}
+	if seg.timedOut {
+		t.Error("timedOut set within the budget")
+	}
+}
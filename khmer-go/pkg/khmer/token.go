@@ -0,0 +1,108 @@
+package khmer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TokenType classifies an output segment
+type TokenType string
+
+// Token types assigned by ClassifyToken
+const (
+	TokenKhmerWord    TokenType = "KHMER_WORD"
+	TokenKhmerUnknown TokenType = "KHMER_UNKNOWN"
+	TokenNumber       TokenType = "NUMBER"
+	TokenCurrency     TokenType = "CURRENCY"
+	TokenPunct        TokenType = "PUNCT"
+	TokenLatin        TokenType = "LATIN"
+	TokenSpace        TokenType = "SPACE"
+	TokenAcronym      TokenType = "ACRONYM"
+	TokenSymbol       TokenType = "SYMBOL"
+)
+
+// Token is a segment together with its type
+type Token struct {
+	Text string
+	Type TokenType
+}
+
+// Tokenize segments text and classifies each segment; gazetteer entries
+// take their gazetteer's entity type
+func (s *KhmerSegmenter) Tokenize(text string) []Token {
+	segments := s.Segment(text)
+	types := ClassifyTokens(segments, s.Dictionary)
+	TagEntities(types, segments, s.Gazetteers)
+	tokens := make([]Token, len(segments))
+	for i, seg := range segments {
+		tokens[i] = Token{Text: seg, Type: types[i]}
+	}
+	return tokens
+}
+
+// ClassifyTokens returns the type of each segment
+func ClassifyTokens(segments []string, dict *Dictionary) []TokenType {
+	types := make([]TokenType, len(segments))
+	for i, seg := range segments {
+		types[i] = ClassifyToken(seg, dict)
+	}
+	return types
+}
+
+// ClassifyToken returns the type of a single segment. Khmer segments are
+// KHMER_WORD when the dictionary (or the valid single-character list) knows
+// them and KHMER_UNKNOWN otherwise; LATIN covers letters of any non-Khmer script.
+// Lunar date and divination symbols (U+19E0 - U+19FF) are SYMBOL.
+func ClassifyToken(seg string, dict *Dictionary) TokenType {
+	var hasDigit, hasCurrency, hasKhmer, hasLetter, hasDot bool
+	allSpace, allSeparator, allSymbol := true, true, true
+	for _, r := range seg {
+		if !IsLunarSymbol(r) {
+			allSymbol = false
+		}
+		isSpace := unicode.IsSpace(r) || r == '\u200b'
+		if !isSpace {
+			allSpace = false
+		}
+		if !isSpace && !IsSeparator(r) {
+			allSeparator = false
+		}
+		switch {
+		case IsDigit(r):
+			hasDigit = true
+		case IsCurrencySymbol(r):
+			hasCurrency = true
+		case IsKhmerChar(r):
+			hasKhmer = true
+		case r == '.':
+			hasDot = true
+		case unicode.IsLetter(r):
+			hasLetter = true
+		}
+	}
+
+	switch {
+	case seg == "" || allSpace:
+		return TokenSpace
+	case allSymbol:
+		return TokenSymbol
+	case hasDigit && hasCurrency:
+		return TokenCurrency
+	case hasDigit && !hasKhmer && !hasLetter:
+		return TokenNumber
+	case allSeparator:
+		return TokenPunct
+	case hasKhmer:
+		runes := []rune(seg)
+		if hasDot && len(runes) >= 2 && strings.ContainsFunc(seg, IsConsonant) {
+			return TokenAcronym
+		}
+		if dict.Has(seg) || (len(runes) == 1 && IsValidSingleWord(runes[0])) {
+			return TokenKhmerWord
+		}
+		return TokenKhmerUnknown
+	case hasLetter || hasDigit:
+		return TokenLatin
+	}
+	return TokenPunct
+}
@@ -0,0 +1,61 @@
+package khmer
+
+import "testing"
+
+func TestClassifyToken(t *testing.T) {
+	cases := []struct {
+		seg  string
+		want TokenType
+	}{
+		{"សួស្តី", TokenKhmerWord},
+		{"ក", TokenKhmerWord},
+		{"ឃ្ឃ្ឃ", TokenKhmerUnknown},
+		{"១២៣", TokenNumber},
+		{"1,000.50", TokenNumber},
+		{"$100", TokenCurrency},
+		{"៛5000", TokenCurrency},
+		{"។", TokenPunct},
+		{"(", TokenPunct},
+		{"hello", TokenLatin},
+		{" ", TokenSpace},
+		{"\u200b", TokenSpace},
+		{"ស.ភ.", TokenAcronym},
+		{"\u19E0", TokenSymbol},
+	}
+	for _, tc := range cases {
+		if got := ClassifyToken(tc.seg, testSegmenter.Dictionary); got != tc.want {
+			t.Errorf("ClassifyToken(%q) = %s, want %s", tc.seg, got, tc.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := testSegmenter.Tokenize("ខ្ញុំ 100")
+	want := []Token{{"ខ្ញុំ", TokenKhmerWord}, {" ", TokenSpace}, {"100", TokenNumber}}
+	if len(tokens) != len(want) {
+		t.Fatalf("Tokenize = %v, want %v", tokens, want)
+	}
+	for i := range want {
+		if tokens[i] != want[i] {
+			t.Errorf("token %d = %v, want %v", i, tokens[i], want[i])
+		}
+	}
+}
+
+func TestLunarSymbols(t *testing.T) {
+	// Each symbol is its own segment, even between unknown clusters
+	input := "ខែ\u19E6\u19F1ឃ្ឃ\u19E0ឃ្ឃ"
+	tokens := testSegmenter.Tokenize(input)
+	symbols := 0
+	for _, tok := range tokens {
+		if tok.Type == TokenSymbol {
+			if len([]rune(tok.Text)) != 1 {
+				t.Errorf("Symbol token %q holds more than one symbol", tok.Text)
+			}
+			symbols++
+		}
+	}
+	if symbols != 3 {
+		t.Errorf("Tokenize(%q) = %v, want 3 SYMBOL tokens", input, tokens)
+	}
+}
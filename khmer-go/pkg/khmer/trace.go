@@ -0,0 +1,30 @@
+package khmer
+
+// Tracer receives the stages of loading and segmentation, so tracing systems
+// such as OpenTelemetry can show where time goes (see pkg/khmerotel).
+// StartSpan begins a stage and returns the function that ends it. Stages do
+// not nest; a tracer bound to a request makes them children of its span.
+type Tracer interface {
+	StartSpan(name string) (end func())
+}
+
+// Stage names passed to Tracer.StartSpan
+const (
+	SpanLoadDictionary  = "khmer.load.dictionary"
+	SpanLoadFrequencies = "khmer.load.frequencies"
+	SpanLoadCompiled    = "khmer.load.compiled"
+	SpanBuildTrie       = "khmer.load.trie"
+	SpanNormalize       = "khmer.normalize"
+	SpanViterbi         = "khmer.viterbi"
+	SpanPostProcess     = "khmer.postprocess"
+)
+
+func endNothing() {}
+
+// startSpan starts a stage on t, which may be nil
+func startSpan(t Tracer, name string) func() {
+	if t == nil {
+		return endNothing
+	}
+	return t.StartSpan(name)
+}
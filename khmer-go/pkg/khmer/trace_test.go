@@ -0,0 +1,55 @@
+package khmer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// recordingTracer records the stages it sees and checks each is ended
+type recordingTracer struct {
+	started, ended []string
+}
+
+func (r *recordingTracer) StartSpan(name string) func() {
+	r.started = append(r.started, name)
+	return func() { r.ended = append(r.ended, name) }
+}
+
+func TestTracerSegment(t *testing.T) {
+	rec := &recordingTracer{}
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.Tracer = rec
+	seg.Segment("ខ្ញុំទៅសាលារៀន")
+	want := []string{SpanNormalize, SpanViterbi, SpanPostProcess}
+	if !reflect.DeepEqual(rec.started, want) || !reflect.DeepEqual(rec.ended, want) {
+		t.Errorf("Started %v, ended %v, want %v", rec.started, rec.ended, want)
+	}
+
+	// Empty input ends its normalize stage too
+	rec.started, rec.ended = nil, nil
+	seg.Segment("\u200b")
+	if len(rec.started) != 1 || len(rec.ended) != 1 {
+		t.Errorf("Empty input: started %v, ended %v", rec.started, rec.ended)
+	}
+}
+
+func TestTracerLoad(t *testing.T) {
+	dir := t.TempDir()
+	dictPath := filepath.Join(dir, "words.txt")
+	freqPath := filepath.Join(dir, "freq.json")
+	os.WriteFile(dictPath, []byte("ខ្ញុំ\nទៅ\n"), 0o644)
+	os.WriteFile(freqPath, []byte(`{"ខ្ញុំ": 10}`), 0o644)
+
+	rec := &recordingTracer{}
+	dict := NewDictionary()
+	dict.Tracer = rec
+	if err := dict.Load(dictPath, freqPath); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{SpanLoadDictionary, SpanLoadFrequencies, SpanBuildTrie}
+	if !reflect.DeepEqual(rec.started, want) || !reflect.DeepEqual(rec.ended, want) {
+		t.Errorf("Started %v, ended %v, want %v", rec.started, rec.ended, want)
+	}
+}
@@ -0,0 +1,82 @@
+package khmer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FrequencyCounter accumulates word counts for building a frequency file.
+// Only tokens present in the dictionary are counted, matching generate_frequencies.py.
+type FrequencyCounter struct {
+	Counts map[string]int
+	dict   *Dictionary
+}
+
+// NewFrequencyCounter creates a counter that filters tokens against dict
+func NewFrequencyCounter(dict *Dictionary) *FrequencyCounter {
+	return &FrequencyCounter{Counts: make(map[string]int), dict: dict}
+}
+
+// Add counts the dictionary words among tokens
+func (c *FrequencyCounter) Add(tokens []string) {
+	for _, tok := range tokens {
+		if c.dict.Has(tok) {
+			c.Counts[tok]++
+		}
+	}
+}
+
+// Merge adds the counts from other into c
+func (c *FrequencyCounter) Merge(other *FrequencyCounter) {
+	for word, n := range other.Counts {
+		c.Counts[word] += n
+	}
+}
+
+// SplitAnnotated splits text whose word boundaries are marked with zero-width
+// spaces. Whitespace also separates tokens; empty tokens are dropped.
+func SplitAnnotated(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\u200b' || r == ' ' || r == '\t'
+	})
+}
+
+// WriteFrequencies writes counts as a JSON object sorted by descending count
+// (ties broken alphabetically), in the same layout as the Python generator.
+func WriteFrequencies(w io.Writer, counts map[string]int) error {
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("{")
+	for i, word := range words {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		key, err := json.Marshal(word)
+		if err != nil {
+			return err
+		}
+		bw.WriteString("\n    ")
+		bw.Write(key)
+		bw.WriteString(": ")
+		bw.WriteString(strconv.Itoa(counts[word]))
+	}
+	if len(words) > 0 {
+		bw.WriteString("\n")
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
@@ -0,0 +1,30 @@
+package khmer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSplitAnnotated(t *testing.T) {
+	result := SplitAnnotated("ខ្ញុំ\u200bទៅ\u200b\u200bសាលា រៀន")
+	expected := []string{"ខ្ញុំ", "ទៅ", "សាលា", "រៀន"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestFrequencyCounterAndWrite(t *testing.T) {
+	c := NewFrequencyCounter(testSegmenter.Dictionary)
+	c.Add(testSegmenter.Segment("ខ្ញុំទៅសាលារៀន"))
+	c.Add([]string{"ខ្ញុំ", "xyz"})
+
+	var buf bytes.Buffer
+	if err := WriteFrequencies(&buf, c.Counts); err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n    \"ខ្ញុំ\": 2,\n    \"ទៅ\": 1,\n    \"សាលារៀន\": 1\n}\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,98 @@
+package khmer
+
+import "unicode/utf8"
+
+// TrieStats describes the shape of a dictionary's trie
+type TrieStats struct {
+	Nodes int
+	// WordNodes end a word; Leaves have no children
+	WordNodes int
+	Leaves    int
+	// NodesByDepth[d] counts the nodes d runes below the root
+	NodesByDepth []int
+	// ChildCounts[k] counts the nodes with k children
+	ChildCounts []int
+	// AvgBranching is the mean number of children of nodes that have any
+	AvgBranching float64
+	MaxBranching int
+	// ByteNodes counts the nodes of the byte-keyed trie, including those
+	// inside multi-byte runes that the rune-level counts above skip
+	ByteNodes int
+	// Lengths[l] describes the dictionary's words of l runes
+	Lengths []LengthStats
+}
+
+// LengthStats counts the words of one length and how many have a cost from
+// the frequency file
+type LengthStats struct {
+	Words       int
+	CostedWords int
+}
+
+// walk calls visit for n and every node a whole number of runes below it,
+// with its depth in runes
+func (n *TrieNode) walk(depth int, visit func(n *TrieNode, depth int)) {
+	visit(n, depth)
+	n.eachChild(func(_ rune, child *TrieNode) {
+		child.walk(depth+1, visit)
+	})
+}
+
+// walkBytes calls visit for n and every node below it
+func (n *TrieNode) walkBytes(visit func(n *TrieNode)) {
+	visit(n)
+	for _, child := range n.children {
+		child.walkBytes(visit)
+	}
+}
+
+// TrieStats walks the trie and the word list, to guide data-structure
+// tuning (node counts and branching) and dictionary curation (coverage by length)
+func (d *Dictionary) TrieStats() TrieStats {
+	var st TrieStats
+	branching := 0
+	if d.trie != nil {
+		d.trie.walk(0, func(n *TrieNode, depth int) {
+			st.Nodes++
+			if n.isWord {
+				st.WordNodes++
+			}
+			for len(st.NodesByDepth) <= depth {
+				st.NodesByDepth = append(st.NodesByDepth, 0)
+			}
+			st.NodesByDepth[depth]++
+
+			children := 0
+			n.eachChild(func(rune, *TrieNode) { children++ })
+			for len(st.ChildCounts) <= children {
+				st.ChildCounts = append(st.ChildCounts, 0)
+			}
+			st.ChildCounts[children]++
+			if children == 0 {
+				st.Leaves++
+			} else {
+				branching += children
+			}
+			if children > st.MaxBranching {
+				st.MaxBranching = children
+			}
+		})
+		d.trie.walkBytes(func(*TrieNode) { st.ByteNodes++ })
+	}
+	if internal := st.Nodes - st.Leaves; internal > 0 {
+		st.AvgBranching = float64(branching) / float64(internal)
+	}
+
+	st.Lengths = make([]LengthStats, d.MaxWordLength+1)
+	for word := range d.words {
+		l := utf8.RuneCountInString(word)
+		for len(st.Lengths) <= l {
+			st.Lengths = append(st.Lengths, LengthStats{})
+		}
+		st.Lengths[l].Words++
+		if _, ok := d.wordCosts[word]; ok {
+			st.Lengths[l].CostedWords++
+		}
+	}
+	return st
+}
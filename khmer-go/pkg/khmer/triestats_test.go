@@ -0,0 +1,39 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrieStats(t *testing.T) {
+	dict := NewDictionary()
+	for _, word := range []string{"ក", "កខ", "កគ", "កខគ", "a"} {
+		dict.words[word] = true
+	}
+	dict.wordCosts["កខ"] = 1
+	dict.MaxWordLength = 3
+	dict.buildTrie()
+
+	st := dict.TrieStats()
+	// root -> ក, a; ក -> ខ, គ; កខ -> គ
+	if st.Nodes != 6 || st.WordNodes != 5 || st.Leaves != 3 {
+		t.Errorf("Got %d nodes, %d word nodes, %d leaves; want 6, 5, 3", st.Nodes, st.WordNodes, st.Leaves)
+	}
+	if want := []int{1, 2, 2, 1}; !reflect.DeepEqual(st.NodesByDepth, want) {
+		t.Errorf("NodesByDepth = %v, want %v", st.NodesByDepth, want)
+	}
+	if want := []int{3, 1, 2}; !reflect.DeepEqual(st.ChildCounts, want) {
+		t.Errorf("ChildCounts = %v, want %v", st.ChildCounts, want)
+	}
+	if st.MaxBranching != 2 || st.AvgBranching != 5.0/3 {
+		t.Errorf("Branching avg %v max %d", st.AvgBranching, st.MaxBranching)
+	}
+	// Khmer runes are three bytes; ខ and គ share their first two
+	if st.ByteNodes != 12 {
+		t.Errorf("Got %d byte nodes, want 12", st.ByteNodes)
+	}
+	want := []LengthStats{{}, {Words: 2}, {Words: 2, CostedWords: 1}, {Words: 1}}
+	if !reflect.DeepEqual(st.Lengths, want) {
+		t.Errorf("Lengths = %v, want %v", st.Lengths, want)
+	}
+}
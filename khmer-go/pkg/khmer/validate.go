@@ -0,0 +1,146 @@
+package khmer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kinds of dictionary problems reported by ValidateDictionary
+const (
+	IssueCoengInitial     = "coeng-initial"
+	IssueDanglingMark     = "dangling-mark"
+	IssueDanglingCoeng    = "dangling-coeng"
+	IssueMixedScript      = "mixed-script"
+	IssueWhitespace       = "whitespace"
+	IssueInvalidSingle    = "invalid-single"
+	IssueRepetitionMark   = "repetition-mark"
+	IssueDuplicate        = "duplicate"
+	IssueVariantDuplicate = "variant-duplicate"
+	IssueOrCompound       = "or-compound"
+)
+
+// DictionaryIssue is a problem found in a dictionary entry
+type DictionaryIssue struct {
+	Line    int
+	Word    string
+	Kind    string
+	Message string
+}
+
+func (i DictionaryIssue) String() string {
+	return fmt.Sprintf("%d: %s: %s (%q)", i.Line, i.Kind, i.Message, i.Word)
+}
+
+// ValidateDictionary checks a text dictionary (one word per line) for entries
+// that loadDictionary would drop or that cannot match well-formed Khmer text.
+// Issues are returned in line order.
+func ValidateDictionary(r io.Reader) ([]DictionaryIssue, error) {
+	var issues []DictionaryIssue
+	firstSeen := make(map[string]int)
+	// variantOf maps each generated variant to the line of the entry that produced it
+	variantOf := make(map[string]int)
+	expander := &Dictionary{}
+	// loaded is the word set loading builds (entries other than invalid
+	// single characters, and their variants), which the ឬ compound check
+	// looks parts up in; entries are the distinct loaded entries
+	loaded := make(map[string]bool)
+	var entries []DictionaryIssue
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" {
+			continue
+		}
+		report := func(kind, msg string) {
+			issues = append(issues, DictionaryIssue{Line: lineNo, Word: word, Kind: kind, Message: msg})
+		}
+
+		if prev, ok := firstSeen[word]; ok {
+			report(IssueDuplicate, fmt.Sprintf("duplicate of line %d", prev))
+			continue
+		}
+		firstSeen[word] = lineNo
+		if runes := []rune(word); len(runes) > 1 || IsValidSingleWord(runes[0]) {
+			loaded[word] = true
+			entries = append(entries, DictionaryIssue{Line: lineNo, Word: word, Kind: IssueOrCompound})
+		}
+		prevVariant, isVariant := variantOf[word]
+		if isVariant {
+			report(IssueVariantDuplicate, fmt.Sprintf("already generated as a variant of line %d", prevVariant))
+		}
+		for _, v := range expander.generateVariants(word) {
+			if prev, ok := firstSeen[v]; ok && prev != lineNo && !(isVariant && prev == prevVariant) {
+				report(IssueVariantDuplicate, fmt.Sprintf("variant %q duplicates line %d", v, prev))
+			}
+			if _, ok := variantOf[v]; !ok {
+				variantOf[v] = lineNo
+			}
+			loaded[v] = true
+		}
+
+		for _, issue := range checkEntry(word) {
+			report(issue[0], issue[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return issues, err
+	}
+
+	// Compounds need the whole word set, so they are checked once every
+	// line is read and merged into line order
+	for _, e := range entries {
+		if isOrCompound(e.Word, loaded) {
+			e.Message = "joins dictionary words with ឬ and is dropped"
+			issues = append(issues, e)
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues, nil
+}
+
+// checkEntry returns (kind, message) pairs for malformed spellings in a single entry
+func checkEntry(word string) [][2]string {
+	var problems [][2]string
+	runes := []rune(word)
+
+	if len(runes) == 1 && !IsValidSingleWord(runes[0]) {
+		problems = append(problems, [2]string{IssueInvalidSingle, "single character is not a valid standalone word"})
+	}
+	if IsCoeng(runes[0]) {
+		problems = append(problems, [2]string{IssueCoengInitial, "starts with coeng (U+17D2)"})
+	} else if IsDependentVowel(runes[0]) || IsSign(runes[0]) {
+		problems = append(problems, [2]string{IssueDanglingMark, fmt.Sprintf("starts with combining mark U+%04X", runes[0])})
+	}
+	for i, r := range runes {
+		if IsCoeng(r) && (i+1 >= len(runes) || !IsConsonant(runes[i+1])) {
+			problems = append(problems, [2]string{IssueDanglingCoeng, fmt.Sprintf("coeng at position %d is not followed by a consonant", i)})
+			break
+		}
+	}
+	if strings.ContainsRune(word, 'ៗ') {
+		problems = append(problems, [2]string{IssueRepetitionMark, "contains repetition mark ៗ"})
+	}
+	if strings.IndexFunc(word, unicode.IsSpace) >= 0 || strings.ContainsRune(word, '\u200b') {
+		problems = append(problems, [2]string{IssueWhitespace, "contains whitespace or zero-width space"})
+	}
+
+	hasKhmer, hasOther := false, false
+	for _, r := range runes {
+		if IsKhmerChar(r) {
+			hasKhmer = true
+		} else if unicode.IsLetter(r) {
+			hasOther = true
+		}
+	}
+	if hasKhmer && hasOther {
+		problems = append(problems, [2]string{IssueMixedScript, "mixes Khmer with letters from another script"})
+	}
+	return problems
+}
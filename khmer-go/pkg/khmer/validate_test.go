@@ -0,0 +1,52 @@
+package khmer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDictionary(t *testing.T) {
+	input := strings.Join([]string{
+		"សួស្តី",
+		"្ត",
+		"ាក",
+		"ក្",
+		"abcក",
+		"សួស្តី",
+		"ឃ",
+		"ពេលៗ",
+		"កញ្ជ្រឹល",
+		"កញ្រ្ជឹល",
+		"ឬសួស្តី",
+	}, "\n")
+	issues, err := ValidateDictionary(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]string{
+		2:  IssueCoengInitial,
+		3:  IssueDanglingMark,
+		4:  IssueDanglingCoeng,
+		5:  IssueMixedScript,
+		6:  IssueDuplicate,
+		7:  IssueInvalidSingle,
+		8:  IssueRepetitionMark,
+		10: IssueVariantDuplicate,
+		11: IssueOrCompound,
+	}
+	found := make(map[int]string)
+	for _, issue := range issues {
+		if _, ok := found[issue.Line]; !ok {
+			found[issue.Line] = issue.Kind
+		}
+	}
+	for line, kind := range expected {
+		if found[line] != kind {
+			t.Errorf("Line %d: expected %s, got %q", line, kind, found[line])
+		}
+	}
+	if _, ok := found[1]; ok {
+		t.Errorf("Unexpected issue on valid line 1: %v", issues)
+	}
+}
@@ -0,0 +1,125 @@
+package khmer
+
+import "strings"
+
+// Precompiled patterns as simple string operations
+var (
+	coengTa = "\u17D2\u178F"
+	coengDa = "\u17D2\u178D"
+	coengRo = "\u17D2\u179A"
+)
+
+// VariantGenerator adds orthographic variants of dictionary words, such as
+// common misspellings or alternative sign orders, so that text written
+// either way matches. A variant gets the word's frequency.
+type VariantGenerator interface {
+	Name() string
+	// Variants returns the variants of word; word itself may be included
+	Variants(word string) []string
+}
+
+// VariantGeneratorFunc adapts a callback into a VariantGenerator
+type VariantGeneratorFunc struct {
+	GenName string
+	Fn      func(word string) []string
+}
+
+// Name returns the generator name
+func (g VariantGeneratorFunc) Name() string { return g.GenName }
+
+// Variants runs the wrapped callback
+func (g VariantGeneratorFunc) Variants(word string) []string { return g.Fn(word) }
+
+// DefaultVariantGenerators returns the built-in rules: Coeng Ta and Coeng Da
+// written for each other (coeng-ta-da), then Coeng Ro before or after
+// another subscript (coeng-ro-order). Append to it to keep them.
+func DefaultVariantGenerators() []VariantGenerator {
+	return []VariantGenerator{
+		VariantGeneratorFunc{GenName: "coeng-ta-da", Fn: swapCoengTaDa},
+		VariantGeneratorFunc{GenName: "coeng-ro-order", Fn: func(word string) []string {
+			return []string{swapCoengRoOrder(word)}
+		}},
+	}
+}
+
+// generateVariants runs the variant generators in order. Each sees the word
+// and the variants the earlier ones produced, so that, for instance, the
+// Coeng Ro order is also swapped in the Coeng Da spelling of a word.
+func (d *Dictionary) generateVariants(word string) []string {
+	if d.DisableVariants {
+		return nil
+	}
+	generators := d.VariantGenerators
+	if generators == nil {
+		generators = DefaultVariantGenerators()
+	}
+
+	seen := map[string]bool{word: true}
+	forms := []string{word}
+	for _, g := range generators {
+		n := len(forms)
+		for _, w := range forms[:n] {
+			for _, v := range g.Variants(w) {
+				if v != "" && !seen[v] {
+					seen[v] = true
+					forms = append(forms, v)
+				}
+			}
+		}
+	}
+	return forms[1:]
+}
+
+// swapCoengTaDa returns word with Coeng Ta written as Coeng Da, and with
+// Coeng Da written as Coeng Ta
+func swapCoengTaDa(word string) []string {
+	var variants []string
+	if strings.Contains(word, coengTa) {
+		variants = append(variants, strings.ReplaceAll(word, coengTa, coengDa))
+	}
+	if strings.Contains(word, coengDa) {
+		variants = append(variants, strings.ReplaceAll(word, coengDa, coengTa))
+	}
+	return variants
+}
+
+// swapCoengRoOrder swaps Coeng+Ro with adjacent Coeng+X patterns
+func swapCoengRoOrder(word string) string {
+	runes := []rune(word)
+	n := len(runes)
+	if n < 4 {
+		return word
+	}
+
+	result := make([]rune, 0, n)
+	i := 0
+	changed := false
+
+	for i < n {
+		// Look for pattern: Coeng + Ro + Coeng + X
+		if i+3 < n &&
+			runes[i] == 0x17D2 && runes[i+1] == 0x179A &&
+			runes[i+2] == 0x17D2 && runes[i+3] != 0x179A {
+			result = append(result, runes[i+2], runes[i+3], runes[i], runes[i+1])
+			i += 4
+			changed = true
+			continue
+		}
+		// Look for pattern: Coeng + X + Coeng + Ro
+		if i+3 < n &&
+			runes[i] == 0x17D2 && runes[i+1] != 0x179A &&
+			runes[i+2] == 0x17D2 && runes[i+3] == 0x179A {
+			result = append(result, runes[i+2], runes[i+3], runes[i], runes[i+1])
+			i += 4
+			changed = true
+			continue
+		}
+		result = append(result, runes[i])
+		i++
+	}
+
+	if changed {
+		return string(result)
+	}
+	return word
+}
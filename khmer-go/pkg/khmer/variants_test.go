@@ -0,0 +1,43 @@
+package khmer
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDefaultVariants(t *testing.T) {
+	// Coeng Da for Coeng Ta, and Coeng Ro moved in both spellings
+	word := "ស្ត្រី"
+	got := NewDictionary().generateVariants(word)
+	sort.Strings(got)
+	want := []string{"ស្ឍ្រី", "ស្រ្ឍី", "ស្រ្តី"}
+	sort.Strings(want)
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Variants = %q, want %q", got, want)
+	}
+}
+
+func TestCustomVariantGenerator(t *testing.T) {
+	// A common misspelling: ឲ្យ written ឱ្យ
+	misspelling := VariantGeneratorFunc{GenName: "oy", Fn: func(word string) []string {
+		return []string{strings.ReplaceAll(word, "ឲ", "ឱ")}
+	}}
+	dict := NewDictionary()
+	dict.VariantGenerators = append(DefaultVariantGenerators(), misspelling)
+	dict.addWordWithVariants("ឲ្យ")
+	dict.addWordWithVariants("ស្តី")
+	for _, word := range []string{"ឲ្យ", "ឱ្យ", "ស្តី", "ស្ឍី"} {
+		if !dict.Has(word) {
+			t.Errorf("Expected %q", word)
+		}
+	}
+
+	// The generator alone replaces the built-in rules
+	dict = NewDictionary()
+	dict.VariantGenerators = []VariantGenerator{misspelling}
+	dict.addWordWithVariants("ស្តី")
+	if dict.Size() != 1 {
+		t.Errorf("Size = %d, want the word alone", dict.Size())
+	}
+}
@@ -0,0 +1,81 @@
+package khmer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PassWhitespace is the name of the whitespace policy pass
+const PassWhitespace = "whitespace"
+
+// Whitespace is the policy for whitespace segments in the output
+type Whitespace int
+
+const (
+	// WhitespaceKeep emits whitespace segments as they are, so the segments
+	// join back into the input
+	WhitespaceKeep Whitespace = iota
+	// WhitespaceCollapse replaces each run of whitespace segments with a
+	// single " "
+	WhitespaceCollapse
+	// WhitespaceDrop removes whitespace segments
+	WhitespaceDrop
+)
+
+var whitespaceNames = map[Whitespace]string{
+	WhitespaceKeep:     "keep",
+	WhitespaceCollapse: "collapse",
+	WhitespaceDrop:     "drop",
+}
+
+func (w Whitespace) String() string {
+	if name, ok := whitespaceNames[w]; ok {
+		return name
+	}
+	return fmt.Sprintf("Whitespace(%d)", int(w))
+}
+
+// WhitespaceByName looks up a policy by its String name
+func WhitespaceByName(name string) (Whitespace, error) {
+	for w, n := range whitespaceNames {
+		if strings.EqualFold(name, n) {
+			return w, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown whitespace policy %q (available: keep, collapse, drop)", name)
+}
+
+// Name returns the pass name. A Whitespace implements PostProcessor so it
+// can be appended to a segmenter's pipeline; punctuation is left alone (see
+// StopwordFilter to remove it).
+func (w Whitespace) Name() string { return PassWhitespace }
+
+// Process implements PostProcessor
+func (w Whitespace) Process(segments []string, _ *Dictionary) []string {
+	if w == WhitespaceKeep {
+		return segments
+	}
+	out := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if !isWhitespaceSegment(seg) {
+			out = append(out, seg)
+			continue
+		}
+		if w == WhitespaceCollapse && (len(out) == 0 || out[len(out)-1] != " ") {
+			out = append(out, " ")
+		}
+	}
+	return out
+}
+
+// isWhitespaceSegment reports whether seg consists only of whitespace and
+// zero-width spaces
+func isWhitespaceSegment(seg string) bool {
+	for _, r := range seg {
+		if !unicode.IsSpace(r) && r != '\u200b' {
+			return false
+		}
+	}
+	return seg != ""
+}
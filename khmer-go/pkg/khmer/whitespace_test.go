@@ -0,0 +1,36 @@
+package khmer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhitespace(t *testing.T) {
+	segments := []string{"ខ្ញុំ", " ", "\t", "ទៅ", " ", "។", " ប់"}
+	cases := []struct {
+		policy Whitespace
+		want   []string
+	}{
+		{WhitespaceKeep, segments},
+		{WhitespaceCollapse, []string{"ខ្ញុំ", " ", "ទៅ", " ", "។", " ប់"}},
+		{WhitespaceDrop, []string{"ខ្ញុំ", "ទៅ", "។", " ប់"}},
+	}
+	for _, tc := range cases {
+		if got := tc.policy.Process(segments, nil); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.policy, got, tc.want)
+		}
+	}
+}
+
+func TestWhitespacePass(t *testing.T) {
+	seg := NewKhmerSegmenter(testSegmenter.Dictionary)
+	seg.PostProcessors = seg.PostProcessors.Append(WhitespaceDrop)
+	for _, s := range seg.Segment("ខ្ញុំ  ទៅ សាលា") {
+		if isWhitespaceSegment(s) {
+			t.Errorf("Whitespace segment %q kept", s)
+		}
+	}
+	if _, err := WhitespaceByName("nope"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,7 @@
+// Package khmerbleve provides a Bleve tokenizer backed by the Khmer
+// segmenter. It is built with the bleve tag, so the khmer module itself keeps
+// no dependencies:
+//
+//	go get github.com/blevesearch/bleve/v2
+//	go build -tags bleve ./...
+package khmerbleve
@@ -0,0 +1,85 @@
+//go:build bleve
+
+package khmerbleve
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/registry"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+// Name registers the tokenizer with Bleve; its config takes "dict" and
+// "freq" paths (see khmer.Dictionary.Load)
+const Name = "khmer"
+
+// Tokenizer implements analysis.Tokenizer. Token offsets are bytes in the
+// input, zero-width spaces included, so highlighting lines up with the
+// stored text. Spaces and punctuation are not emitted and positions count
+// from 1, as for Bleve's unicode tokenizer.
+type Tokenizer struct {
+	dict *khmer.Dictionary
+	// Segmenters keep per-instance buffers, so each Tokenize call takes one
+	pool sync.Pool
+}
+
+// NewTokenizer returns a tokenizer segmenting with dict
+func NewTokenizer(dict *khmer.Dictionary) *Tokenizer {
+	t := &Tokenizer{dict: dict}
+	t.pool.New = func() interface{} { return khmer.NewKhmerSegmenter(dict) }
+	return t
+}
+
+// Tokenize implements analysis.Tokenizer
+func (t *Tokenizer) Tokenize(input []byte) analysis.TokenStream {
+	seg := t.pool.Get().(*khmer.KhmerSegmenter)
+	spans := seg.SegmentSpans(string(input))
+	t.pool.Put(seg)
+
+	stream := make(analysis.TokenStream, 0, len(spans))
+	for _, sp := range spans {
+		typ := khmer.ClassifyToken(sp.Text, t.dict)
+		if typ == khmer.TokenSpace || typ == khmer.TokenPunct {
+			continue
+		}
+		tokenType := analysis.AlphaNumeric
+		if typ == khmer.TokenNumber || typ == khmer.TokenCurrency {
+			tokenType = analysis.Numeric
+		}
+		stream = append(stream, &analysis.Token{
+			Term:     input[sp.Start:sp.End],
+			Start:    sp.Start,
+			End:      sp.End,
+			Position: len(stream) + 1,
+			Type:     tokenType,
+		})
+	}
+	return stream
+}
+
+// TokenizerConstructor builds a Tokenizer from a Bleve config
+func TokenizerConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.Tokenizer, error) {
+	dictPath, _ := config["dict"].(string)
+	freqPath, _ := config["freq"].(string)
+	if dictPath == "" {
+		return nil, fmt.Errorf("%s tokenizer: missing \"dict\" path", Name)
+	}
+	dict := khmer.NewDictionary()
+	var err error
+	if khmer.IsCompiledDictionary(dictPath) {
+		err = dict.LoadCompiled(dictPath)
+	} else {
+		err = dict.Load(dictPath, freqPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s tokenizer: %w", Name, err)
+	}
+	return NewTokenizer(dict), nil
+}
+
+func init() {
+	registry.RegisterTokenizer(Name, TokenizerConstructor)
+}
@@ -0,0 +1,7 @@
+// Package khmerotel reports the segmenter's load and segmentation stages as
+// OpenTelemetry spans. It is built with the otel tag, so the khmer module
+// itself keeps no dependencies:
+//
+//	go get go.opentelemetry.io/otel
+//	go build -tags otel ./...
+package khmerotel
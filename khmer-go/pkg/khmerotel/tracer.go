@@ -0,0 +1,28 @@
+//go:build otel
+
+package khmerotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/khmer-segmenter/pkg/khmer"
+)
+
+type tracer struct {
+	ctx    context.Context
+	tracer trace.Tracer
+}
+
+// New returns a khmer.Tracer whose spans are children of the span in ctx,
+// e.g. the one of the request being served. Set it as KhmerSegmenter.Tracer
+// or Dictionary.Tracer.
+func New(ctx context.Context, t trace.Tracer) khmer.Tracer {
+	return tracer{ctx: ctx, tracer: t}
+}
+
+func (t tracer) StartSpan(name string) func() {
+	_, span := t.tracer.Start(t.ctx, name)
+	return func() { span.End() }
+}